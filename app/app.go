@@ -1,13 +1,18 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/liwnn/redisterm/audit"
 	"github.com/liwnn/redisterm/config"
 	"github.com/liwnn/redisterm/model"
+	"github.com/liwnn/redisterm/redis"
 	"github.com/liwnn/redisterm/redisapi"
+	"github.com/liwnn/redisterm/secret"
 	"github.com/liwnn/redisterm/tlog"
 	"github.com/liwnn/redisterm/view"
 )
@@ -18,123 +23,404 @@ type App struct {
 	main *view.MainView
 	tree *DBTree
 
-	dbTree map[string]*DBTree
+	dbTree    map[string]*DBTree
+	histories map[string]*view.History
+	stream    *redisapi.Subscriber
+	info      *infoMonitor
+	slowLog   *slowLogPage
+	clients   *clientsPage
+	memory    *memoryPage
+	compare   *comparePage
+
+	rdbFile string
+	// editIndex is the connection shown by the connection setting.
+	editIndex int
+	// name and address are the name and address of the shown connection.
+	name    string
+	address string
+	audit   *audit.Logger
+	// secrets resolves the passwords of the config, vault holds the
+	// encrypted ones.
+	secrets *secret.Resolver
+	vault   *secret.Vault
+	// prefs are the preferences of the config, configErr the errors of
+	// the config shown by Run.
+	prefs     config.Preferences
+	formatter *model.ValueFormatter
+	configErr error
 }
 
 // NewApp new
 func NewApp(cfgFile string) *App {
 	cfg, err := config.NewConfig(cfgFile)
-	if err != nil {
-		panic(err)
+	errs := []error{err, cfg.Validate()}
+	prefs := cfg.Preferences()
+	// the colours are read when the views are created.
+	if err := view.ApplyTheme(prefs.Theme); err != nil {
+		errs = append(errs, fmt.Errorf("preferences: %w", err))
 	}
+	main := view.NewMainView()
 	a := &App{
-		main:   view.NewMainView(),
-		dbTree: make(map[string]*DBTree),
-		cfg:    cfg,
+		main:      main,
+		info:      newInfoMonitor(main),
+		dbTree:    make(map[string]*DBTree),
+		histories: make(map[string]*view.History),
+		cfg:       cfg,
+		secrets:   secret.NewResolver(),
+		vault:     secret.NewVault(cfg.VaultFile()),
+		prefs:     prefs,
+		formatter: &model.ValueFormatter{},
 	}
+	for _, f := range prefs.Formatters {
+		a.formatter.AddRule(f.Pattern, f.Format)
+	}
+	if prefs.ClientName != "" {
+		redisapi.ClientName = prefs.ClientName
+	}
+	a.secrets.Register("vault", a.vault)
 	a.init()
+	errs = append(errs, a.bindKeys(prefs.Keybindings))
+	a.configErr = errors.Join(errs...)
 	return a
 }
 
 func (a *App) init() {
-	tlog.SetLogger(a.main.GetOutput())
+	tlog.SetConsole(a.main.GetConsole().Add)
 
 	a.main.GetOpLine().SetEditClickFunc(func() {
-		index := a.main.GetOpLine().GetSelect()
-		config := a.cfg.GetConfig(index)
-		setting := view.Setting{
-			Name: config.Name,
-			Host: config.Host,
-			Port: strconv.Itoa(config.Port),
-			Auth: config.Auth,
-		}
-		a.main.ShowConnSetting(setting, true)
-		tlog.Log("[App] init Edit Click: %v", setting)
+		a.editConnection(a.main.GetOpLine().GetSelect())
 	})
-
-	a.main.GetConnSetting().SetOKHandler(func(s view.Setting, edit bool) {
-		a.main.HideConnSetting()
-		if s.Name == "" {
-			return
-		}
-		port, _ := strconv.Atoi(s.Port)
-		conf := redisapi.RedisConfig{
-			Name: s.Name,
-			Host: s.Host,
-			Port: port,
-			Auth: s.Auth,
-		}
-		fmt.Fprintln(a.main.GetOutput(), edit)
-		if edit {
-			lastIndex := a.main.GetOpLine().GetSelect()
-			a.cfg.Update(conf, a.main.GetOpLine().GetSelect())
-			a.main.RefreshOpLine(a.cfg.GetDbNames(), a.Show)
-			a.main.GetOpLine().Select(lastIndex)
-		} else {
-			a.cfg.Add(conf)
-			a.main.GetOpLine().AddSelect(conf.Name)
-			a.main.GetOpLine().Select(a.main.GetOpLine().GetOptionCount() - 1)
-		}
-		if err := a.cfg.Save(); err != nil {
-			panic(err)
-		}
+	a.main.GetConnSetting().SetOKHandler(a.saveConnection)
+	picker := a.main.GetConnPicker()
+	picker.SetSelectedFunc(a.main.GetOpLine().Select)
+	picker.SetEditFunc(a.editConnection)
+	picker.SetAddFunc(func() {
+		a.main.ShowConnSetting(view.Setting{}, false)
 	})
+	picker.SetImportFunc(a.importConnections)
+	picker.SetDuplicateFunc(a.duplicateConnection)
+	picker.SetDeleteFunc(a.deleteConnection)
+	picker.SetMoveFunc(a.moveConnection)
 	a.main.GetCmd().SetEnterHandler(a.onCmdLineEnter)
-	a.main.RefreshOpLine(a.cfg.GetDbNames(), a.Show)
+	a.main.GetCmd().SetCompleteFunc(a.complete)
+	a.main.GetCmd().SetHintFunc(a.hint)
+	a.slowLog = newSlowLogPage(a)
+	a.main.AddBottomPage(a.slowLog.table.Title(), a.slowLog.table)
+	a.clients = newClientsPage(a)
+	a.main.AddBottomPage(a.clients.table.Title(), a.clients.table)
+	a.memory = newMemoryPage(a)
+	a.main.AddBottomPage(a.memory.table.Title(), a.memory.table)
+	a.compare = newComparePage(a)
+	a.main.AddBottomPage(a.compare.table.Title(), a.compare.table)
+	a.main.SetBottomPageFunc(func(title string) {
+		a.info.SetActive(title == a.main.GetInfo().Title())
+		switch title {
+		case a.slowLog.table.Title():
+			a.slowLog.Refresh()
+		case a.clients.table.Title():
+			a.clients.Refresh()
+		}
+	})
+	a.main.RefreshOpLine(a.connItems(), 0, a.Show)
+}
+
+// OpenRDB makes Run browse the RDB file name, read-only, instead of the
+// first connection.
+func (a *App) OpenRDB(name string) {
+	a.rdbFile = name
+}
+
+// OpenAudit records the write commands to the audit log filename, the
+// default one of the config when empty, rotated above maxSize bytes and
+// keeping backups older files.
+func (a *App) OpenAudit(filename string, maxSize int64, backups int) {
+	if filename == "" {
+		filename = a.cfg.AuditFile()
+	}
+	l, err := audit.Open(filename, maxSize, backups)
+	if err != nil {
+		tlog.Error("[App] open audit log", "file", filename, "err", err)
+		return
+	}
+	a.audit = l
+}
+
+// OpenLog writes the log records at level and above to filename.
+func (a *App) OpenLog(filename string, level string) {
+	l, err := tlog.ParseLevel(level)
+	if err != nil {
+		tlog.Warn("[App] log level", "level", level, "err", err)
+		l = tlog.LevelInfo
+	}
+	if err := tlog.OpenFile(filename, l); err != nil {
+		tlog.Error("[App] open log", "file", filename, "err", err)
+	}
 }
 
 // Run run
 func (a *App) Run() {
-	a.main.GetOpLine().Select(0)
+	if a.rdbFile != "" {
+		a.showRDB()
+	} else {
+		a.showConfigErr(func() {
+			a.migrateSecrets(func() {
+				a.main.GetOpLine().Select(0)
+			})
+		})
+	}
+	a.info.Start()
 
 	if err := a.main.Run(); err != nil {
 		panic(err)
 	}
 
+	a.info.Stop()
+	a.stopStream()
 	for _, client := range a.dbTree {
 		client.Close()
 	}
+	a.audit.Close()
+	tlog.Close()
+}
+
+// showConfigErr shows the errors of the config, if any, then calls done.
+func (a *App) showConfigErr(done func()) {
+	if a.configErr == nil {
+		done()
+		return
+	}
+	tlog.Error("[App] config", "err", a.configErr)
+	a.main.ShowModalOKFunc(fmt.Sprintf("The config has errors:\n%v", a.configErr), done)
+}
+
+// newData returns the data of the server of config, its password being
+// auth, split and scanned as set by the preferences.
+func (a *App) newData(config redisapi.RedisConfig, auth string) *model.Data {
+	data := model.NewData(config.Address(), auth)
+	data.SetDialOptions(config.DialOptions())
+	if a.prefs.Separator != "" {
+		data.SetSeparator(a.prefs.Separator[0])
+	}
+	data.SetPageSize(a.prefs.PageSize)
+	return data
 }
 
 // Show show
 func (a *App) Show(index int) {
 	config := a.cfg.GetConfig(index)
-	address := fmt.Sprintf("%v:%v", config.Host, config.Port)
-	t, ok := a.dbTree[address]
+	address := config.Address()
+	// connections to the same server as another user or in TLS have their
+	// own tree.
+	key := config.URL()
+	t, ok := a.dbTree[key]
 	if !ok {
-		tree := view.NewTree("db")
-		tree.GetRoot().SetReference(&Reference{
-			Name: "db",
+		a.resolveAuth(config, func(auth string) {
+			if _, ok := a.dbTree[key]; ok {
+				a.Show(index)
+				return
+			}
+			data := a.newData(config, auth)
+			if err := data.Connect(); err != nil {
+				tlog.Error("[Show] connect", "err", err)
+			} else {
+				if err := data.Select(config.DB); err != nil {
+					tlog.Error("[Show] select", "db", config.DB, "err", err)
+				}
+				a.loadCommands(data)
+			}
+			a.dbTree[key] = a.newDBTree(address, data)
+			a.Show(index)
 		})
-		preview := view.NewPreview()
-
-		t = NewDBTree(tree, preview)
-		t.ShowModalOK = a.main.ShowModalOK
-		t.ShowModal = a.main.ShowModal
-		data := model.NewData(address, config.Auth)
-		if err := data.Connect(); err != nil {
-			tlog.Log("[Show] %v", err)
+		return
+	}
+	t.data.SetReadOnly(config.ReadOnly)
+	t.data.SetGuard(model.NewGuard(config.Guard))
+	t.data.SetAudit(a.audit, config.Name)
+	a.showTree(t, config.Name, address)
+}
+
+// showRDB shows the keys of the RDB file given to OpenRDB.
+func (a *App) showRDB() {
+	name := filepath.Base(a.rdbFile)
+	data := model.NewRDBData(a.rdbFile)
+	if a.prefs.Separator != "" {
+		data.SetSeparator(a.prefs.Separator[0])
+	}
+	title := name + " (read-only)"
+	if err := data.Connect(); err != nil {
+		tlog.Error("[showRDB] open", "file", a.rdbFile, "err", err)
+		a.main.ShowModalOK(fmt.Sprintf("Open %v: %v", name, err))
+	} else {
+		f := data.RDB()
+		tlog.Info("[showRDB] opened", "file", a.rdbFile, "version", f.Version, "keys", len(f.Entries))
+		title = fmt.Sprintf("%v (RDB v%v, read-only)", name, f.Version)
+	}
+	t := a.newDBTree(title, data)
+	a.dbTree[a.rdbFile] = t
+	a.showTree(t, name, name)
+}
+
+func (a *App) newDBTree(name string, data *model.Data) *DBTree {
+	tree := view.NewTree("db")
+	tree.GetRoot().SetReference(&Reference{
+		Name: "db",
+	})
+	preview := view.NewPreview()
+
+	t := NewDBTree(tree, preview)
+	t.ShowModalOK = a.main.ShowModalOK
+	t.ShowModal = a.main.ShowModal
+	t.ShowMenu = a.main.ShowMenu
+	t.ShowProgress = a.main.ShowProgress
+	t.ShowPrompt = a.main.ShowPrompt
+	t.QueueUpdateDraw = func(f func()) {
+		a.main.QueueUpdateDraw(f)
+	}
+	t.ShowMemoryReport = a.memory.Show
+	t.ShowImportResult = a.showImportResult
+	t.Connections = a.connections
+	t.Connection = a.connection
+	t.ShowComparison = a.compare.Show
+	t.ShowUndoHistory = a.showUndoHistory
+	t.Formatter = a.formatter
+	t.SetData(name, data)
+	return t
+}
+
+// connections returns the configured connection names and the index of
+// the shown one, -1 when browsing an RDB file.
+func (a *App) connections() ([]string, int) {
+	if a.tree != nil && a.tree.data.IsRDB() {
+		return a.cfg.GetDbNames(), -1
+	}
+	return a.cfg.GetDbNames(), a.main.GetOpLine().GetSelect()
+}
+
+// connection calls ok with the data of connection index, shared with its
+// tree when it was shown.
+func (a *App) connection(index int, ok func(data *model.Data)) {
+	config := a.cfg.GetConfig(index)
+	if t, found := a.dbTree[config.URL()]; found {
+		ok(t.data)
+		return
+	}
+	a.resolveAuth(config, func(auth string) {
+		data := a.newData(config, auth)
+		data.SetReadOnly(config.ReadOnly)
+		data.SetGuard(model.NewGuard(config.Guard))
+		data.SetAudit(a.audit, config.Name)
+		ok(data)
+	})
+}
+
+// showImportResult prints the summary of an import to the console.
+func (a *App) showImportResult(file string, result *model.ImportResult) {
+	cmd := a.main.GetCmd()
+	fmt.Fprintf(cmd, "Import %v\n%v\n", file, result)
+	a.main.ShowBottomPage(cmd.Title())
+}
+
+// showUndoHistory prints the recorded changes of the shown connection to
+// the console, telling which ones can be undone.
+func (a *App) showUndoHistory(entries []*model.UndoEntry) {
+	cmd := a.main.GetCmd()
+	undoable := 0
+	fmt.Fprintf(cmd, "Undo history of %v\n", a.name)
+	for _, e := range entries {
+		if e.Undoable() {
+			undoable++
 		}
-		t.SetData(fmt.Sprintf("%s:%v", config.Host, config.Port), data)
-		a.dbTree[address] = t
+		fmt.Fprintln(cmd, e)
 	}
+	fmt.Fprintf(cmd, "%v changes, %v can be undone\n", len(entries), undoable)
+	a.main.ShowBottomPage(cmd.Title())
+}
 
+func (a *App) showTree(t *DBTree, name, prompt string) {
 	a.tree = t
+	a.name = name
+	a.address = prompt
+	a.info.SetData(t.data)
 
 	a.main.SetTree(a.tree.tree.TreeView)
 	a.main.SetPreview(a.tree.preview.FlexBox())
 
-	a.main.GetCmd().SetPromt(address, a.tree.data.Index())
+	readOnly := t.data.CheckWrite() != nil
+	t.preview.SetReadOnly(readOnly)
+	a.main.GetOpLine().SetReadOnly(readOnly)
+	a.main.GetCmd().SetReadOnly(readOnly)
+	a.main.GetCmd().SetHistory(a.history(name))
+	a.main.GetCmd().SetPromt(prompt, a.tree.data.Index())
 }
 
-func (a *App) onCmdLineEnter(text string) {
-	args := strings.Fields(text)
-	if len(args) == 0 {
+// history returns the console history of a connection, loaded from and
+// appended to its history file.
+func (a *App) history(name string) *view.History {
+	if h, ok := a.histories[name]; ok {
+		return h
+	}
+	filename := a.cfg.HistoryFile(name)
+	lines, err := config.LoadHistory(filename)
+	if err != nil {
+		tlog.Warn("[App] load history", "err", err)
+	}
+	h := view.NewHistory(lines)
+	h.SetAddFunc(func(line string) {
+		// The passwords are kept out of the file, as by redis-cli.
+		args, err := redis.SplitArgs(line)
+		if err != nil {
+			args = strings.Fields(line)
+		}
+		if audit.HasSecret(args) {
+			return
+		}
+		if err := config.AppendHistory(filename, line); err != nil {
+			tlog.Warn("[App] save history", "err", err)
+		}
+	})
+	a.histories[name] = h
+	return h
+}
+
+func (a *App) onCmdLineEnter(args []string) {
+	if !a.tree.data.Guard().Command(args[0], args[1:]...) {
+		a.runCmd(args)
 		return
 	}
-	cmd := args[0]
+	// Dangerous commands run once the connection name, or its address
+	// when it has none, is typed.
+	want := strings.TrimSpace(a.name)
+	if want == "" {
+		want = a.address
+	}
+	cmd := a.main.GetCmd()
+	name := strings.ToUpper(args[0])
+	fmt.Fprintf(cmd, "%v waits for confirmation\n", name)
+	fields := []view.PromptField{{Label: fmt.Sprintf("Type %v to confirm:", want)}}
+	a.main.ShowPrompt(fmt.Sprintf("Run %v on %v?", name, want), fields, func(values []string) {
+		a.main.SetFocus(cmd)
+		quoted := make([]string, len(args))
+		for i, arg := range args {
+			quoted[i] = redis.QuoteArg(arg)
+		}
+		cmd.Resume(strings.Join(quoted, " "), func() {
+			if typed := strings.TrimSpace(values[0]); typed == "" || typed != want {
+				fmt.Fprintf(cmd, "Not confirmed, %v was not run\n", name)
+				return
+			}
+			a.runCmd(args)
+		})
+	})
+}
+
+func (a *App) runCmd(args []string) {
 	view := a.main.GetCmd()
-	if err := a.tree.data.Cmd(view, cmd, args[1:]...); err != nil {
+	cmd := args[0]
+	if redisapi.IsStreamCommand(cmd) {
+		a.startStream(view, args)
+		return
+	}
+	if err := a.tree.data.Cmd(view, view.OutputMode(), cmd, args[1:]...); err != nil {
 		fmt.Fprintln(view, err)
 	} else {
 		switch strings.ToUpper(cmd) {
@@ -0,0 +1,282 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/liwnn/redisterm/model"
+	"github.com/liwnn/redisterm/tlog"
+	"github.com/liwnn/redisterm/view"
+
+	"github.com/rivo/tview"
+)
+
+// toggleMark marks or unmarks the key or namespace of node for a bulk
+// operation. Marks belong to one database, marking a node of another
+// database clears them.
+func (t *DBTree) toggleMark(node *tview.TreeNode) {
+	r := t.getReference(node)
+	if r == nil || r.Data == nil || r.Data.IsRemoved() || (r.Name != "key" && r.Name != "dir") {
+		return
+	}
+	if len(t.marked) > 0 && t.markIndex != r.Index {
+		t.clearMarks()
+	}
+	if t.marked == nil {
+		t.marked = make(map[*model.DataNode]*Reference)
+	}
+	t.markIndex = r.Index
+	if _, ok := t.marked[r.Data]; ok {
+		delete(t.marked, r.Data)
+		t.tree.SetNodeMarked(node, false)
+	} else {
+		t.marked[r.Data] = r
+		t.tree.SetNodeMarked(node, true)
+	}
+	t.tree.SetMarkCount(len(t.marked))
+}
+
+// clearMarks unmarks all nodes.
+func (t *DBTree) clearMarks() {
+	t.walkMarked(func(node *tview.TreeNode) {
+		t.tree.SetNodeMarked(node, false)
+	})
+	t.marked = nil
+	t.tree.SetMarkCount(0)
+}
+
+// walkMarked calls f with the tree nodes of the marked keys and
+// namespaces.
+func (t *DBTree) walkMarked(f func(node *tview.TreeNode)) {
+	t.tree.GetRoot().Walk(func(node, parent *tview.TreeNode) bool {
+		if r := t.getReference(node); r != nil && r.Data != nil {
+			if _, ok := t.marked[r.Data]; ok {
+				f(node)
+			}
+		}
+		return true
+	})
+}
+
+// markPattern asks for a glob-style pattern and marks the keys below
+// node that match it, including keys of namespaces not expanded yet.
+func (t *DBTree) markPattern(r *Reference) {
+	prefix := ""
+	if r.Name == "dir" {
+		prefix = r.Data.Key()
+	}
+	fields := []view.PromptField{
+		{Label: "Pattern:", Text: model.FormatKey(prefix) + "*"},
+	}
+	t.ShowPrompt(fmt.Sprintf("Mark keys of db%v", r.Index), fields, func(values []string) {
+		pattern := values[0]
+		if pattern == "" {
+			return
+		}
+		if len(t.marked) > 0 && t.markIndex != r.Index {
+			t.clearMarks()
+		}
+		if t.marked == nil {
+			t.marked = make(map[*model.DataNode]*Reference)
+		}
+		t.markIndex = r.Index
+		n := t.markMatching(r.Data, r.Index, pattern)
+		t.walkMarked(func(node *tview.TreeNode) {
+			t.tree.SetNodeMarked(node, true)
+		})
+		t.tree.SetMarkCount(len(t.marked))
+		tlog.Info("[Mark] marked", "pattern", pattern, "keys", n)
+	})
+}
+
+func (t *DBTree) markMatching(n *model.DataNode, index int, pattern string) int {
+	count := 0
+	for _, child := range n.GetChildren() {
+		if child.IsRemoved() {
+			continue
+		}
+		if child.HasChild() {
+			count += t.markMatching(child, index, pattern)
+			continue
+		}
+		if model.MatchPattern(pattern, child.Key()) {
+			t.marked[child] = &Reference{Name: "key", Index: index, Data: child}
+			count++
+		}
+	}
+	return count
+}
+
+// selection returns the marked keys and namespaces, and the number of keys
+// they hold as last scanned.
+func (t *DBTree) selection() (*model.Selection, int) {
+	var keys, prefixes []string
+	for data, r := range t.marked {
+		if r.Name == "dir" {
+			prefixes = append(prefixes, data.Key())
+		} else {
+			keys = append(keys, data.Key())
+		}
+	}
+	sel := model.NewSelection(t.markIndex, keys, prefixes)
+	total := len(sel.Keys)
+	for data, r := range t.marked {
+		if r.Name != "dir" {
+			continue
+		}
+		for _, p := range sel.Prefixes {
+			if p == data.Key() {
+				total += data.KeyNum()
+			}
+		}
+	}
+	return sel, total
+}
+
+// markedItems returns the menu items of the bulk operations on the marked
+// keys and namespaces.
+func (t *DBTree) markedItems() []view.MenuItem {
+	sel, total := t.selection()
+	title := fmt.Sprintf("%v (%v keys) in db%v", sel, total, sel.Index)
+	export := view.MenuItem{
+		Label: "Export marked",
+		Action: func() {
+			t.promptExport(model.ExportOptions{Selection: sel}, fmt.Sprintf("db%v-marked", sel.Index))
+		},
+	}
+	clear := view.MenuItem{
+		Label:  "Clear marks",
+		Action: t.clearMarks,
+	}
+	if t.data.CheckWrite() != nil {
+		return []view.MenuItem{export, clear}
+	}
+	items := []view.MenuItem{
+		{
+			Label: "Delete marked",
+			Action: func() {
+				t.confirmDelete("Delete "+title+"?", sel.Index, total, func() {
+					t.runBulk(sel, model.BulkDelete, 0, total, "Deleting "+title)
+				})
+			},
+		},
+		{
+			Label:  "Set TTL of marked",
+			Action: func() { t.promptTTL(sel, total, title) },
+		},
+		{
+			Label: "Clear TTL of marked",
+			Action: func() {
+				t.ShowModal("Clear the TTL of "+title+"?", func() {
+					t.runBulk(sel, model.BulkPersist, 0, total, "Clearing the TTL of "+title)
+				})
+			},
+		},
+		export,
+		{
+			Label: "Move marked to...",
+			Action: func() {
+				opts := model.TransferOptions{Index: sel.Index, Selection: sel, Move: true}
+				t.promptTransfer(opts, title, t.clearMarks)
+			},
+		},
+		clear,
+	}
+	return items
+}
+
+func (t *DBTree) promptTTL(sel *model.Selection, total int, title string) {
+	fields := []view.PromptField{
+		{Label: "TTL (seconds):"},
+	}
+	t.ShowPrompt("Set TTL of "+title, fields, func(values []string) {
+		seconds, err := strconv.ParseInt(strings.TrimSpace(values[0]), 10, 64)
+		if err != nil || seconds <= 0 {
+			t.ShowModalOK("Invalid TTL")
+			return
+		}
+		t.ShowModal(fmt.Sprintf("Set the TTL of %v to %vs?", title, seconds), func() {
+			t.runBulk(sel, model.BulkExpire, seconds*1000, total, "Setting the TTL of "+title)
+		})
+	})
+}
+
+var bulkVerbs = map[model.BulkOp]string{
+	model.BulkDelete:  "Deleted",
+	model.BulkExpire:  "Set the TTL of",
+	model.BulkPersist: "Cleared the TTL of",
+}
+
+func (t *DBTree) runBulk(sel *model.Selection, op model.BulkOp, ttl int64, total int, text string) {
+	if err := t.data.CheckWrite(); err != nil {
+		t.ShowModalOK(err.Error())
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := t.ShowProgress(text, cancel)
+	go func() {
+		defer cancel()
+		result, err := t.data.Bulk(ctx, sel, op, ttl, func(done int) {
+			t.QueueUpdateDraw(func() {
+				progress.Update(text, done, max(total, done))
+			})
+		})
+		t.QueueUpdateDraw(func() {
+			progress.Hide()
+			if err != nil {
+				tlog.Error("[Bulk] failed", "keys", result.Keys, "err", err)
+				if op == model.BulkDelete {
+					// Some marked keys may be left, scan them again.
+					t.clearMarks()
+					if n := t.resetDB(sel.Index); n != nil {
+						t.tree.SetCurrentNode(n)
+						t.OnChanged(n)
+					}
+				}
+				msg := fmt.Sprintf("%v %v keys before: %v", bulkVerbs[op], result.Keys, err)
+				if err == context.Canceled {
+					msg = fmt.Sprintf("Cancelled, %v %v keys", strings.ToLower(bulkVerbs[op]), result.Keys)
+				}
+				t.ShowModalOK(msg)
+				return
+			}
+			if op == model.BulkDelete {
+				t.removeMarked()
+			}
+			msg := fmt.Sprintf("%v %v keys", bulkVerbs[op], result.Keys)
+			if result.Errors > 0 {
+				msg += fmt.Sprintf(", %v errors\n%v", result.Errors, strings.Join(result.FirstErrors, "\n"))
+			}
+			tlog.Info("[Bulk] done", "op", op.String(), "keys", result.Keys, "errors", result.Errors)
+			if op != model.BulkDelete {
+				t.clearMarks()
+			}
+			t.ShowModalOK(msg)
+		})
+	}()
+}
+
+// removeMarked shows the marked keys and namespaces as removed, then
+// clears the marks.
+func (t *DBTree) removeMarked() {
+	var nodes []*tview.TreeNode
+	t.walkMarked(func(node *tview.TreeNode) {
+		nodes = append(nodes, node)
+	})
+	for data := range t.marked {
+		t.data.RemoveNode(t.markIndex, data)
+	}
+	index := t.markIndex
+	t.clearMarks()
+	for _, node := range nodes {
+		t.tree.SetRemoved(node)
+	}
+	if n := t.indexNode(index); n != nil {
+		t.refreshText(n)
+	}
+	if r := t.getReference(t.getCurrentNode()); r != nil && r.Data != nil && r.Data.IsRemoved() {
+		t.updatePreviewWithType(fmt.Sprintf("%v was removed", model.FormatKey(r.Data.Key())), "", false)
+	}
+}
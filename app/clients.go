@@ -0,0 +1,96 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/liwnn/redisterm/model"
+	"github.com/liwnn/redisterm/redisapi"
+	"github.com/liwnn/redisterm/tlog"
+	"github.com/liwnn/redisterm/view"
+
+	"github.com/rivo/tview"
+)
+
+// clientsPage shows CLIENT LIST of the current connection.
+type clientsPage struct {
+	app   *App
+	table *view.ReportTable
+}
+
+func newClientsPage(a *App) *clientsPage {
+	table := view.NewReportTable("CLIENTS", func(p tview.Primitive) {
+		a.main.SetFocus(p)
+	})
+	table.SetColumns([]view.ReportColumn{
+		{Name: "id", Expansion: 1, Numeric: true},
+		{Name: "addr", Expansion: 3},
+		{Name: "name", Expansion: 3},
+		{Name: "db", Expansion: 1, Numeric: true},
+		{Name: "age(s)", Expansion: 1, Numeric: true},
+		{Name: "idle(s)", Expansion: 1, Numeric: true},
+		{Name: "flags", Expansion: 1},
+		{Name: "cmd", Expansion: 2},
+		{Name: "memory", Expansion: 1, Numeric: true},
+	})
+	p := &clientsPage{
+		app:   a,
+		table: table,
+	}
+	table.AddButton("Refresh", p.Refresh)
+	table.AddButton("Kill", p.kill)
+	table.SortBy(0, false)
+	return p
+}
+
+// Refresh reload the client list.
+func (p *clientsPage) Refresh() {
+	if p.app.tree == nil {
+		return
+	}
+	clients, err := p.app.tree.data.ClientList()
+	if err != nil {
+		tlog.Error("[Clients] list", "err", err)
+		p.table.SetStatus(err.Error())
+		return
+	}
+	rows := make([]view.Row, 0, len(clients))
+	for _, c := range clients {
+		name := c.Name
+		if name == redisapi.ClientName {
+			name += " (redis-term)"
+		}
+		rows = append(rows, view.Row{
+			strconv.FormatInt(c.ID, 10),
+			c.Addr,
+			name,
+			strconv.Itoa(c.DB),
+			strconv.FormatInt(c.Age, 10),
+			strconv.FormatInt(c.Idle, 10),
+			c.Flags,
+			c.Cmd,
+			model.FormatBytes(c.Memory),
+		})
+	}
+	p.table.SetRows(rows)
+	p.table.SetStatus("")
+}
+
+func (p *clientsPage) kill() {
+	row := p.table.GetSelectedRow()
+	if row == nil || p.app.tree == nil {
+		return
+	}
+	id, err := strconv.ParseInt(row[0], 10, 64)
+	if err != nil {
+		return
+	}
+	notice := fmt.Sprintf("Kill client %v (%v %v)?", id, row[1], row[2])
+	p.app.main.ShowModal(notice, func() {
+		if err := p.app.tree.data.ClientKill(id); err != nil {
+			p.app.main.ShowModalOK(err.Error())
+			return
+		}
+		p.Refresh()
+	})
+}
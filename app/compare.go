@@ -0,0 +1,161 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/liwnn/redisterm/model"
+	"github.com/liwnn/redisterm/tlog"
+	"github.com/liwnn/redisterm/view"
+
+	"github.com/rivo/tview"
+)
+
+const compareKeyLimit = 100000
+
+// comparison is a finished compare, kept to diff the values of its keys.
+type comparison struct {
+	a, b         *model.Data
+	nameA, nameB string
+	opts         model.CompareOptions
+	result       *model.CompareResult
+}
+
+// compareKeys asks for a connection and a database, then compares the
+// keys of node with the same keys there in the background.
+func (t *DBTree) compareKeys(r *Reference) {
+	opts := model.CompareOptions{
+		Index: r.Index,
+		Limit: compareKeyLimit,
+	}
+	if r.Name == "dir" {
+		opts.Prefix = r.Data.Key()
+	}
+	title := exportTitle(model.ExportOptions{Index: opts.Index, Prefix: opts.Prefix})
+	t.promptTarget("Compare "+title+" with", r.Index, func(target *model.Data, name string, db int) {
+		opts.TargetIndex = db
+		c := &comparison{
+			a:     t.data,
+			b:     target,
+			nameA: fmt.Sprintf("%v db%v", t.tree.GetRoot().GetText(), opts.Index),
+			nameB: fmt.Sprintf("%v db%v", name, db),
+			opts:  opts,
+		}
+		t.runCompare(c)
+	})
+}
+
+func (t *DBTree) runCompare(c *comparison) {
+	text := fmt.Sprintf("Comparing %v*: %v with %v", model.FormatKey(c.opts.Prefix), c.nameA, c.nameB)
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := t.ShowProgress(text, cancel)
+	go func() {
+		defer cancel()
+		result, err := c.a.Compare(ctx, c.b, c.opts, func(compared int) {
+			t.QueueUpdateDraw(func() {
+				progress.Update(text, compared, 0)
+			})
+		})
+		t.QueueUpdateDraw(func() {
+			progress.Hide()
+			if err != nil {
+				if err != context.Canceled {
+					t.ShowModalOK(err.Error())
+				}
+				tlog.Error("[Compare] failed", "err", err)
+				return
+			}
+			c.result = result
+			t.ShowComparison(c)
+		})
+	}()
+}
+
+// comparePage lists the keys that differ in the last compare, Enter shows
+// the values of a key side by side in the preview.
+type comparePage struct {
+	app   *App
+	table *view.ReportTable
+	last  *comparison
+	// shown counts the keys selected, a diff read after another key is
+	// selected is not shown.
+	shown int
+}
+
+func newComparePage(a *App) *comparePage {
+	table := view.NewReportTable("COMPARE", func(p tview.Primitive) {
+		a.main.SetFocus(p)
+	})
+	table.SetColumns([]view.ReportColumn{
+		{Name: "key", Expansion: 6},
+		{Name: "difference", Expansion: 1},
+		{Name: "A", Expansion: 1},
+		{Name: "B", Expansion: 1},
+	})
+	p := &comparePage{
+		app:   a,
+		table: table,
+	}
+	table.SetSelectedFunc(p.diff)
+	return p
+}
+
+// Show the differences of c.
+func (p *comparePage) Show(c *comparison) {
+	p.last = c
+	rows := make([]view.Row, 0, len(c.result.Diffs))
+	for _, d := range c.result.Diffs {
+		rows = append(rows, view.Row{model.FormatKey(d.Key), d.Kind.String(), d.A, d.B})
+	}
+	p.table.SetRows(rows)
+
+	r := c.result
+	status := fmt.Sprintf("A: %v, B: %v | %v only in A, %v only in B, %v differing, %v same",
+		c.nameA, c.nameB, r.Count(model.DiffOnlyA), r.Count(model.DiffOnlyB),
+		len(r.Diffs)-r.Count(model.DiffOnlyA)-r.Count(model.DiffOnlyB), r.Same)
+	if r.Truncated {
+		status += fmt.Sprintf(" (stopped at %v keys)", compareKeyLimit)
+	}
+	p.table.SetStatus(status)
+	tlog.Info("[Compare] done", "a", c.nameA, "b", c.nameB, "differing", len(r.Diffs), "same", r.Same, "truncated", r.Truncated)
+	p.app.main.ShowBottomPage(p.table.Title())
+}
+
+func (p *comparePage) diff(row view.Row) {
+	c := p.last
+	if c == nil || p.app.tree == nil {
+		return
+	}
+	key, err := model.ParseKey(row[0])
+	if err != nil {
+		return
+	}
+	p.shown++
+	shown := p.shown
+	go func() {
+		d, err := c.a.CompareKey(c.b, c.opts, key)
+		p.app.main.QueueUpdateDraw(func() {
+			if shown != p.shown || p.app.tree == nil {
+				return
+			}
+			if err != nil {
+				p.app.main.ShowModalOK(err.Error())
+				return
+			}
+			preview := p.app.tree.preview
+			preview.Clear()
+			preview.SetKey("")
+			preview.ShowDiff(
+				view.DiffPane{Title: diffTitle("A", c.nameA, d.A), Lines: d.LinesA, Changed: d.ChangedA},
+				view.DiffPane{Title: diffTitle("B", c.nameB, d.B), Lines: d.LinesB, Changed: d.ChangedB},
+			)
+		})
+	}()
+}
+
+func diffTitle(side, name string, kv *model.KeyValue) string {
+	if kv == nil {
+		return fmt.Sprintf("%v: %v (missing)", side, name)
+	}
+	return fmt.Sprintf("%v: %v %v %v", side, name, model.FormatKey(kv.Key), kv.Type)
+}
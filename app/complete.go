@@ -0,0 +1,151 @@
+package app
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/liwnn/redisterm/model"
+	"github.com/liwnn/redisterm/redis"
+	"github.com/liwnn/redisterm/redisapi"
+	"github.com/liwnn/redisterm/tlog"
+)
+
+const maxKeyCompletions = 100
+
+// loadCommands loads the commands of the server of data in the
+// background, completed and hinted once loaded.
+func (a *App) loadCommands(data *model.Data) {
+	go func() {
+		commands, err := data.LoadCommands()
+		a.main.QueueUpdateDraw(func() {
+			if err != nil {
+				tlog.Warn("[App] load commands", "err", err)
+			}
+			data.SetCommands(commands)
+		})
+	}()
+}
+
+// complete returns the completed console inputs for text: command names for
+// the first word, subcommands after a container command such as CONFIG and
+// keys loaded in the tree for the other arguments, once the commands of
+// the server are loaded.
+func (a *App) complete(text string) []string {
+	if a.tree == nil {
+		return nil
+	}
+	head, last := splitLastArg(text)
+	args, err := redis.SplitArgs(head)
+	if err != nil {
+		return nil
+	}
+	commands := a.tree.data.LoadedCommands()
+	switch {
+	case len(args) == 0:
+		return completeCommand(commands, "", head, last)
+	case len(args) == 1 && redisapi.IsSubcommandContainer(commands, args[0]):
+		return completeCommand(commands, strings.ToUpper(args[0])+" ", head, last)
+	}
+
+	prefix := last
+	if strings.HasPrefix(last, `"`) || strings.HasPrefix(last, "'") {
+		partial, err := redis.SplitArgs(last + last[:1])
+		if err != nil || len(partial) != 1 {
+			return nil
+		}
+		prefix = partial[0]
+	}
+	keys := a.tree.data.CompleteKeys(prefix, maxKeyCompletions)
+	candidates := make([]string, 0, len(keys))
+	for _, key := range keys {
+		candidates = append(candidates, head+redis.QuoteArg(key))
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+func completeCommand(commands map[string]*redisapi.CommandInfo, parent, head, word string) []string {
+	upper := strings.ToUpper(word)
+	lower := word != "" && word == strings.ToLower(word)
+	var candidates []string
+	for name := range commands {
+		if !strings.HasPrefix(name, parent) {
+			continue
+		}
+		rest := name[len(parent):]
+		if strings.Contains(rest, " ") || !strings.HasPrefix(rest, upper) {
+			continue
+		}
+		if lower {
+			rest = strings.ToLower(rest)
+		}
+		candidates = append(candidates, head+rest+" ")
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+// hint returns the syntax and summary of the command typed in text, none
+// until the commands of the server are loaded.
+func (a *App) hint(text string) string {
+	if a.tree == nil {
+		return ""
+	}
+	args, err := redis.SplitArgs(text)
+	if err != nil || len(args) == 0 {
+		return ""
+	}
+	commands := a.tree.data.LoadedCommands()
+	var c *redisapi.CommandInfo
+	if len(args) > 1 {
+		c = commands[strings.ToUpper(args[0]+" "+args[1])]
+	}
+	if c == nil {
+		c = commands[strings.ToUpper(args[0])]
+	}
+	if c == nil {
+		return ""
+	}
+	if c.Summary != "" {
+		return c.Hint() + " - " + c.Summary
+	}
+	return c.Hint()
+}
+
+// splitLastArg splits text before the argument under the cursor, which is
+// at the end of text. Quotes are taken into account.
+func splitLastArg(text string) (head, last string) {
+	start := 0
+	inToken, inq, insq := false, false, false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case inq:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inq = false
+			}
+		case insq:
+			if c == '\'' {
+				insq = false
+			}
+		case c == ' ' || c == '\t':
+			inToken = false
+		default:
+			if !inToken {
+				inToken = true
+				start = i
+			}
+			if c == '"' {
+				inq = true
+			} else if c == '\'' {
+				insq = true
+			}
+		}
+	}
+	if !inToken && !inq && !insq {
+		return text, ""
+	}
+	return text[:start], text[start:]
+}
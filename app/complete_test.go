@@ -0,0 +1,23 @@
+package app
+
+import "testing"
+
+func TestSplitLastArg(t *testing.T) {
+	tests := []struct {
+		text, head, last string
+	}{
+		{"", "", ""},
+		{"GE", "", "GE"},
+		{"GET ", "GET ", ""},
+		{"GET us", "GET ", "us"},
+		{`GET "a b`, "GET ", `"a b`},
+		{`SET "a b" v`, `SET "a b" `, "v"},
+		{`GET 'it`, "GET ", "'it"},
+	}
+	for _, tt := range tests {
+		head, last := splitLastArg(tt.text)
+		if head != tt.head || last != tt.last {
+			t.Errorf("splitLastArg(%q) = %q, %q, want %q, %q", tt.text, head, last, tt.head, tt.last)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/liwnn/redisterm/config"
+	"github.com/liwnn/redisterm/redisapi"
+	"github.com/liwnn/redisterm/tlog"
+	"github.com/liwnn/redisterm/view"
+)
+
+// connItems returns the configured connections as shown by the picker.
+func (a *App) connItems() []view.ConnItem {
+	items := make([]view.ConnItem, 0, a.cfg.Len())
+	for i := 0; i < a.cfg.Len(); i++ {
+		conf := a.cfg.GetConfig(i)
+		items = append(items, view.ConnItem{
+			Name:     conf.Name,
+			Address:  conf.URL(),
+			Group:    conf.Group,
+			Color:    conf.Color,
+			ReadOnly: conf.ReadOnly,
+		})
+	}
+	return items
+}
+
+// editConnection shows the setting of connection index.
+func (a *App) editConnection(index int) {
+	config := a.cfg.GetConfig(index)
+	setting := view.Setting{
+		Name:     config.Name,
+		Address:  config.URL(),
+		Auth:     config.Auth,
+		AuthRef:  config.AuthRef,
+		ReadOnly: config.ReadOnly,
+		Group:    config.Group,
+		Color:    config.Color,
+	}
+	a.editIndex = index
+	a.main.ShowConnSetting(setting, true)
+	tlog.Debug("[App] edit connection", "name", setting.Name)
+}
+
+// saveConnection adds the connection s, or updates the edited one, and
+// shows it. A password in the URL of the address replaces the one and the
+// reference of s,
+// and names the connection after its address when s has no name.
+func (a *App) saveConnection(s view.Setting, edit bool) {
+	a.main.HideConnSetting()
+	address, err := redisapi.ParseAddress(s.Address)
+	if err != nil {
+		a.main.ShowModalOKFunc(fmt.Sprintf("Invalid address %v: %v", s.Address, err), func() {
+			a.main.ShowConnSetting(s, edit)
+		})
+		return
+	}
+	if s.Name == "" {
+		s.Name = address.Name
+	}
+	if address.Auth != "" {
+		// the password of the URL is taken literally, never as a reference.
+		s.Auth, s.AuthRef = address.Auth, ""
+	}
+	index := a.cfg.Len()
+	var conf redisapi.RedisConfig
+	if edit {
+		index = a.editIndex
+		// keeps the settings without field in the form, such as the guard.
+		conf = a.cfg.GetConfig(index)
+	}
+	conf.Name = s.Name
+	conf.Host = address.Host
+	conf.Port = address.Port
+	conf.Socket = address.Socket
+	conf.TLS = address.TLS
+	conf.Username = address.Username
+	conf.DB = address.DB
+	conf.Auth = s.Auth
+	conf.AuthRef = s.AuthRef
+	conf.ReadOnly = s.ReadOnly
+	conf.Group = s.Group
+	conf.Color = s.Color
+	tlog.Debug("[App] save connection", "name", conf.Name, "edit", edit)
+	a.cfg.Update(conf, index)
+	a.saveConnections(index)
+	a.main.GetOpLine().Select(index)
+	a.migrateSecrets(func() {})
+}
+
+// duplicateConnection adds a copy of connection index after it.
+func (a *App) duplicateConnection(index int) {
+	current := a.main.GetOpLine().GetSelect()
+	copied := a.cfg.Duplicate(index)
+	if current >= copied {
+		current++
+	}
+	tlog.Info("[App] duplicate connection", "name", a.cfg.GetConfig(index).Name, "copy", a.cfg.GetConfig(copied).Name)
+	a.saveConnections(current)
+}
+
+// deleteConnection removes connection index once confirmed, showing the
+// next one when it was shown.
+func (a *App) deleteConnection(index int) {
+	if a.cfg.Len() == 1 {
+		a.main.ShowModalOK("The last connection can't be deleted")
+		return
+	}
+	name := a.cfg.GetConfig(index).Name
+	a.main.ShowModal(fmt.Sprintf("Delete connection %v?", name), func() {
+		current := a.main.GetOpLine().GetSelect()
+		a.cfg.Delete(index)
+		tlog.Info("[App] delete connection", "name", name)
+		switch {
+		case current == index:
+			current = min(index, a.cfg.Len()-1)
+			a.saveConnections(current)
+			a.main.GetOpLine().Select(current)
+			return
+		case current > index:
+			current--
+		}
+		a.saveConnections(current)
+	})
+}
+
+// moveConnection swaps connection index with its neighbour to.
+func (a *App) moveConnection(index, to int) {
+	current := a.main.GetOpLine().GetSelect()
+	a.cfg.Swap(index, to)
+	switch current {
+	case index:
+		current = to
+	case to:
+		current = index
+	}
+	a.saveConnections(current)
+}
+
+// importConnections asks for a file exported by another tool, or listing
+// redis URLs, and adds its connections.
+func (a *App) importConnections() {
+	fields := []view.PromptField{{Label: "File:"}}
+	a.main.ShowPrompt("Import connections", fields, func(values []string) {
+		filename := strings.TrimSpace(values[0])
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			a.main.ShowModalOK(fmt.Sprintf("Import connections: %v", err))
+			return
+		}
+		conns, skipped, err := config.ParseConnections(b)
+		if err != nil {
+			tlog.Error("[App] import connections", "file", filename, "err", err)
+			a.main.ShowModalOK(fmt.Sprintf("Import connections from %v: %v", filename, err))
+			return
+		}
+		added := a.cfg.Import(conns)
+		tlog.Info("[App] import connections", "file", filename, "added", added, "skipped", skipped)
+		a.saveConnections(a.main.GetOpLine().GetSelect())
+		text := fmt.Sprintf("%v connection(s) imported, %v already configured.", added, len(conns)-added)
+		if len(skipped) > 0 {
+			text += fmt.Sprintf("\nSentinels skipped: %v", strings.Join(skipped, ", "))
+		}
+		a.main.ShowModalOKFunc(text, func() {
+			a.migrateSecrets(func() {})
+		})
+	})
+}
+
+// saveConnections saves the config and refreshes the connection line and
+// picker, current being the shown connection.
+func (a *App) saveConnections(current int) {
+	if err := a.cfg.Save(); err != nil {
+		tlog.Error("[App] save config", "err", err)
+		a.main.ShowModalOK(fmt.Sprintf("Save the config: %v", err))
+	}
+	a.main.RefreshOpLine(a.connItems(), current, a.Show)
+}
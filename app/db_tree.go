@@ -1,8 +1,11 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/liwnn/redisterm/model"
@@ -27,8 +30,31 @@ type DBTree struct {
 
 	data *model.Data
 
-	ShowModalOK func(string)
-	ShowModal   func(text string, okFunc func())
+	ShowModalOK      func(string)
+	ShowModal        func(text string, okFunc func())
+	ShowMenu         func(title string, items []view.MenuItem)
+	ShowProgress     func(text string, cancel func()) *view.Progress
+	ShowPrompt       func(title string, fields []view.PromptField, ok func(values []string))
+	QueueUpdateDraw  func(f func())
+	ShowMemoryReport func(report *model.MemoryReport)
+	ShowImportResult func(file string, result *model.ImportResult)
+	ShowUndoHistory  func(entries []*model.UndoEntry)
+	// Connections returns the configured connections and the index of
+	// the shown one, Connection the data of one of them.
+	Connections    func() (names []string, current int)
+	Connection     func(index int, ok func(data *model.Data))
+	ShowComparison func(c *comparison)
+	// Formatter chooses the format of the string values shown.
+	Formatter *model.ValueFormatter
+
+	// format is the format of the shown string value, shown its bytes.
+	format string
+	shown  []byte
+
+	// marked are the keys and namespaces marked for a bulk operation, all
+	// in database markIndex.
+	marked    map[*model.DataNode]*Reference
+	markIndex int
 }
 
 // NewDBTree new
@@ -39,10 +65,12 @@ func NewDBTree(tree *view.Tree, preview *view.Preview) *DBTree {
 	}
 	tree.SetSelectedFunc(dbTree.OnSelected)
 	tree.SetChangedFunc(dbTree.OnChanged)
+	tree.SetMarkFunc(dbTree.toggleMark)
 	preview.SetSaveFunc(dbTree.saveKey)
 	preview.SetReloadFunc(dbTree.reloadSelectKey)
 	preview.SetRenameFunc(dbTree.renameSelectKey)
 	preview.SetDeleteFunc(dbTree.deleteKey)
+	preview.SetActionFunc(dbTree.showActions)
 	return dbTree
 }
 
@@ -51,6 +79,8 @@ func (t *DBTree) SetData(name string, data *model.Data) {
 	t.tree.GetRoot().ClearChildren()
 	t.tree.GetRoot().SetText(name)
 	t.data = data
+	t.marked = nil
+	t.tree.SetMarkCount(0)
 }
 
 func (t *DBTree) changeDB(index int) error {
@@ -63,18 +93,14 @@ func (t *DBTree) OnSelected(node *tview.TreeNode) {
 	err := t.changeDB(typ.Index)
 	if err != nil {
 		if err := t.data.Connect(); err != nil {
-			tlog.Log("[OnSelected] %v", err)
+			tlog.Error("[OnSelected] failed", "err", err)
 			return
 		}
 	}
-	tlog.Log("OnSelected: name[%v] index[%v]", typ.Name, typ.Index)
+	tlog.Debug("[OnSelected]", "name", typ.Name, "index", typ.Index)
 	if typ.Data != nil && typ.Data.HasChild() {
 		node := t.tree.GetCurrentNode()
-		if node.IsExpanded() {
-			node.SetText(fmt.Sprintf("▼ %v (%v)", typ.Data.Name(), typ.Data.KeyNum()))
-		} else {
-			node.SetText(fmt.Sprintf("▶ %v (%v)", typ.Data.Name(), typ.Data.KeyNum()))
-		}
+		t.tree.SetNodeText(node, t.nodeText(typ, node.IsExpanded()))
 	}
 	childen := node.GetChildren()
 	if len(childen) == 0 {
@@ -86,16 +112,17 @@ func (t *DBTree) OnSelected(node *tview.TreeNode) {
 				if err := t.data.Connect(); err == nil {
 					dbs, _ = t.data.GetDatabases()
 				} else {
-					tlog.Log("[OnSelected] db %v", err)
+					tlog.Error("[OnSelected] db", "err", err)
 					return
 				}
 			}
 			for i, dataNode := range dbs {
-				t.tree.AddNode(dataNode.Name(), &Reference{
+				r := &Reference{
 					Name:  "index",
 					Index: i,
 					Data:  dataNode,
-				})
+				}
+				t.tree.AddNode(t.nodeText(r, false), r)
 			}
 			t.addNode(node, dataNodes)
 		case "index":
@@ -104,7 +131,7 @@ func (t *DBTree) OnSelected(node *tview.TreeNode) {
 				if err := t.data.Connect(); err == nil {
 					dataNodes, _ = t.data.ScanAllKeys()
 				} else {
-					tlog.Log("[OnSelected] index %v", err)
+					tlog.Error("[OnSelected] index", "err", err)
 					return
 				}
 			}
@@ -131,13 +158,13 @@ func (t *DBTree) addNode(node *tview.TreeNode, dataNodes []*model.DataNode) {
 func (t *DBTree) OnChanged(node *tview.TreeNode) {
 	typ := t.getReference(node)
 	if typ.Name == "db" {
-		tlog.Log("OnChanged: db %v", typ.Name)
+		tlog.Debug("[OnChanged] db", "name", typ.Name)
 		t.preview.SetOpBtnVisible(false)
 	} else {
 		if typ.Name == "index" {
-			tlog.Log("OnChanged: %v - %v", typ.Name, typ.Index)
+			tlog.Debug("[OnChanged]", "name", typ.Name, "index", typ.Index)
 		} else {
-			tlog.Log("OnChanged: %v - %v", typ.Name, typ.Data.Key())
+			tlog.Debug("[OnChanged]", "name", typ.Name, "key", typ.Data.Key())
 		}
 		t.preview.SetOpBtnVisible(true)
 	}
@@ -148,13 +175,14 @@ func (t *DBTree) OnChanged(node *tview.TreeNode) {
 			begin := time.Now()
 			o := t.data.GetValue(typ.Data.Key())
 			keyType := t.data.Type(typ.Data.Key())
-			tlog.Log("redis value time cost %v", time.Since(begin))
+			t.format = t.Formatter.Format(typ.Data.Key())
+			tlog.Debug("[OnChanged] value loaded", "cost", time.Since(begin))
 			t.updatePreviewWithType(o, keyType, true)
 		} else {
-			t.updatePreviewWithType(fmt.Sprintf("%v was removed", typ.Data.Key()), "", false)
+			t.updatePreviewWithType(fmt.Sprintf("%v was removed", model.FormatKey(typ.Data.Key())), "", false)
 		}
 		t.preview.SetDeleteText("Delete")
-		t.preview.SetKey(typ.Data.Key())
+		t.preview.SetKey(model.FormatKey(typ.Data.Key()))
 	} else {
 		if typ.Name == "index" {
 			t.preview.SetDeleteText("Flush")
@@ -174,13 +202,9 @@ func (t *DBTree) updatePreviewWithType(o interface{}, keyType string, valid bool
 	switch h := o.(type) {
 	case []byte:
 		b := o.([]byte)
-		var text string
-		if model.IsText(b) {
-			text = string(b)
-		} else {
-			text = model.EncodeToHexString(b)
-		}
-		p.ShowText(text, valid)
+		text, editable := model.FormatValue(t.format, b)
+		t.shown = b
+		p.ShowText(text, valid && editable)
 		if valid {
 			p.SetSizeText(fmt.Sprintf("Size: %d bytes", len(b)))
 		}
@@ -253,7 +277,7 @@ func (t *DBTree) reloadSelectKey() {
 		return
 	}
 	key := reference.Data.Key()
-	tlog.Log("reload %v", key)
+	tlog.Debug("[Reload]", "key", key)
 
 	if reference.Name == "key" {
 		t.changeDB(reference.Index)
@@ -261,7 +285,7 @@ func (t *DBTree) reloadSelectKey() {
 		if o == nil {
 			reference.Data.SetRemoved()
 			t.tree.SetNodeRemoved()
-			t.updatePreviewWithType(fmt.Sprintf("%v was removed", key), "", false)
+			t.updatePreviewWithType(fmt.Sprintf("%v was removed", model.FormatKey(key)), "", false)
 			t.preview.SetDeleteText("Delete")
 		} else {
 			kt := t.data.Type(key)
@@ -272,9 +296,9 @@ func (t *DBTree) reloadSelectKey() {
 
 	node.ClearChildren()
 	if err := t.data.Reload(reference.Data); err != nil {
-		tlog.Log("[App] err %v", err)
+		tlog.Error("[Reload] failed", "err", err)
 		node.SetExpanded(false)
-		node.SetText(reference.Data.Name())
+		t.tree.SetNodeText(node, model.FormatKey(reference.Data.Name()))
 	}
 
 	childen := reference.Data.GetChildren()
@@ -294,10 +318,52 @@ func (t *DBTree) reloadSelectKey() {
 func (t *DBTree) addReference(dataNode *model.DataNode, r *Reference) {
 	if dataNode.HasChild() {
 		r.Name = "dir"
-		t.tree.AddNode(fmt.Sprintf("▶ %v (%v)", dataNode.Name(), dataNode.KeyNum()), r)
 	} else {
 		r.Name = "key"
-		t.tree.AddNode(dataNode.Name(), r)
+	}
+	node := t.tree.AddNode(t.nodeText(r, false), r)
+	if _, ok := t.marked[dataNode]; ok {
+		t.tree.SetNodeMarked(node, true)
+	}
+}
+
+// nodeText returns the tree text of a node: the key name, the key count of
+// namespaces and the sampled memory usage when analyzed.
+func (t *DBTree) nodeText(r *Reference, expanded bool) string {
+	text := model.FormatKey(r.Data.Name())
+	if r.Name == "dir" {
+		arrow := "▶"
+		if expanded {
+			arrow = "▼"
+		}
+		text = fmt.Sprintf("%v %v (%v)", arrow, text, r.Data.KeyNum())
+	}
+	if r.Data.MemoryKeys() > 0 {
+		text += " " + model.FormatBytes(r.Data.Memory())
+	}
+	return text
+}
+
+// resetDB drops the loaded keys of database index, scanned again when it
+// is expanded, and returns its node.
+func (t *DBTree) resetDB(index int) *tview.TreeNode {
+	t.data.ResetKeys(index)
+	n := t.indexNode(index)
+	if n != nil {
+		n.ClearChildren()
+		n.SetExpanded(false)
+	}
+	return n
+}
+
+// refreshText updates the text of node and of the nodes below it.
+func (t *DBTree) refreshText(node *tview.TreeNode) {
+	r := t.getReference(node)
+	if r != nil && r.Data != nil && !r.Data.IsRemoved() && r.Name != "db" {
+		t.tree.SetNodeText(node, t.nodeText(r, node.IsExpanded()))
+	}
+	for _, child := range node.GetChildren() {
+		t.refreshText(child)
 	}
 }
 
@@ -311,15 +377,23 @@ func (t *DBTree) renameSelectKey() {
 	}
 
 	key := reference.Data.Key()
-	notice := fmt.Sprintf("Rename %v->%v", key, t.preview.GetKey())
+	newKey, err := model.ParseKey(t.preview.GetKey())
+	if err != nil {
+		t.ShowModalOK(err.Error())
+		return
+	}
+	notice := fmt.Sprintf("Rename %v->%v", model.FormatKey(key), model.FormatKey(newKey))
 	t.ShowModal(notice, func() {
-		if key == t.preview.GetKey() {
+		if key == newKey {
 			return
 		}
 
-		tlog.Log("rename %v %v", key, t.preview.GetKey())
-		t.data.Rename(reference.Data, t.preview.GetKey())
-		t.getCurrentNode().SetText(reference.Data.Name())
+		tlog.Info("[Rename]", "key", key, "newkey", newKey)
+		if err := t.data.Rename(reference.Data, newKey); err != nil {
+			t.ShowModalOK(err.Error())
+			return
+		}
+		t.tree.SetNodeText(t.getCurrentNode(), model.FormatKey(reference.Data.Name()))
 	})
 }
 
@@ -328,20 +402,106 @@ func (t *DBTree) deleteKey() {
 	if typ == nil {
 		return
 	}
+	if err := t.data.CheckWrite(); err != nil {
+		t.ShowModalOK(err.Error())
+		return
+	}
 	var notice string
 	switch typ.Name {
 	case "key":
-		notice = "Delete " + typ.Data.Key() + " ?"
+		notice = "Delete " + model.FormatKey(typ.Data.Key()) + " ?"
 	case "index":
 		notice = fmt.Sprintf("FlushDB index:%v?", typ.Index)
+		if t.data.Guard().Command("FLUSHDB") {
+			t.confirmTyped(notice, strconv.Itoa(typ.Index), func() {
+				go t.deleteSelectKey(typ)
+			})
+			return
+		}
 	case "dir":
-		notice = "Delete " + typ.Data.Key() + "* ?"
+		node := t.getCurrentNode()
+		notice = fmt.Sprintf("Delete %v* (%v keys)?", model.FormatKey(typ.Data.Key()), typ.Data.KeyNum())
+		t.confirmDelete(notice, typ.Index, typ.Data.KeyNum(), func() {
+			t.deleteNamespace(node, typ)
+		})
+		return
 	}
 	t.ShowModal(notice, func() {
 		go t.deleteSelectKey(typ)
 	})
 }
 
+// confirmDelete asks to confirm deleting keys keys of database index,
+// by typing the index when the guard finds it dangerous.
+func (t *DBTree) confirmDelete(notice string, index, keys int, ok func()) {
+	if t.data.Guard().Bulk(keys) {
+		t.confirmTyped(notice, strconv.Itoa(index), ok)
+		return
+	}
+	t.ShowModal(notice, ok)
+}
+
+// confirmTyped calls ok once want is typed to confirm.
+func (t *DBTree) confirmTyped(notice, want string, ok func()) {
+	fields := []view.PromptField{{Label: fmt.Sprintf("Type %v to confirm:", want)}}
+	t.ShowPrompt(notice, fields, func(values []string) {
+		if strings.TrimSpace(values[0]) != want {
+			t.ShowModalOK("Not confirmed, nothing was changed")
+			return
+		}
+		ok()
+	})
+}
+
+// showActions shows the action menu of the current node.
+func (t *DBTree) showActions() {
+	node := t.getCurrentNode()
+	r := t.getReference(node)
+	if r == nil || r.Data == nil {
+		return
+	}
+	var items []view.MenuItem
+	if len(t.marked) > 0 {
+		items = append(items, t.markedItems()...)
+	}
+	switch r.Name {
+	case "index", "dir":
+		items = append(items, view.MenuItem{
+			Label:  "Mark by pattern...",
+			Action: func() { t.markPattern(r) },
+		}, view.MenuItem{
+			Label:  "Analyze memory",
+			Action: func() { t.analyzeMemory(node, r) },
+		}, view.MenuItem{
+			Label:  "Compare with...",
+			Action: func() { t.compareKeys(r) },
+		})
+	}
+	// Read-only connections only get the items leaving them unchanged.
+	writable := t.data.CheckWrite() == nil
+	if r.Name == "index" && writable {
+		items = append(items, view.MenuItem{
+			Label:  "Import",
+			Action: func() { t.importKeys(node, r) },
+		})
+	}
+	items = append(items, view.MenuItem{
+		Label:  "Export",
+		Action: func() { t.exportKeys(r) },
+	}, view.MenuItem{
+		Label:  "Copy to...",
+		Action: func() { t.transferKeys(node, r, false) },
+	})
+	if writable {
+		items = append(items, view.MenuItem{
+			Label:  "Move to...",
+			Action: func() { t.transferKeys(node, r, true) },
+		})
+		items = append(items, t.undoItems()...)
+	}
+	t.ShowMenu(model.FormatKey(r.Data.Key()), items)
+}
+
 func (t *DBTree) saveKey(oldValue, newValue string) {
 	if oldValue == newValue {
 		t.ShowModalOK("Nothing to save")
@@ -353,11 +513,18 @@ func (t *DBTree) saveKey(oldValue, newValue string) {
 	}
 	switch typ.Name {
 	case "key":
-		if err := t.data.SetValue(typ.Data, newValue); err == nil {
+		value, err := model.ParseValue(t.format, t.shown, newValue)
+		if err != nil {
+			t.ShowModalOK(err.Error())
+			return
+		}
+		if err := t.data.SetValue(typ.Data, value); err == nil {
+			t.shown = []byte(value)
 			t.preview.ShowText(newValue, true)
 			t.ShowModalOK("Value was updated!")
 		} else {
-			tlog.Log("saveKey %v", err)
+			tlog.Error("[Save] failed", "err", err)
+			t.ShowModalOK(err.Error())
 		}
 	}
 }
@@ -385,33 +552,68 @@ func (t *DBTree) getCurrentNode() *tview.TreeNode {
 func (t *DBTree) deleteSelectKey(typ *Reference) {
 	switch typ.Name {
 	case "key":
-		tlog.Log("delete %v", typ.Data.Key())
+		tlog.Info("[Delete]", "key", typ.Data.Key())
 		if err := t.data.Delete(typ.Data); err != nil {
-			tlog.Log("DBTree deleteSelectKey %v", err)
+			tlog.Error("[Delete] failed", "err", err)
 			return
 		}
 		t.tree.SetNodeRemoved()
-		t.updatePreviewWithType(fmt.Sprintf("%v was removed", typ.Data.Key()), "", false)
+		t.updatePreviewWithType(fmt.Sprintf("%v was removed", model.FormatKey(typ.Data.Key())), "", false)
 	case "index":
 		if err := t.data.FlushDB(typ.Data); err != nil {
-			tlog.Log("DBTree deleteSelectKey %v", err)
+			tlog.Error("[Delete] failed", "err", err)
 			return
 		}
 		t.getCurrentNode().ClearChildren()
 		t.getCurrentNode().SetText(typ.Data.Name())
-	case "dir":
-		tlog.Log("delete %v", typ.Data.Key())
-		if err := t.data.Delete(typ.Data); err != nil {
-			tlog.Log("DBTree deleteSelectKey %v", err)
-			return
-		}
-		t.tree.SetNodeRemoved()
-		t.updatePreviewWithType("", "", false)
 	default:
-		tlog.Log("delete %v not implement", typ.Name)
+		tlog.Warn("[Delete] not implemented", "name", typ.Name)
 	}
 }
 
+// deleteNamespace deletes the keys below node in the background, all the
+// keys of the namespace on the server, not only the ones loaded.
+func (t *DBTree) deleteNamespace(node *tview.TreeNode, r *Reference) {
+	text := fmt.Sprintf("Deleting db%v %v*", r.Index, model.FormatKey(r.Data.Key()))
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := t.ShowProgress(text, cancel)
+	go func() {
+		defer cancel()
+		deleted, err := t.data.DeleteNamespace(ctx, r.Index, r.Data, func(scanned int) {
+			t.QueueUpdateDraw(func() {
+				progress.Update(text, scanned, max(r.Data.KeyNum(), scanned))
+			})
+		})
+		t.QueueUpdateDraw(func() {
+			progress.Hide()
+			if err != nil {
+				tlog.Error("[Delete] namespace failed", "prefix", r.Data.Key(), "deleted", deleted, "err", err)
+				// Some keys may be left, scan them again.
+				if n := t.resetDB(r.Index); n != nil {
+					t.tree.SetCurrentNode(n)
+					t.OnChanged(n)
+				}
+				msg := fmt.Sprintf("Deleted %v keys of %v* before: %v", deleted, model.FormatKey(r.Data.Key()), err)
+				if err == context.Canceled {
+					msg = fmt.Sprintf("Cancelled after deleting %v keys of %v*", deleted, model.FormatKey(r.Data.Key()))
+				}
+				t.ShowModalOK(msg)
+				return
+			}
+			tlog.Info("[Delete] namespace", "prefix", r.Data.Key(), "deleted", deleted)
+			t.data.RemoveNode(r.Index, r.Data)
+			t.tree.SetRemoved(node)
+			if n := t.indexNode(r.Index); n != nil {
+				t.refreshText(n)
+			}
+			if node == t.getCurrentNode() {
+				t.updatePreviewWithType("", "", false)
+			}
+			t.ShowModalOK(fmt.Sprintf("Deleted %v keys of %v*", deleted, model.FormatKey(r.Data.Key())))
+		})
+	}()
+}
+
 // Close close
 func (t *DBTree) Close() {
 	t.data.Close()
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/liwnn/redisterm/config"
+	"github.com/liwnn/redisterm/model"
+	"github.com/liwnn/redisterm/tlog"
+	"github.com/liwnn/redisterm/view"
+)
+
+// exportKeys asks for a file and a format, then writes the keys of node
+// to the file in the background.
+func (t *DBTree) exportKeys(r *Reference) {
+	opts := model.ExportOptions{
+		Index: r.Index,
+	}
+	name := fmt.Sprintf("db%v", r.Index)
+	if r.Name != "index" {
+		opts.Prefix = r.Data.Key()
+		opts.Exact = r.Name == "key"
+		name = config.SanitizeFileName(strings.TrimRight(opts.Prefix, ":"))
+	}
+	t.promptExport(opts, name)
+}
+
+// promptExport asks for a file, name by default, and a format, then
+// exports the keys of opts.
+func (t *DBTree) promptExport(opts model.ExportOptions, name string) {
+	formats := make([]string, 0, len(model.ExportFormats))
+	for _, f := range model.ExportFormats {
+		formats = append(formats, f.String())
+	}
+	fields := []view.PromptField{
+		{Label: "File:", Text: name},
+		{Label: "Format:", Text: model.ExportJSON.String(), Options: formats},
+	}
+	t.ShowPrompt("Export "+exportTitle(opts), fields, func(values []string) {
+		file := values[0]
+		for _, f := range model.ExportFormats {
+			if f.String() == values[1] {
+				opts.Format = f
+			}
+		}
+		if file == "" {
+			return
+		}
+		if filepath.Ext(file) == "" {
+			file += opts.Format.Ext()
+		}
+		if _, err := os.Stat(file); err == nil {
+			t.ShowModal(fmt.Sprintf("Overwrite %v?", file), func() {
+				t.runExport(opts, file)
+			})
+			return
+		}
+		t.runExport(opts, file)
+	})
+}
+
+func exportTitle(opts model.ExportOptions) string {
+	if opts.Selection != nil {
+		return fmt.Sprintf("db%v %v", opts.Selection.Index, opts.Selection)
+	}
+	if opts.Exact {
+		return model.FormatKey(opts.Prefix)
+	}
+	if opts.Prefix == "" {
+		return fmt.Sprintf("db%v", opts.Index)
+	}
+	return fmt.Sprintf("db%v %v*", opts.Index, model.FormatKey(opts.Prefix))
+}
+
+func (t *DBTree) runExport(opts model.ExportOptions, file string) {
+	f, err := os.Create(file)
+	if err != nil {
+		t.ShowModalOK(err.Error())
+		return
+	}
+	text := fmt.Sprintf("Exporting %v to %v", exportTitle(opts), file)
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := t.ShowProgress(text, cancel)
+	go func() {
+		defer cancel()
+		result, err := t.data.Export(ctx, opts, f, func(exported int) {
+			t.QueueUpdateDraw(func() {
+				progress.Update(text, exported, 0)
+			})
+		})
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(file)
+		}
+		t.QueueUpdateDraw(func() {
+			progress.Hide()
+			if err != nil {
+				if err != context.Canceled {
+					t.ShowModalOK(err.Error())
+				}
+				tlog.Error("[Export] failed", "err", err)
+				return
+			}
+			msg := fmt.Sprintf("Exported %v keys to %v", result.Keys, file)
+			if result.Skipped > 0 {
+				msg += fmt.Sprintf(", skipped %v", result.Skipped)
+			}
+			tlog.Info("[Export] done", "file", file, "keys", result.Keys, "skipped", result.Skipped)
+			t.ShowModalOK(msg)
+		})
+	}()
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/liwnn/redisterm/model"
+	"github.com/liwnn/redisterm/tlog"
+	"github.com/liwnn/redisterm/view"
+
+	"github.com/rivo/tview"
+)
+
+const (
+	dryRunNo  = "No"
+	dryRunYes = "Yes"
+)
+
+// importKeys asks for a file and the import options, then runs the
+// commands of the file on the database of node in the background.
+func (t *DBTree) importKeys(node *tview.TreeNode, r *Reference) {
+	if t.data.IsRDB() {
+		t.ShowModalOK(model.ErrOffline.Error())
+		return
+	}
+	dryRun := []string{dryRunNo, dryRunYes}
+	if t.data.CheckWrite() != nil {
+		// a read-only connection only checks the file.
+		dryRun = []string{dryRunYes}
+	}
+	formats := make([]string, 0, len(model.ImportFormats))
+	for _, f := range model.ImportFormats {
+		formats = append(formats, f.String())
+	}
+	policies := make([]string, 0, len(model.ConflictPolicies))
+	for _, p := range model.ConflictPolicies {
+		policies = append(policies, p.String())
+	}
+	fields := []view.PromptField{
+		{Label: "File:"},
+		{Label: "Format:", Text: model.ImportAuto.String(), Options: formats},
+		{Label: "Existing keys:", Text: model.ConflictSkip.String(), Options: policies},
+		{Label: "Dry run:", Text: dryRun[0], Options: dryRun},
+	}
+	t.ShowPrompt(fmt.Sprintf("Import into db%v", r.Index), fields, func(values []string) {
+		opts := model.ImportOptions{
+			Index:  r.Index,
+			DryRun: values[3] == dryRunYes,
+		}
+		for _, f := range model.ImportFormats {
+			if f.String() == values[1] {
+				opts.Format = f
+			}
+		}
+		for _, p := range model.ConflictPolicies {
+			if p.String() == values[2] {
+				opts.Conflict = p
+			}
+		}
+		if values[0] == "" {
+			return
+		}
+		t.runImport(r, opts, values[0])
+	})
+}
+
+func (t *DBTree) runImport(r *Reference, opts model.ImportOptions, file string) {
+	f, err := os.Open(file)
+	if err != nil {
+		t.ShowModalOK(err.Error())
+		return
+	}
+	var size int64
+	if stat, err := f.Stat(); err == nil {
+		size = stat.Size()
+	}
+	text := fmt.Sprintf("Importing %v into db%v", file, r.Index)
+	if opts.DryRun {
+		text = fmt.Sprintf("Checking %v against db%v", file, r.Index)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := t.ShowProgress(text, cancel)
+	go func() {
+		defer cancel()
+		defer f.Close()
+		result, err := t.data.Import(ctx, opts, f, func(read int64) {
+			t.QueueUpdateDraw(func() {
+				progress.Update(text, int(read), int(size))
+			})
+		})
+		t.QueueUpdateDraw(func() {
+			progress.Hide()
+			if err != nil {
+				if err != context.Canceled {
+					t.ShowModalOK(err.Error())
+				}
+				tlog.Error("[Import] failed", "file", file, "err", err)
+				return
+			}
+			tlog.Info("[Import] done", "file", file, "dry_run", opts.DryRun, "keys", result.Keys, "commands", result.Commands, "errors", result.Errors)
+			if !opts.DryRun {
+				t.resetDB(r.Index)
+			}
+			t.ShowImportResult(file, result)
+		})
+	}()
+}
@@ -0,0 +1,213 @@
+package app
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/liwnn/redisterm/model"
+	"github.com/liwnn/redisterm/redisapi"
+	"github.com/liwnn/redisterm/view"
+)
+
+const (
+	infoInterval = 2 * time.Second
+	infoSamples  = 60
+)
+
+var infoSections = []string{"server", "clients", "memory", "persistence", "stats", "replication", "keyspace"}
+
+// infoMonitor refreshes the INFO page from a dedicated connection while the
+// page is shown.
+type infoMonitor struct {
+	main *view.MainView
+
+	mu     sync.Mutex
+	data   *model.Data
+	active bool
+	wake   chan struct{}
+	stop   chan struct{}
+
+	// used by the refresh goroutine only
+	client  *redisapi.Redis
+	current *model.Data
+	prev    *redisapi.Info
+	prevAt  time.Time
+	samples map[string][]float64
+}
+
+func newInfoMonitor(main *view.MainView) *infoMonitor {
+	return &infoMonitor{
+		main: main,
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+	}
+}
+
+// SetData set the connection to monitor.
+func (m *infoMonitor) SetData(data *model.Data) {
+	m.mu.Lock()
+	m.data = data
+	m.mu.Unlock()
+	m.refreshNow()
+}
+
+// SetActive starts or pauses refreshing, when the INFO page is shown or
+// hidden.
+func (m *infoMonitor) SetActive(active bool) {
+	m.mu.Lock()
+	m.active = active
+	m.mu.Unlock()
+	m.refreshNow()
+}
+
+func (m *infoMonitor) refreshNow() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start refreshing in a goroutine.
+func (m *infoMonitor) Start() {
+	go m.loop()
+}
+
+// Stop the refresh goroutine.
+func (m *infoMonitor) Stop() {
+	close(m.stop)
+}
+
+func (m *infoMonitor) loop() {
+	ticker := time.NewTicker(infoInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			if m.client != nil {
+				m.client.Close()
+			}
+			return
+		case <-ticker.C:
+		case <-m.wake:
+		}
+		m.refresh()
+	}
+}
+
+func (m *infoMonitor) refresh() {
+	m.mu.Lock()
+	data, active := m.data, m.active
+	m.mu.Unlock()
+	if !active || data == nil {
+		return
+	}
+
+	if data != m.current {
+		if m.client != nil {
+			m.client.Close()
+			m.client = nil
+		}
+		m.current = data
+		m.prev = nil
+		m.samples = make(map[string][]float64)
+	}
+	if m.client == nil {
+		client, err := data.Dial()
+		if err != nil {
+			m.setStatus(fmt.Sprintf("INFO: %v", err))
+			return
+		}
+		m.client = client
+	}
+
+	info, err := m.client.Info("all")
+	if err != nil {
+		m.client.Close()
+		m.client = nil
+		m.setStatus(fmt.Sprintf("INFO: %v", err))
+		return
+	}
+	now := time.Now()
+	metrics := m.metrics(info, now)
+	groups := make([]view.InfoGroup, 0, len(infoSections))
+	for _, name := range infoSections {
+		section := info.Section(name)
+		if section == nil {
+			continue
+		}
+		g := view.InfoGroup{Name: name}
+		for _, f := range section.Fields {
+			g.Fields = append(g.Fields, [2]string{f.Key, f.Value})
+		}
+		groups = append(groups, g)
+	}
+	m.prev, m.prevAt = info, now
+
+	panel := m.main.GetInfo()
+	m.main.QueueUpdateDraw(func() {
+		panel.Update(metrics, groups)
+		panel.SetStatus(fmt.Sprintf("INFO %v  refreshed %v", info.Get("redis_version"), now.Format("15:04:05")))
+	})
+}
+
+func (m *infoMonitor) setStatus(status string) {
+	panel := m.main.GetInfo()
+	m.main.QueueUpdateDraw(func() {
+		panel.SetStatus(status)
+	})
+}
+
+// metrics computes the dashboard metrics. Rates use the previous sample
+// when there is one, otherwise the server's instantaneous values.
+func (m *infoMonitor) metrics(info *redisapi.Info, now time.Time) []view.InfoMetric {
+	ops := info.Float("instantaneous_ops_per_sec")
+
+	hits, misses := info.Float("keyspace_hits"), info.Float("keyspace_misses")
+	netIn := info.Float("instantaneous_input_kbps") * 1024
+	netOut := info.Float("instantaneous_output_kbps") * 1024
+	if m.prev != nil {
+		hits -= m.prev.Float("keyspace_hits")
+		misses -= m.prev.Float("keyspace_misses")
+		if dt := now.Sub(m.prevAt).Seconds(); dt > 0 {
+			netIn = (info.Float("total_net_input_bytes") - m.prev.Float("total_net_input_bytes")) / dt
+			netOut = (info.Float("total_net_output_bytes") - m.prev.Float("total_net_output_bytes")) / dt
+		}
+	}
+	hitRatio := 0.0
+	if hits+misses > 0 {
+		hitRatio = hits / (hits + misses) * 100
+	}
+
+	var keys int64
+	for _, db := range info.Keyspace() {
+		keys += db.Keys
+	}
+	memory := info.Float("used_memory")
+	clients := info.Float("connected_clients")
+
+	return []view.InfoMetric{
+		m.metric("ops/sec", fmt.Sprintf("%.0f", ops), ops),
+		m.metric("hit ratio", fmt.Sprintf("%.1f%%", hitRatio), hitRatio),
+		m.metric("net in", model.FormatBytes(int64(netIn))+"/s", netIn),
+		m.metric("net out", model.FormatBytes(int64(netOut))+"/s", netOut),
+		m.metric("memory", model.FormatBytes(int64(memory)), memory),
+		m.metric("clients", fmt.Sprintf("%.0f", clients), clients),
+		m.metric("keys", fmt.Sprint(keys), float64(keys)),
+	}
+}
+
+// metric records a sample and returns the metric with a copy of its
+// history, safe to use on the UI goroutine.
+func (m *infoMonitor) metric(name, value string, sample float64) view.InfoMetric {
+	samples := append(m.samples[name], sample)
+	if len(samples) > infoSamples {
+		samples = samples[len(samples)-infoSamples:]
+	}
+	m.samples[name] = samples
+	return view.InfoMetric{
+		Name:    name,
+		Value:   value,
+		History: append([]float64(nil), samples...),
+	}
+}
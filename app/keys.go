@@ -0,0 +1,117 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// defaultKeys are the keys of the global actions, overridden by the
+// keybindings of the preferences.
+var defaultKeys = map[string]string{
+	"connections": "F2",
+	"actions":     "F3",
+	"console":     "F4",
+	"reload":      "F5",
+}
+
+// binding is a key: a special key such as F2 or Ctrl-P, or a rune with
+// Alt.
+type binding struct {
+	key  tcell.Key
+	rune rune
+}
+
+func (b binding) match(event *tcell.EventKey) bool {
+	if b.key != tcell.KeyRune {
+		return event.Key() == b.key
+	}
+	return event.Key() == tcell.KeyRune && event.Modifiers()&tcell.ModAlt != 0 && event.Rune() == b.rune
+}
+
+// parseKey parses a key named as by tcell, such as "F2", "Ctrl-P" or
+// "Alt-x". Runes need Alt, not to be taken from the inputs.
+func parseKey(s string) (binding, error) {
+	if r, ok := strings.CutPrefix(s, "Alt-"); ok && len([]rune(r)) == 1 {
+		return binding{key: tcell.KeyRune, rune: []rune(r)[0]}, nil
+	}
+	for k, name := range tcell.KeyNames {
+		if strings.EqualFold(name, s) && k != tcell.KeyRune {
+			return binding{key: k}, nil
+		}
+	}
+	return binding{}, fmt.Errorf("unknown key %q", s)
+}
+
+// keyActions returns the actions run by the global keys.
+func (a *App) keyActions() map[string]func() {
+	return map[string]func(){
+		"connections": a.main.ShowConnPicker,
+		"actions": func() {
+			if a.tree != nil {
+				a.tree.showActions()
+			}
+		},
+		"console": func() {
+			cmd := a.main.GetCmd()
+			a.main.ShowBottomPage(cmd.Title())
+			a.main.SetFocus(cmd)
+		},
+		"reload": func() {
+			if a.tree != nil {
+				a.tree.reloadSelectKey()
+			}
+		},
+	}
+}
+
+// bindKeys runs the actions on their keys, the default ones overridden by
+// keybindings, and returns the errors of keybindings.
+func (a *App) bindKeys(keybindings map[string]string) error {
+	actions := a.keyActions()
+	keys := make(map[string]string, len(defaultKeys))
+	for action, key := range defaultKeys {
+		keys[action] = key
+	}
+	var errs []error
+	for action, key := range keybindings {
+		if _, ok := actions[action]; !ok {
+			errs = append(errs, fmt.Errorf("keybindings: unknown action %q", action))
+			continue
+		}
+		keys[action] = key
+	}
+
+	type boundAction struct {
+		binding
+		run func()
+	}
+	var bound []boundAction
+	names := make([]string, 0, len(keys))
+	for action := range keys {
+		names = append(names, action)
+	}
+	sort.Strings(names)
+	for _, action := range names {
+		b, err := parseKey(keys[action])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("keybindings: %v: %w", action, err))
+			continue
+		}
+		bound = append(bound, boundAction{b, actions[action]})
+	}
+
+	a.main.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		for _, b := range bound {
+			if b.match(event) {
+				b.run()
+				return nil
+			}
+		}
+		return event
+	})
+	return errors.Join(errs...)
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *tcell.EventKey
+	}{
+		{"F2", tcell.NewEventKey(tcell.KeyF2, 0, tcell.ModNone)},
+		{"ctrl-p", tcell.NewEventKey(tcell.KeyCtrlP, 0, tcell.ModCtrl)},
+		{"Alt-x", tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModAlt)},
+	}
+	for _, tt := range tests {
+		b, err := parseKey(tt.name)
+		if err != nil {
+			t.Fatalf("parseKey(%q): %v", tt.name, err)
+		}
+		if !b.match(tt.event) {
+			t.Errorf("%q doesn't match %v", tt.name, tt.event.Name())
+		}
+	}
+	if b, _ := parseKey("Alt-x"); b.match(tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone)) {
+		t.Error("Alt-x matches x")
+	}
+	for _, name := range []string{"x", "Hyper-Q", ""} {
+		if _, err := parseKey(name); err == nil {
+			t.Errorf("parseKey(%q) no error", name)
+		}
+	}
+}
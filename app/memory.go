@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/liwnn/redisterm/model"
+	"github.com/liwnn/redisterm/tlog"
+	"github.com/liwnn/redisterm/view"
+
+	"github.com/rivo/tview"
+)
+
+const (
+	memoryKeyLimit = 100000
+	memoryTopN     = 100
+)
+
+// analyzeMemory samples the memory usage of the keys below node in the
+// background, then shows the sizes in the tree and the biggest keys in the
+// MEMORY page.
+func (t *DBTree) analyzeMemory(node *tview.TreeNode, r *Reference) {
+	prefix := ""
+	if r.Name == "dir" {
+		prefix = r.Data.Key()
+	}
+	text := fmt.Sprintf("Analyzing memory of db%v %v*", r.Index, model.FormatKey(prefix))
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := t.ShowProgress(text, cancel)
+	go func() {
+		defer cancel()
+		report, err := t.data.AnalyzeMemory(ctx, r.Index, prefix, memoryKeyLimit, func(scanned int) {
+			t.QueueUpdateDraw(func() {
+				progress.Update(text, scanned, 0)
+			})
+		})
+		t.QueueUpdateDraw(func() {
+			progress.Hide()
+			if err != nil {
+				if err != context.Canceled {
+					t.ShowModalOK(err.Error())
+				}
+				tlog.Error("[Memory] scan", "err", err)
+				return
+			}
+			t.data.ApplyMemory(r.Index, r.Data, report)
+			t.refreshText(node)
+			t.ShowMemoryReport(report)
+		})
+	}()
+}
+
+// memoryPage shows the biggest keys of the last memory analysis.
+type memoryPage struct {
+	app   *App
+	table *view.ReportTable
+}
+
+func newMemoryPage(a *App) *memoryPage {
+	table := view.NewReportTable("MEMORY", func(p tview.Primitive) {
+		a.main.SetFocus(p)
+	})
+	table.SetColumns([]view.ReportColumn{
+		{Name: "key", Expansion: 8},
+		{Name: "type", Expansion: 1},
+		{Name: "size", Expansion: 1, Numeric: true},
+		{Name: "elements", Expansion: 1, Numeric: true},
+	})
+	table.SortBy(2, true)
+	return &memoryPage{
+		app:   a,
+		table: table,
+	}
+}
+
+// Show the top keys of report.
+func (p *memoryPage) Show(report *model.MemoryReport) {
+	top := report.Top(memoryTopN)
+	rows := make([]view.Row, 0, len(top))
+	for _, k := range top {
+		rows = append(rows, view.Row{
+			model.FormatKey(k.Key),
+			k.Type,
+			model.FormatBytes(k.Bytes),
+			strconv.FormatInt(k.Elements, 10),
+		})
+	}
+	p.table.SetRows(rows)
+
+	status := fmt.Sprintf("%v*: %v keys, %v", model.FormatKey(report.Prefix), len(report.Keys), model.FormatBytes(report.Total))
+	if report.Truncated {
+		status += fmt.Sprintf(" (stopped at %v keys)", memoryKeyLimit)
+	}
+	p.table.SetStatus(status)
+	p.app.main.ShowBottomPage(p.table.Title())
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/liwnn/redisterm/redisapi"
+	"github.com/liwnn/redisterm/secret"
+	"github.com/liwnn/redisterm/tlog"
+	"github.com/liwnn/redisterm/view"
+)
+
+// passphraseEnv is the environment variable unlocking the vault without
+// asking the passphrase.
+const passphraseEnv = "REDIS_TERM_PASSPHRASE"
+
+// resolveAuth resolves the password of config, asking the passphrase when
+// the vault is locked, and calls ok with it. References are resolved in
+// the background, commands such as pass may take a while.
+func (a *App) resolveAuth(config redisapi.RedisConfig, ok func(auth string)) {
+	if config.AuthRef == "" {
+		ok(config.Auth)
+		return
+	}
+	go func() {
+		auth, err := a.secrets.Password(config.Auth, config.AuthRef)
+		a.main.QueueUpdateDraw(func() {
+			if errors.Is(err, secret.ErrLocked) {
+				a.unlockVault(func() {
+					a.resolveAuth(config, ok)
+				})
+				return
+			}
+			if err != nil {
+				tlog.Error("[Secret] resolve", "conn", config.Name, "err", err)
+				a.main.ShowModalOK(fmt.Sprintf("Password of %v: %v", config.Name, err))
+				return
+			}
+			ok(auth)
+		})
+	}()
+}
+
+// unlockVault asks the master passphrase, twice when the vault is
+// created, unlocks the vault and calls ok.
+func (a *App) unlockVault(ok func()) {
+	if p := os.Getenv(passphraseEnv); p != "" {
+		err := a.vault.Unlock(p)
+		if err == nil {
+			ok()
+			return
+		}
+		tlog.Warn("[Secret] unlock with "+passphraseEnv, "err", err)
+	}
+
+	title := "Unlock the vault"
+	fields := []view.PromptField{{Label: "Master passphrase:", Mask: true}}
+	if !a.vault.Exists() {
+		title = "Create the vault"
+		fields = append(fields, view.PromptField{Label: "Repeat passphrase:", Mask: true})
+	}
+	a.main.ShowPrompt(title, fields, func(values []string) {
+		if len(values) > 1 && values[0] != values[1] {
+			a.main.ShowModalOK("The passphrases differ, the vault was not created")
+			return
+		}
+		// deriving the key takes a moment.
+		go func() {
+			err := a.vault.Unlock(values[0])
+			a.main.QueueUpdateDraw(func() {
+				if err != nil {
+					tlog.Error("[Secret] unlock", "err", err)
+					a.main.ShowModalOK(fmt.Sprintf("Unlock the vault: %v", err))
+					return
+				}
+				ok()
+			})
+		}()
+	})
+}
+
+// migrateSecrets offers to move the plaintext passwords of the config to
+// the vault, then calls then whatever the answer.
+func (a *App) migrateSecrets(then func()) {
+	var plain []int
+	for i := range a.cfg.GetDbNames() {
+		if conf := a.cfg.GetConfig(i); conf.Auth != "" && conf.AuthRef == "" {
+			plain = append(plain, i)
+		}
+	}
+	if len(plain) == 0 {
+		then()
+		return
+	}
+
+	text := fmt.Sprintf("%v connection(s) keep their password in clear in the config. Move the passwords to the encrypted vault?", len(plain))
+	a.main.ShowModalChoice(text, func(ok bool) {
+		if !ok {
+			then()
+			return
+		}
+		a.unlockVault(func() {
+			for _, i := range plain {
+				conf := a.cfg.GetConfig(i)
+				name, err := a.vault.Store(conf.Name, conf.Auth)
+				if err != nil {
+					tlog.Error("[Secret] store", "conn", conf.Name, "err", err)
+					a.main.ShowModalOK(fmt.Sprintf("Store the password of %v: %v", conf.Name, err))
+					break
+				}
+				conf.Auth, conf.AuthRef = "", "vault:"+name
+				a.cfg.Update(conf, i)
+			}
+			if err := a.cfg.Save(); err != nil {
+				tlog.Error("[Secret] save config", "err", err)
+			} else {
+				tlog.Info("[Secret] passwords moved to the vault", "connections", len(plain))
+			}
+			then()
+		})
+	})
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/liwnn/redisterm/redis"
+	"github.com/liwnn/redisterm/tlog"
+	"github.com/liwnn/redisterm/view"
+
+	"github.com/rivo/tview"
+)
+
+const slowLogCount = 128
+
+// slowLogPage shows the slow log of the current connection.
+type slowLogPage struct {
+	app   *App
+	table *view.ReportTable
+}
+
+func newSlowLogPage(a *App) *slowLogPage {
+	table := view.NewReportTable("SLOWLOG", func(p tview.Primitive) {
+		a.main.SetFocus(p)
+	})
+	table.SetColumns([]view.ReportColumn{
+		{Name: "id", Expansion: 1, Numeric: true},
+		{Name: "time", Expansion: 3},
+		{Name: "duration(us)", Expansion: 2, Numeric: true},
+		{Name: "command", Expansion: 10},
+		{Name: "client", Expansion: 3},
+		{Name: "name", Expansion: 2},
+	})
+	p := &slowLogPage{
+		app:   a,
+		table: table,
+	}
+	table.AddButton("Refresh", p.Refresh)
+	table.AddButton("Reset", p.reset)
+	table.SortBy(0, true)
+	return p
+}
+
+// Refresh reload the slow log.
+func (p *slowLogPage) Refresh() {
+	if p.app.tree == nil {
+		return
+	}
+	entries, err := p.app.tree.data.SlowLog(slowLogCount)
+	if err != nil {
+		tlog.Error("[SlowLog] list", "err", err)
+		p.table.SetStatus(err.Error())
+		return
+	}
+	rows := make([]view.Row, 0, len(entries))
+	for _, e := range entries {
+		args := make([]string, 0, len(e.Args))
+		for _, arg := range e.Args {
+			args = append(args, redis.QuoteArg(arg))
+		}
+		rows = append(rows, view.Row{
+			strconv.FormatInt(e.ID, 10),
+			e.Time.Format("2006-01-02 15:04:05"),
+			strconv.FormatInt(e.Duration.Microseconds(), 10),
+			strings.Join(args, " "),
+			e.ClientAddr,
+			e.ClientName,
+		})
+	}
+	p.table.SetRows(rows)
+	p.table.SetStatus("")
+}
+
+func (p *slowLogPage) reset() {
+	if p.app.tree == nil {
+		return
+	}
+	p.app.main.ShowModal("Reset the slow log?", func() {
+		if err := p.app.tree.data.SlowLogReset(); err != nil {
+			p.app.main.ShowModalOK(err.Error())
+			return
+		}
+		p.Refresh()
+	})
+}
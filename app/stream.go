@@ -0,0 +1,78 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/liwnn/redisterm/redisapi"
+	"github.com/liwnn/redisterm/view"
+)
+
+// startStream runs a SUBSCRIBE, PSUBSCRIBE, SSUBSCRIBE or MONITOR command on
+// a dedicated connection and shows its messages in the STREAM page.
+func (a *App) startStream(w io.Writer, args []string) {
+	a.stopStream()
+	sub, err := a.tree.data.Subscribe(args[0], args[1:]...)
+	if err != nil {
+		fmt.Fprintln(w, err)
+		return
+	}
+	a.stream = sub
+
+	streamView := a.main.GetStream()
+	streamView.Start(strings.Join(args, " "), a.stopStream)
+	a.main.ShowBottomPage(streamView.Title())
+	a.main.SetFocus(streamView)
+	fmt.Fprintf(w, "Streaming to the %v page, press q there to stop.\n", streamView.Title())
+
+	go func() {
+		for {
+			m, err := sub.Receive()
+			if err != nil {
+				a.main.QueueUpdateDraw(func() {
+					if a.stream == sub {
+						a.stream = nil
+						streamView.Stopped(err)
+					}
+				})
+				return
+			}
+			msg := streamMessage(m)
+			a.main.QueueUpdateDraw(func() {
+				if a.stream == sub {
+					streamView.AddMessage(msg)
+				}
+			})
+		}
+	}()
+}
+
+// stopStream closes the streaming connection, if any.
+func (a *App) stopStream() {
+	if a.stream == nil {
+		return
+	}
+	a.stream.Close()
+	a.stream = nil
+	a.main.GetStream().Stopped(nil)
+}
+
+func streamMessage(m redisapi.Message) view.StreamMessage {
+	msg := view.StreamMessage{
+		Time:    time.Now(),
+		Channel: m.Channel,
+	}
+	switch m.Kind {
+	case "message", "smessage":
+		msg.Text = m.Payload
+	case "pmessage":
+		msg.Text = fmt.Sprintf("(%v) %v", m.Pattern, m.Payload)
+	case "monitor":
+		msg.Text = m.Payload
+	default:
+		msg.Text = fmt.Sprintf("%v %v", m.Kind, m.Payload)
+	}
+	return msg
+}
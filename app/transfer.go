@@ -0,0 +1,140 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/liwnn/redisterm/model"
+	"github.com/liwnn/redisterm/tlog"
+	"github.com/liwnn/redisterm/view"
+
+	"github.com/rivo/tview"
+)
+
+// transferKeys asks for a target connection and database, then copies or
+// moves the keys of node there in the background.
+func (t *DBTree) transferKeys(node *tview.TreeNode, r *Reference, move bool) {
+	if move {
+		if err := t.data.CheckWrite(); err != nil {
+			t.ShowModalOK(err.Error())
+			return
+		}
+	}
+	opts := model.TransferOptions{
+		Index: r.Index,
+		Move:  move,
+	}
+	if r.Name != "index" {
+		opts.Prefix = r.Data.Key()
+		opts.Exact = r.Name == "key"
+	}
+	title := exportTitle(model.ExportOptions{Index: opts.Index, Prefix: opts.Prefix, Exact: opts.Exact})
+	t.promptTransfer(opts, title, nil)
+}
+
+// promptTransfer asks for the target of opts, then copies or moves the
+// keys. moved, if not nil, is called once keys were moved, before the
+// database is scanned again.
+func (t *DBTree) promptTransfer(opts model.TransferOptions, title string, moved func()) {
+	verb := "Copy"
+	if opts.Move {
+		verb = "Move"
+	}
+	t.promptTarget(verb+" "+title, opts.Index, func(target *model.Data, name string, db int) {
+		opts.TargetIndex = db
+		to := fmt.Sprintf("%v db%v", name, db)
+		run := func() { t.runTransfer(opts, target, fmt.Sprintf("%v %v to %v", verb, title, to), moved) }
+		if opts.Move {
+			t.ShowModal(fmt.Sprintf("Move %v to %v? The keys are deleted here once copied.", title, to), run)
+			return
+		}
+		run()
+	})
+}
+
+// promptTarget asks for a configured connection and a database, the
+// shown connection and database index by default.
+func (t *DBTree) promptTarget(title string, index int, ok func(target *model.Data, name string, db int)) {
+	names, current := t.Connections()
+	if len(names) == 0 {
+		return
+	}
+	fields := []view.PromptField{
+		{Label: "Connection:", Text: names[max(current, 0)], Options: names},
+		{Label: "DB:", Text: strconv.Itoa(index)},
+	}
+	t.ShowPrompt(title, fields, func(values []string) {
+		conn := -1
+		for i, name := range names {
+			if name == values[0] {
+				conn = i
+			}
+		}
+		db, err := strconv.Atoi(strings.TrimSpace(values[1]))
+		if conn < 0 || err != nil || db < 0 {
+			t.ShowModalOK("Invalid target database")
+			return
+		}
+		t.Connection(conn, func(target *model.Data) {
+			ok(target, names[conn], db)
+		})
+	})
+}
+
+func (t *DBTree) runTransfer(opts model.TransferOptions, target *model.Data, text string, moved func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := t.ShowProgress(text, cancel)
+	go func() {
+		defer cancel()
+		result, err := t.data.Transfer(ctx, target, opts, func(done int) {
+			t.QueueUpdateDraw(func() {
+				progress.Update(text, done, 0)
+			})
+		})
+		t.QueueUpdateDraw(func() {
+			progress.Hide()
+			// keys may have been moved or copied before an error, their
+			// databases are scanned again when expanded.
+			if opts.Move {
+				if moved != nil {
+					moved()
+				}
+				if n := t.resetDB(opts.Index); n != nil {
+					t.tree.SetCurrentNode(n)
+					t.OnChanged(n)
+				}
+			}
+			if target == t.data {
+				t.resetDB(opts.TargetIndex)
+			}
+			if err != nil {
+				if err != context.Canceled {
+					t.ShowModalOK(err.Error())
+				}
+				tlog.Error("[Transfer] failed", "err", err)
+				return
+			}
+			msg := fmt.Sprintf("%v: %v keys (%v restored, %v rewritten)", text, result.Keys(), result.Restored, result.Copied)
+			if result.Skipped > 0 {
+				msg += fmt.Sprintf(", skipped %v", result.Skipped)
+			}
+			if result.Errors > 0 {
+				msg += fmt.Sprintf(", %v errors\n%v", result.Errors, strings.Join(result.FirstErrors, "\n"))
+			}
+			tlog.Info("[Transfer] done", "move", opts.Move, "keys", result.Keys(), "restored", result.Restored, "rewritten", result.Copied, "skipped", result.Skipped, "errors", result.Errors)
+			t.ShowModalOK(msg)
+		})
+	}()
+}
+
+// indexNode returns the tree node of database index, nil if not loaded.
+func (t *DBTree) indexNode(index int) *tview.TreeNode {
+	for _, node := range t.tree.GetRoot().GetChildren() {
+		if r := t.getReference(node); r != nil && r.Name == "index" && r.Index == index {
+			return node
+		}
+	}
+	return nil
+}
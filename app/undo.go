@@ -0,0 +1,63 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/liwnn/redisterm/model"
+	"github.com/liwnn/redisterm/tlog"
+	"github.com/liwnn/redisterm/view"
+)
+
+// undoItems returns the undo items of the action menu, none without
+// recorded changes.
+func (t *DBTree) undoItems() []view.MenuItem {
+	if len(t.data.UndoHistory()) == 0 {
+		return nil
+	}
+	return []view.MenuItem{
+		{Label: "Undo last", Action: t.undoLast},
+		{Label: "Undo history", Action: func() { t.ShowUndoHistory(t.data.UndoHistory()) }},
+	}
+}
+
+// undoLast undoes the last recorded change once confirmed. A change that
+// can't be undone is dropped instead, to undo the changes before it.
+func (t *DBTree) undoLast() {
+	history := t.data.UndoHistory()
+	if len(history) == 0 {
+		t.ShowModalOK(model.ErrNothingToUndo.Error())
+		return
+	}
+	last := history[len(history)-1]
+	if !last.Undoable() {
+		notice := fmt.Sprintf("%v in db%v can't be undone: %v.\nDrop it to undo the changes before?", last.Op, last.Index, last.Reason)
+		t.ShowModal(notice, func() {
+			if _, err := t.data.Undo(); err != nil {
+				tlog.Error("[Undo] undo", "err", err)
+			}
+		})
+		return
+	}
+	t.ShowModal(fmt.Sprintf("Undo %v in db%v?", last.Op, last.Index), func() {
+		go func() {
+			e, err := t.data.Undo()
+			t.QueueUpdateDraw(func() {
+				if e != nil && e.Undoable() {
+					// Keys are scanned again when the database is expanded.
+					if n := t.resetDB(e.Index); n != nil {
+						t.refreshText(n)
+						t.tree.SetCurrentNode(n)
+						t.OnChanged(n)
+					}
+				}
+				if err != nil {
+					tlog.Error("[Undo] undo", "err", err)
+					t.ShowModalOK(err.Error())
+					return
+				}
+				tlog.Info("[Undo] undone", "op", e.Op)
+				t.ShowModalOK("Undone: " + e.Op)
+			})
+		}()
+	})
+}
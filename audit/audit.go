@@ -0,0 +1,261 @@
+// Package audit writes the write commands sent by redis-term to a file, as
+// JSON lines rotated by size.
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a line of the audit log.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Conn    string    `json:"conn"`
+	DB      int       `json:"db"`
+	Command string    `json:"command"`
+	Key     string    `json:"key,omitempty"`
+	// Args are the arguments of commands without key, with secrets
+	// redacted. Values are never logged.
+	Args    []string `json:"args,omitempty"`
+	Outcome string   `json:"outcome"`
+}
+
+// Outcome returns the outcome of a command that failed with err, or "ok".
+func Outcome(err error) string {
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return "ok"
+}
+
+// Logger appends entries to a file. Once the file is above maxSize bytes
+// it is renamed with a .1 suffix, the older files shifted up to .backups.
+// A nil Logger discards entries.
+type Logger struct {
+	mu       sync.Mutex
+	filename string
+	maxSize  int64
+	backups  int
+	file     *os.File
+	size     int64
+}
+
+// Open opens the audit log filename, created readable by the user only.
+func Open(filename string, maxSize int64, backups int) (*Logger, error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return nil, err
+	}
+	l := &Logger{filename: filename, maxSize: maxSize, backups: backups}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Logger) open() error {
+	f, err := os.OpenFile(l.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file = f
+	l.size = info.Size()
+	return nil
+}
+
+// Log appends entries to the log, in a single write.
+func (l *Logger) Log(entries ...Entry) error {
+	if l == nil || len(entries) == 0 {
+		return nil
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return os.ErrClosed
+	}
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(b.Len()) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(b.Bytes())
+	l.size += int64(n)
+	return err
+}
+
+// rotate shifts the files of the log and opens a new one.
+func (l *Logger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	l.file = nil
+	if l.backups > 0 {
+		for i := l.backups - 1; i > 0; i-- {
+			os.Rename(backupName(l.filename, i), backupName(l.filename, i+1))
+		}
+		if err := os.Rename(l.filename, backupName(l.filename, 1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(l.filename); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+func backupName(filename string, i int) string {
+	return fmt.Sprintf("%v.%v", filename, i)
+}
+
+// Close closes the log file.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// adminCommands are the commands changing the server without being
+// flagged as write by COMMAND.
+var adminCommands = map[string]bool{
+	"CONFIG SET":       true,
+	"CONFIG REWRITE":   true,
+	"CONFIG RESETSTAT": true,
+	"ACL SETUSER":      true,
+	"ACL DELUSER":      true,
+	"ACL LOAD":         true,
+	"ACL SAVE":         true,
+	"CLIENT KILL":      true,
+	"SLOWLOG RESET":    true,
+	"SCRIPT FLUSH":     true,
+	"SCRIPT KILL":      true,
+	"FUNCTION KILL":    true,
+	"MODULE LOAD":      true,
+	"MODULE UNLOAD":    true,
+	"SHUTDOWN":         true,
+	"DEBUG":            true,
+	"SAVE":             true,
+	"BGSAVE":           true,
+	"BGREWRITEAOF":     true,
+	"REPLICAOF":        true,
+	"SLAVEOF":          true,
+	"FAILOVER":         true,
+}
+
+// IsAdminCommand tells if the command changes the server though COMMAND
+// doesn't flag it as write. name is upper case, with the subcommand after
+// a space as in "CONFIG SET".
+func IsAdminCommand(name string) bool {
+	return adminCommands[name]
+}
+
+// redacted replaces secrets.
+const redacted = "***"
+
+// Redact returns args of the command with the passwords replaced: the
+// arguments of AUTH, the password after AUTH of HELLO and MIGRATE, the
+// values of CONFIG SET parameters about passwords and keys, and the
+// password rules of ACL SETUSER. cmd is upper case, with the subcommand
+// after a space as in "CONFIG SET".
+func Redact(cmd string, args []string) []string {
+	args = append([]string(nil), args...)
+	switch cmd {
+	case "AUTH":
+		for i := range args {
+			args[i] = redacted
+		}
+	case "HELLO", "MIGRATE":
+		for i := 0; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "AUTH":
+				if i+2 < len(args) && cmd == "HELLO" {
+					// HELLO protover AUTH username password
+					args[i+2] = redacted
+					i += 2
+				} else if i+1 < len(args) {
+					args[i+1] = redacted
+					i++
+				}
+			case "AUTH2":
+				if i+2 < len(args) {
+					args[i+2] = redacted
+					i += 2
+				}
+			}
+		}
+	case "CONFIG SET":
+		for i := 0; i+1 < len(args); i += 2 {
+			if isSecretParam(args[i]) {
+				args[i+1] = redacted
+			}
+		}
+	case "ACL SETUSER":
+		for i, rule := range args {
+			if i > 0 && rule != "" && strings.ContainsRune("><#!", rune(rule[0])) {
+				args[i] = rule[:1] + redacted
+			}
+		}
+	}
+	return args
+}
+
+// RedactCommand returns the arguments of cmd with the passwords replaced,
+// as Redact, cmd and its subcommand in args in any case.
+func RedactCommand(cmd string, args []string) []string {
+	name := strings.ToUpper(cmd)
+	if len(args) > 0 {
+		if sub := name + " " + strings.ToUpper(args[0]); sub == "CONFIG SET" || sub == "ACL SETUSER" {
+			return append([]string{args[0]}, Redact(sub, args[1:])...)
+		}
+	}
+	return Redact(name, args)
+}
+
+// HasSecret tells if the command line args, the command first in any
+// case, has a password replaced by RedactCommand.
+func HasSecret(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	redacted := RedactCommand(args[0], args[1:])
+	for i, arg := range redacted {
+		if arg != args[i+1] {
+			return true
+		}
+	}
+	return false
+}
+
+func isSecretParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{"pass", "auth", "secret", "key"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		args []string
+		want string
+	}{
+		{"AUTH", []string{"user", "secret"}, "*** ***"},
+		{"HELLO", []string{"3", "AUTH", "user", "secret", "SETNAME", "x"}, "3 AUTH user *** SETNAME x"},
+		{"MIGRATE", []string{"h", "6379", "k", "0", "100", "AUTH", "secret"}, "h 6379 k 0 100 AUTH ***"},
+		{"MIGRATE", []string{"h", "6379", "", "0", "100", "AUTH2", "user", "secret", "KEYS", "a"}, "h 6379  0 100 AUTH2 user *** KEYS a"},
+		{"CONFIG SET", []string{"requirepass", "secret", "maxmemory", "1gb"}, "requirepass *** maxmemory 1gb"},
+		{"ACL SETUSER", []string{"bob", "on", ">secret", "~*"}, "bob on >*** ~*"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(Redact(tt.cmd, tt.args), " "); got != tt.want {
+			t.Errorf("Redact(%v) = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+	if got := RedactCommand("config", []string{"set", "masterauth", "secret"}); strings.Join(got, " ") != "set masterauth ***" {
+		t.Errorf("RedactCommand = %q", got)
+	}
+	args := []string{"secret"}
+	Redact("AUTH", args)
+	if args[0] != "secret" {
+		t.Error("Redact changed its argument")
+	}
+}
+
+func TestHasSecret(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"auth secret", true},
+		{"AUTH user secret", true},
+		{"hello 3 auth user secret", true},
+		{"acl setuser bob on >secret", true},
+		{"config set requirepass secret", true},
+		{"config set maxmemory 1gb", false},
+		{"acl setuser bob on ~*", false},
+		{"hello 3", false},
+		{"get auth", false},
+	}
+	for _, tt := range tests {
+		if got := HasSecret(strings.Fields(tt.line)); got != tt.want {
+			t.Errorf("HasSecret(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestLogger(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit", "audit.log")
+	l, err := Open(filename, 200, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := Entry{Time: time.Unix(0, 0).UTC(), Conn: "local", Command: "SET", Key: "user:1", Outcome: Outcome(nil)}
+	for i := 0; i < 5; i++ {
+		e.DB = i
+		if err := l.Log(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Log(Entry{Command: "DEL", Outcome: Outcome(errors.New("ERR x"))}); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode %v", info.Mode().Perm())
+	}
+	if _, err := os.Stat(filename + ".3"); err == nil {
+		t.Error("more backups than asked")
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var last Entry
+	for s := bufio.NewScanner(f); s.Scan(); {
+		if err := json.Unmarshal(s.Bytes(), &last); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if last.Command != "DEL" || last.Outcome != "error: ERR x" {
+		t.Errorf("last entry %+v", last)
+	}
+	b, err := os.ReadFile(filename + ".1")
+	if err != nil || !strings.Contains(string(b), `"key":"user:1"`) {
+		t.Errorf("backup %q %v", b, err)
+	}
+}
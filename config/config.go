@@ -2,16 +2,28 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/liwnn/redisterm/redisapi"
+	"github.com/liwnn/redisterm/tlog"
 )
 
+// Config is the config file of redis-term, see Document.
 type Config struct {
 	filename string
-	configs  []redisapi.RedisConfig
+	doc      Document
+	// broken is why the file could not be loaded, Save refusing to
+	// overwrite it.
+	broken error
 }
 
+// NewConfig loads the config filename, JSON, YAML or TOML by its
+// extension. A config of an older version is migrated, the old file kept
+// with a .bak suffix. When the file can't be loaded the default config is
+// returned with the error.
 func NewConfig(filename string) (*Config, error) {
 	if filename[0] == '~' {
 		filename = os.Getenv("HOME") + filename[1:]
@@ -19,66 +31,199 @@ func NewConfig(filename string) (*Config, error) {
 
 	c := &Config{
 		filename: filename,
-		configs: []redisapi.RedisConfig{
-			{
-				Name: "127.0.0.1:6379",
-				Host: "127.0.0.1",
-				Port: 6379,
-				Auth: "",
-			},
+		doc: Document{
+			Version:     Version,
+			Connections: []redisapi.RedisConfig{defaultConnection()},
 		},
 	}
 	if _, err := os.Stat(c.filename); err == nil || os.IsExist(err) {
 		if err := c.load(); err != nil {
-			return nil, err
+			c.broken = fmt.Errorf("%v: %w", c.filename, err)
+			return c, c.broken
 		}
 	}
 	return c, nil
 }
 
+func defaultConnection() redisapi.RedisConfig {
+	return redisapi.RedisConfig{
+		Name: "127.0.0.1:6379",
+		Host: "127.0.0.1",
+		Port: 6379,
+		Auth: "",
+	}
+}
+
 func (c *Config) load() error {
 	b, err := os.ReadFile(c.filename)
 	if err != nil {
 		return err
 	}
+	// older versions wrote the config readable by everyone.
+	if info, err := os.Stat(c.filename); err == nil && info.Mode().Perm()&0077 != 0 {
+		if err := os.Chmod(c.filename, 0600); err != nil {
+			return err
+		}
+	}
 
-	var configs []redisapi.RedisConfig
-	if err := json.Unmarshal(b, &configs); err != nil {
+	j, err := toJSON(fileFormat(c.filename), b)
+	if err != nil {
 		return err
 	}
-	c.configs = configs
+	doc, migrated, err := decodeDocument(j)
+	if err != nil {
+		return err
+	}
+	if len(doc.Connections) == 0 {
+		doc.Connections = []redisapi.RedisConfig{defaultConnection()}
+	}
+	c.doc = doc
+	if doc.Version > Version {
+		// the fields this version doesn't know would be lost.
+		c.broken = fmt.Errorf("%v: version %v is newer than %v", c.filename, doc.Version, Version)
+		return nil
+	}
+	if migrated {
+		backup := c.filename + ".bak"
+		if err := os.WriteFile(backup, b, 0600); err != nil {
+			return err
+		}
+		if err := c.Save(); err != nil {
+			return err
+		}
+		tlog.Info("[Config] migrated", "file", c.filename, "version", Version, "backup", backup)
+	}
 	return nil
 }
 
+// Save writes the config in the format of its file, readable by the user
+// only.
 func (c *Config) Save() error {
-	b, err := json.MarshalIndent(c.configs, "", "  ")
+	if c.broken != nil {
+		return fmt.Errorf("not saved, fix the config first: %w", c.broken)
+	}
+	if c.doc.Version < Version {
+		c.doc.Version = Version
+	}
+	b, err := json.MarshalIndent(&c.doc, "", "  ")
 	if err != nil {
 		return err
 	}
+	if b, err = fromJSON(fileFormat(c.filename), b); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(c.filename, b, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(c.filename, 0600)
+}
+
+// Validate returns the errors of the config, joined.
+func (c *Config) Validate() error {
+	return c.doc.Validate()
+}
 
-	return os.WriteFile(c.filename, b, 0666)
+// Preferences returns the global preferences.
+func (c *Config) Preferences() Preferences {
+	return c.doc.Preferences
+}
+
+// HistoryFile returns the console history file of a connection, kept in a
+// directory next to the config file.
+func (c *Config) HistoryFile(name string) string {
+	dir := strings.TrimSuffix(c.filename, filepath.Ext(c.filename)) + ".history"
+	return filepath.Join(dir, SanitizeFileName(name))
+}
+
+// AuditFile returns the default audit log file, next to the config file.
+func (c *Config) AuditFile() string {
+	return strings.TrimSuffix(c.filename, filepath.Ext(c.filename)) + ".audit.log"
+}
+
+// VaultFile returns the file of the encrypted passwords, next to the config
+// file.
+func (c *Config) VaultFile() string {
+	return strings.TrimSuffix(c.filename, filepath.Ext(c.filename)) + ".vault"
+}
+
+// SanitizeFileName replaces the characters of name unsafe in a file name.
+func SanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, name)
 }
 
 func (c *Config) GetConfig(index int) redisapi.RedisConfig {
-	return c.configs[index]
+	return c.doc.Connections[index]
 }
 
 func (c *Config) GetDbNames() []string {
-	var s = make([]string, 0, len(c.configs))
-	for _, v := range c.configs {
+	var s = make([]string, 0, len(c.doc.Connections))
+	for _, v := range c.doc.Connections {
 		s = append(s, v.Name)
 	}
 	return s
 }
 
 func (c *Config) Update(conf redisapi.RedisConfig, index int) {
-	if index < 0 || index >= len(c.configs) {
+	if index < 0 || index >= len(c.doc.Connections) {
 		c.Add(conf)
 		return
 	}
-	c.configs[index] = conf
+	c.doc.Connections[index] = conf
 }
 
 func (c *Config) Add(conf redisapi.RedisConfig) {
-	c.configs = append(c.configs, conf)
+	c.doc.Connections = append(c.doc.Connections, conf)
+}
+
+// Len returns the number of connections.
+func (c *Config) Len() int {
+	return len(c.doc.Connections)
+}
+
+// Delete removes connection index.
+func (c *Config) Delete(index int) {
+	if index < 0 || index >= len(c.doc.Connections) {
+		return
+	}
+	c.doc.Connections = append(c.doc.Connections[:index], c.doc.Connections[index+1:]...)
+}
+
+// Duplicate inserts a copy of connection index after it, named after it,
+// and returns the index of the copy.
+func (c *Config) Duplicate(index int) int {
+	conf := c.doc.Connections[index]
+	if conf.Guard != nil {
+		guard := *conf.Guard
+		guard.Commands = append([]string(nil), guard.Commands...)
+		conf.Guard = &guard
+	}
+	conf.Name = c.uniqueName(conf.Name + " copy")
+	c.doc.Connections = append(c.doc.Connections[:index+1], append([]redisapi.RedisConfig{conf}, c.doc.Connections[index+1:]...)...)
+	return index + 1
+}
+
+// Swap swaps the connections i and j.
+func (c *Config) Swap(i, j int) {
+	c.doc.Connections[i], c.doc.Connections[j] = c.doc.Connections[j], c.doc.Connections[i]
+}
+
+// uniqueName returns name, or name followed by a number when a connection
+// already has it.
+func (c *Config) uniqueName(name string) string {
+	taken := make(map[string]bool, len(c.doc.Connections))
+	for _, conf := range c.doc.Connections {
+		taken[conf.Name] = true
+	}
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = fmt.Sprintf("%v %v", name, i)
+	}
+	return unique
 }
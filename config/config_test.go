@@ -0,0 +1,224 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/liwnn/redisterm/redisapi"
+)
+
+func TestConnections(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "redis-term.json")
+	if err := os.WriteFile(filename, []byte(`[{"name":"a","host":"h","port":1,"auth":""}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Add(redisapi.RedisConfig{Name: "b", Guard: &redisapi.GuardConfig{Commands: []string{"FLUSHDB"}}})
+	if i := c.Duplicate(1); i != 2 {
+		t.Errorf("Duplicate = %v", i)
+	}
+	c.Duplicate(1)
+	c.GetConfig(2).Guard.Commands[0] = "KEYS"
+	if want := []string{"a", "b", "b copy 2", "b copy"}; !reflect.DeepEqual(c.GetDbNames(), want) {
+		t.Errorf("names %v, want %v", c.GetDbNames(), want)
+	}
+	if c.GetConfig(1).Guard.Commands[0] != "FLUSHDB" {
+		t.Error("the copy shares the guard")
+	}
+	c.Swap(0, 3)
+	c.Delete(1)
+	if want := []string{"b copy", "b copy 2", "a"}; !reflect.DeepEqual(c.GetDbNames(), want) {
+		t.Errorf("names %v, want %v", c.GetDbNames(), want)
+	}
+
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode %v", info.Mode().Perm())
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "redis-term.json")
+	old := []byte(`[{"name":"a","host":"h","port":1,"auth":""}]`)
+	if err := os.WriteFile(filename, old, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewConfig(filename); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filename + ".bak"); err != nil || string(b) != string(old) {
+		t.Errorf("backup %q, %v", b, err)
+	}
+	c, err := NewConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.doc.Version != Version || !reflect.DeepEqual(c.GetDbNames(), []string{"a"}) {
+		t.Errorf("migrated to %+v", c.doc)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	for _, ext := range []string{".yaml", ".toml"} {
+		filename := filepath.Join(t.TempDir(), "redis-term"+ext)
+		c, err := NewConfig(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.doc.Preferences = Preferences{
+			Separator:   "/",
+			PageSize:    500,
+			Keybindings: map[string]string{"connections": "Ctrl-P"},
+			Formatters:  []Formatter{{Pattern: "json:*", Format: "json"}},
+		}
+		c.Update(redisapi.RedisConfig{Name: "b", Host: "h", Port: 6380, Guard: &redisapi.GuardConfig{Commands: []string{"FLUSHDB"}}}, 1)
+		if err := c.Save(); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := NewConfig(filename)
+		if err != nil {
+			t.Fatal(ext, err)
+		}
+		if !reflect.DeepEqual(loaded.doc, c.doc) {
+			t.Errorf("%v: loaded %+v, want %+v", ext, loaded.doc, c.doc)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	d := Document{
+		Version: Version,
+		Preferences: Preferences{
+			Separator:  "::",
+			Formatters: []Formatter{{Pattern: "*", Format: "xml"}},
+			ClientName: "my term",
+		},
+		Connections: []redisapi.RedisConfig{
+			{Name: "a", Host: "h", Port: 1},
+			{Name: "a", Port: 70000},
+		},
+	}
+	err := d.Validate()
+	if err == nil {
+		t.Fatal("no error")
+	}
+	for _, want := range []string{"used twice", "no host", "out of range", "single character", "unknown format", "client_name"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: no %q", err, want)
+		}
+	}
+	d.Preferences = Preferences{}
+	d.Connections = d.Connections[:1]
+	if err := d.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewerVersion(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "redis-term.json")
+	newer := `{"version": 99, "connections": [{"name":"a","host":"h","port":1}]}`
+	if err := os.WriteFile(filename, []byte(newer), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Validate() == nil {
+		t.Error("newer version is valid")
+	}
+	if c.Save() == nil {
+		t.Error("newer version saved")
+	}
+	if b, _ := os.ReadFile(filename); string(b) != newer {
+		t.Errorf("overwritten: %s", b)
+	}
+}
+
+func TestImport(t *testing.T) {
+	ardm := `[{"name":"prod","host":"10.0.0.1","port":"6380","auth":"p","username":"app","connectionReadOnly":true,"sslOptions":{"ca":""}},
+		{"name":"sentinel","host":"10.0.0.2","port":26379,"sentinelOptions":{"masterName":"m"}}]`
+	insight := `[{"id":"1","name":"","host":"cache","port":6379,"password":"q","db":2,"tls":false,"connectionType":"STANDALONE"}]`
+	urls := "# servers\nredis://local:6379\n\nunix:///tmp/redis.sock\n"
+	tests := []struct {
+		name    string
+		b       string
+		conns   []redisapi.RedisConfig
+		skipped []string
+	}{
+		{"ardm", ardm, []redisapi.RedisConfig{{Name: "prod", Host: "10.0.0.1", Port: 6380, Auth: "p", Username: "app", ReadOnly: true, TLS: true}}, []string{"sentinel"}},
+		{"ardm base64", base64.StdEncoding.EncodeToString([]byte(ardm)), []redisapi.RedisConfig{{Name: "prod", Host: "10.0.0.1", Port: 6380, Auth: "p", Username: "app", ReadOnly: true, TLS: true}}, []string{"sentinel"}},
+		{"redisinsight", insight, []redisapi.RedisConfig{{Name: "cache:6379", Host: "cache", Port: 6379, Auth: "q", DB: 2}}, nil},
+		{"urls", urls, []redisapi.RedisConfig{{Name: "local:6379", Host: "local", Port: 6379}, {Name: "/tmp/redis.sock", Socket: "/tmp/redis.sock"}}, nil},
+	}
+	for _, tt := range tests {
+		conns, skipped, err := ParseConnections([]byte(tt.b))
+		if err != nil {
+			t.Errorf("%v: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(conns, tt.conns) || !reflect.DeepEqual(skipped, tt.skipped) {
+			t.Errorf("%v: %+v, skipped %v", tt.name, conns, skipped)
+		}
+	}
+	conns, _, err := ParseConnections([]byte(`[{"host":"h","password":"cmd:touch /tmp/x"}]` + "\n"))
+	if err != nil || len(conns) != 1 || conns[0].Auth != "cmd:touch /tmp/x" || conns[0].AuthRef != "" {
+		t.Errorf("imported password %+v, %v", conns, err)
+	}
+	conns, _, err = ParseConnections([]byte(`[{"host":"h","auth":"p","auth_ref":"cmd:id"}]`))
+	if err != nil || len(conns) != 1 || conns[0].AuthRef != "" {
+		t.Errorf("imported reference %+v, %v", conns, err)
+	}
+	if _, _, err := ParseConnections([]byte("ftp://h")); err == nil {
+		t.Error("no error for an unknown scheme")
+	}
+
+	c, err := NewConfig(filepath.Join(t.TempDir(), "redis-term.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conns, _, _ = ParseConnections([]byte("redis://127.0.0.1:6379\nredis://127.0.0.1:6379/1\nrediss://127.0.0.1:6379/1\n"))
+	if added := c.Import(conns); added != 2 {
+		t.Errorf("Import added %v", added)
+	}
+	if want := []string{"127.0.0.1:6379", "127.0.0.1:6379 2", "127.0.0.1:6379 3"}; !reflect.DeepEqual(c.GetDbNames(), want) {
+		t.Errorf("names %v, want %v", c.GetDbNames(), want)
+	}
+}
+
+func TestAppendHistory(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history", "a")
+	for i := 0; i < MaxHistory+10; i++ {
+		if err := AppendHistory(filename, "get "+strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lines, err := LoadHistory(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(b), "\n") != MaxHistory {
+		t.Errorf("history file of %v lines, want it trimmed to %v", strings.Count(string(b), "\n"), MaxHistory)
+	}
+	if len(lines) != MaxHistory || lines[0] != "get 10" || lines[len(lines)-1] != "get "+strconv.Itoa(MaxHistory+9) {
+		t.Errorf("history of %v lines from %q to %q, want the last %v", len(lines), lines[0], lines[len(lines)-1], MaxHistory)
+	}
+}
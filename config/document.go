@@ -0,0 +1,113 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/liwnn/redisterm/redisapi"
+)
+
+// Version is the version of the config document written by Save. Version
+// 0 is the bare array of connections of older releases.
+const Version = 1
+
+// Document is the config file: the connections and the preferences.
+type Document struct {
+	Version     int                    `json:"version"`
+	Preferences Preferences            `json:"preferences"`
+	Connections []redisapi.RedisConfig `json:"connections"`
+}
+
+// Preferences are the settings of all the connections.
+type Preferences struct {
+	// Theme overrides the colours of the UI by name, such as
+	// "control_bg": "navy".
+	Theme map[string]string `json:"theme,omitempty"`
+	// Separator splits the keys into namespaces, ":" when empty.
+	Separator string `json:"separator,omitempty"`
+	// PageSize is the number of keys asked per SCAN when loading the
+	// keys, 10000 when 0.
+	PageSize int `json:"page_size,omitempty"`
+	// Keybindings maps actions, such as "connections", to keys such as
+	// "Ctrl-P" or "F2".
+	Keybindings map[string]string `json:"keybindings,omitempty"`
+	// Formatters choose how string values are shown by key pattern, the
+	// first matching one applying.
+	Formatters []Formatter `json:"formatters,omitempty"`
+	// ClientName names the connections in CLIENT LIST, redis-term@host
+	// when empty.
+	ClientName string `json:"client_name,omitempty"`
+}
+
+// Formatter shows the string values of the keys matching the glob
+// Pattern in Format: json, hex or text.
+type Formatter struct {
+	Pattern string `json:"pattern"`
+	Format  string `json:"format"`
+}
+
+// decodeDocument decodes a config in JSON, the document or the array of
+// connections of version 0. migrated tells if it was version 0.
+func decodeDocument(b []byte) (doc Document, migrated bool, err error) {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '[' {
+		doc.Version = Version
+		err = json.Unmarshal(b, &doc.Connections)
+		return doc, true, err
+	}
+	err = json.Unmarshal(b, &doc)
+	return doc, false, err
+}
+
+// Validate returns the errors of the document, joined.
+func (d *Document) Validate() error {
+	var errs []error
+	if d.Version > Version {
+		errs = append(errs, fmt.Errorf("version %v is newer than %v, the version of this redis-term", d.Version, Version))
+	}
+	names := make(map[string]bool)
+	for i, c := range d.Connections {
+		if c.Name == "" {
+			errs = append(errs, fmt.Errorf("connections[%v]: no name", i))
+		} else if names[c.Name] {
+			errs = append(errs, fmt.Errorf("connections[%v]: name %q is used twice", i, c.Name))
+		}
+		names[c.Name] = true
+		if c.DB < 0 {
+			errs = append(errs, fmt.Errorf("connections[%v] %v: negative db %v", i, c.Name, c.DB))
+		}
+		if c.Socket != "" {
+			continue
+		}
+		if c.Host == "" {
+			errs = append(errs, fmt.Errorf("connections[%v] %v: no host", i, c.Name))
+		}
+		if c.Port <= 0 || c.Port > 65535 {
+			errs = append(errs, fmt.Errorf("connections[%v] %v: port %v out of range", i, c.Name, c.Port))
+		}
+	}
+	p := d.Preferences
+	if len(p.Separator) > 1 {
+		errs = append(errs, fmt.Errorf("preferences: separator %q is not a single character", p.Separator))
+	}
+	if strings.ContainsFunc(p.ClientName, func(r rune) bool { return r <= ' ' || r > '~' }) {
+		errs = append(errs, fmt.Errorf("preferences: client_name %q has spaces or special characters", p.ClientName))
+	}
+	if p.PageSize < 0 {
+		errs = append(errs, fmt.Errorf("preferences: negative page_size %v", p.PageSize))
+	}
+	for i, f := range p.Formatters {
+		if f.Pattern == "" {
+			errs = append(errs, fmt.Errorf("preferences: formatters[%v]: no pattern", i))
+		}
+		switch f.Format {
+		case "json", "hex", "text":
+		default:
+			errs = append(errs, fmt.Errorf("preferences: formatters[%v]: unknown format %q, not json, hex or text", i, f.Format))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// The config file is JSON, YAML or TOML by its extension. YAML and TOML are
+// converted to JSON and back, the json tags naming the fields in all of
+// them.
+const (
+	formatJSON = "json"
+	formatYAML = "yaml"
+	formatTOML = "toml"
+)
+
+// fileFormat returns the format of filename by its extension, JSON by
+// default.
+func fileFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return formatYAML
+	case ".toml":
+		return formatTOML
+	}
+	return formatJSON
+}
+
+// toJSON converts the config b in format to JSON.
+func toJSON(format string, b []byte) ([]byte, error) {
+	var v any
+	switch format {
+	case formatYAML:
+		if err := yaml.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+	case formatTOML:
+		if err := toml.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+	default:
+		return b, nil
+	}
+	return json.Marshal(v)
+}
+
+// fromJSON converts the JSON config b to format.
+func fromJSON(format string, b []byte) ([]byte, error) {
+	switch format {
+	case formatYAML:
+		// decoding JSON, a subset of YAML, into a node keeps the order of
+		// the fields.
+		var node yaml.Node
+		if err := yaml.Unmarshal(b, &node); err != nil {
+			return nil, err
+		}
+		blockStyle(&node)
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&node); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), enc.Close()
+	case formatTOML:
+		var v map[string]any
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+		return toml.Marshal(integers(v))
+	}
+	return b, nil
+}
+
+// blockStyle makes the flow style and quoted nodes of JSON be written as
+// blocks and plain scalars, quoted again only where needed.
+func blockStyle(n *yaml.Node) {
+	n.Style &^= yaml.FlowStyle | yaml.DoubleQuotedStyle
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+// integers replaces the whole numbers decoded from JSON as float64 by
+// int64, for TOML to write them as integers.
+func integers(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = integers(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = integers(e)
+		}
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+	}
+	return v
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaxHistory is the number of console lines kept per connection.
+const MaxHistory = 1000
+
+// LoadHistory reads the last MaxHistory lines of a history file, and
+// rewrites the file with them when it has more. A missing file is an
+// empty history.
+func LoadHistory(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) > MaxHistory {
+		lines = lines[len(lines)-MaxHistory:]
+		tmp := filename + ".tmp"
+		if err := os.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+			return lines, err
+		}
+		return lines, os.Rename(tmp, filename)
+	}
+	return lines, nil
+}
+
+// AppendHistory appends a line to a history file, trimmed by LoadHistory.
+func AppendHistory(filename string, line string) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(strings.ReplaceAll(line, "\n", " ") + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/liwnn/redisterm/redisapi"
+)
+
+// importedConnection is a connection exported by Another Redis Desktop
+// Manager or RedisInsight, their fields merged.
+type importedConnection struct {
+	Name     string  `json:"name"`
+	Host     string  `json:"host"`
+	Port     flexInt `json:"port"`
+	Username string  `json:"username"`
+	// Auth is the password of Another Redis Desktop Manager, Password the
+	// one of RedisInsight.
+	Auth     string  `json:"auth"`
+	Password string  `json:"password"`
+	DB       flexInt `json:"db"`
+	ReadOnly bool    `json:"connectionReadOnly"`
+	// TLS is set by RedisInsight, SSLOptions by Another Redis Desktop
+	// Manager.
+	TLS        bool            `json:"tls"`
+	SSLOptions json.RawMessage `json:"sslOptions"`
+	// the sentinels are not connected to.
+	ConnectionType  string          `json:"connectionType"`
+	SentinelOptions json.RawMessage `json:"sentinelOptions"`
+}
+
+// flexInt is a number in JSON or in a string.
+type flexInt int
+
+func (n *flexInt) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	i, err := strconv.Atoi(s)
+	*n = flexInt(i)
+	return err
+}
+
+func isSet(raw json.RawMessage) bool {
+	s := string(bytes.TrimSpace(raw))
+	return s != "" && s != "null" && s != "false" && s != "{}"
+}
+
+// ParseConnections parses the connections exported by Another Redis
+// Desktop Manager, in JSON or base64 encoded JSON, by RedisInsight, in
+// JSON, or a list of redis URLs, one per line. The sentinel connections
+// are skipped, returned by name.
+//
+// The passwords are kept literally in Auth, the files imported not being
+// trusted to run commands through references.
+func ParseConnections(b []byte) (conns []redisapi.RedisConfig, skipped []string, err error) {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] != '[' && b[0] != '{' {
+		if decoded, err := base64.StdEncoding.DecodeString(string(b)); err == nil {
+			if decoded = bytes.TrimSpace(decoded); len(decoded) > 0 && decoded[0] == '[' {
+				b = decoded
+			}
+		}
+	}
+	if len(b) == 0 || b[0] != '[' && b[0] != '{' {
+		conns, err = parseURLs(b)
+		return conns, nil, err
+	}
+
+	var imported []importedConnection
+	if err := json.Unmarshal(b, &imported); err != nil {
+		return nil, nil, fmt.Errorf("not a list of connections: %w", err)
+	}
+	for i, c := range imported {
+		if strings.EqualFold(c.ConnectionType, "SENTINEL") || isSet(c.SentinelOptions) {
+			skipped = append(skipped, c.Name)
+			continue
+		}
+		conf := redisapi.RedisConfig{
+			Name:     c.Name,
+			Host:     c.Host,
+			Port:     int(c.Port),
+			Username: c.Username,
+			Auth:     c.Auth,
+			DB:       int(c.DB),
+			ReadOnly: c.ReadOnly,
+			TLS:      c.TLS || isSet(c.SSLOptions),
+		}
+		if conf.Auth == "" {
+			conf.Auth = c.Password
+		}
+		if conf.Host == "" {
+			return nil, nil, fmt.Errorf("connection %v: no host", i)
+		}
+		if conf.Port == 0 {
+			conf.Port = redisapi.DefaultPort
+		}
+		if conf.Name == "" {
+			conf.Name = conf.Address()
+		}
+		conns = append(conns, conf)
+	}
+	return conns, skipped, nil
+}
+
+// parseURLs parses a URL per line, skipping the empty lines and the ones
+// starting with #.
+func parseURLs(b []byte) ([]redisapi.RedisConfig, error) {
+	var conns []redisapi.RedisConfig
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		conf, err := redisapi.ParseURL(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %w", n, err)
+		}
+		conns = append(conns, conf)
+	}
+	return conns, scanner.Err()
+}
+
+// Import adds the connections not configured yet, the same server, user,
+// database and TLS, renamed when their name is taken, their passwords kept
+// literally. It returns the number of connections added.
+func (c *Config) Import(conns []redisapi.RedisConfig) int {
+	known := make(map[string]bool, len(c.doc.Connections))
+	for _, conf := range c.doc.Connections {
+		known[conf.URL()] = true
+	}
+	added := 0
+	for _, conf := range conns {
+		if known[conf.URL()] {
+			continue
+		}
+		known[conf.URL()] = true
+		conf.AuthRef = ""
+		conf.Name = c.uniqueName(conf.Name)
+		c.Add(conf)
+		added++
+	}
+	return added
+}
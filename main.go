@@ -6,14 +6,40 @@ import (
 	"github.com/liwnn/redisterm/app"
 )
 
-var config string
+var (
+	config  string
+	rdbFile string
+
+	logFile  string
+	logLevel string
+
+	auditFile    string
+	auditMaxSize int64
+	auditBackups int
+)
 
 func init() {
 	flag.StringVar(&config, "config", "~/.redis-term.json", "config")
+	flag.StringVar(&rdbFile, "rdb", "", "browse an RDB file read-only instead of a server")
+	flag.StringVar(&logFile, "log", "", "file the log is written to")
+	flag.StringVar(&logLevel, "log-level", "info", "lowest level written to the log file: debug, info, warn or error")
+	flag.StringVar(&auditFile, "audit", "", `audit log of the write commands, next to the config by default, "off" to disable`)
+	flag.Int64Var(&auditMaxSize, "audit-max-size", 10, "size in MB from which the audit log is rotated")
+	flag.IntVar(&auditBackups, "audit-backups", 5, "number of rotated audit logs kept")
 }
 
 func main() {
 	flag.Parse()
 
-	app.NewApp(config).Run()
+	a := app.NewApp(config)
+	if logFile != "" {
+		a.OpenLog(logFile, logLevel)
+	}
+	if auditFile != "off" {
+		a.OpenAudit(auditFile, auditMaxSize<<20, auditBackups)
+	}
+	if rdbFile != "" {
+		a.OpenRDB(rdbFile)
+	}
+	a.Run()
 }
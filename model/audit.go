@@ -0,0 +1,104 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/liwnn/redisterm/audit"
+	"github.com/liwnn/redisterm/redis"
+	"github.com/liwnn/redisterm/redisapi"
+	"github.com/liwnn/redisterm/tlog"
+)
+
+// SetAudit makes the write commands sent to the connection, named conn in
+// the log, be recorded to l.
+func (d *Data) SetAudit(l *audit.Logger, conn string) {
+	d.auditLog = l
+	d.conn = conn
+}
+
+// isAudited tells if the command is recorded: the write commands of
+// IsWriteCommand, admin commands included, never AUTH and HELLO.
+func (d *Data) isAudited(cmd string, params ...string) bool {
+	return !isAuthCommand(strings.ToUpper(cmd)) && d.IsWriteCommand(cmd, params...)
+}
+
+// audit records cmds sent to database index with their replies, or with
+// err when they were not answered.
+func (d *Data) audit(index int, cmds [][]string, replies []*redis.Reply, err error) {
+	if d.auditLog == nil || len(cmds) == 0 {
+		return
+	}
+	now := time.Now()
+	entries := make([]audit.Entry, 0, len(cmds))
+	for i, cmd := range cmds {
+		outcome := audit.Outcome(err)
+		if err == nil && i < len(replies) {
+			outcome = audit.Outcome(replies[i].Err())
+		}
+		entries = append(entries, d.auditEntries(now, index, cmd, outcome)...)
+	}
+	if err := d.auditLog.Log(entries...); err != nil {
+		tlog.Error("[Audit] write", "err", err)
+	}
+}
+
+// auditCmd records a command sent to database index.
+func (d *Data) auditCmd(index int, err error, cmd ...string) {
+	d.audit(index, [][]string{cmd}, nil, err)
+}
+
+// auditEntries returns the entries of a command: one per key for DEL and
+// UNLINK, with the key as told by COMMAND, or the first argument when the
+// command is unknown. Only the arguments of admin commands are kept. The
+// passwords are redacted before any argument is kept, AUTH and HELLO
+// have no entry.
+func (d *Data) auditEntries(now time.Time, index int, cmd []string, outcome string) []audit.Entry {
+	if isAuthCommand(strings.ToUpper(cmd[0])) {
+		return nil
+	}
+	cmd = append([]string{cmd[0]}, audit.RedactCommand(cmd[0], cmd[1:])...)
+	e := audit.Entry{Time: now, Conn: d.conn, DB: index, Command: strings.ToUpper(cmd[0]), Outcome: outcome}
+	args := cmd[1:]
+	commands := d.Commands()
+	info := commands[e.Command]
+	if len(args) > 0 {
+		if sub, ok := commands[e.Command+" "+strings.ToUpper(args[0])]; ok || audit.IsAdminCommand(e.Command+" "+strings.ToUpper(args[0])) {
+			e.Command += " " + strings.ToUpper(args[0])
+			args = args[1:]
+			info = sub
+		}
+	}
+	switch {
+	case e.Command == "DEL" || e.Command == "UNLINK":
+		entries := make([]audit.Entry, 0, len(args))
+		for _, key := range args {
+			e.Key = key
+			entries = append(entries, e)
+		}
+		return entries
+	case info != nil && info.FirstKey > 0:
+		// FirstKey counts from the command name, subcommand included.
+		if info.FirstKey < len(cmd) {
+			e.Key = cmd[info.FirstKey]
+		}
+	case audit.IsAdminCommand(e.Command):
+		e.Args = audit.Redact(e.Command, args)
+	case info == nil && len(args) > 0:
+		e.Key = args[0]
+	}
+	return []audit.Entry{e}
+}
+
+// isAuthCommand tells if the command, upper case, authenticates.
+func isAuthCommand(name string) bool {
+	return name == "AUTH" || name == "HELLO"
+}
+
+// writePipeline sends the write commands cmds to database index on
+// client, a dedicated connection, and records them.
+func (d *Data) writePipeline(client *redisapi.Redis, index int, cmds [][]string) ([]*redis.Reply, error) {
+	replies, err := client.Pipeline(cmds)
+	d.audit(index, cmds, replies, err)
+	return replies, err
+}
@@ -0,0 +1,289 @@
+package model
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/liwnn/redisterm/redisapi"
+)
+
+// Selection is a set of keys and namespaces of database Index: the keys
+// in Keys and the keys starting with one of Prefixes.
+type Selection struct {
+	Index    int
+	Keys     []string
+	Prefixes []string
+}
+
+// NewSelection returns the selection of keys and prefixes, without the
+// ones covered by another prefix.
+func NewSelection(index int, keys, prefixes []string) *Selection {
+	s := &Selection{Index: index}
+	prefixes = append([]string(nil), prefixes...)
+	sort.Strings(prefixes)
+	for _, p := range prefixes {
+		// Sorted, a prefix follows the prefixes covering it.
+		if len(s.Prefixes) > 0 && strings.HasPrefix(p, s.Prefixes[len(s.Prefixes)-1]) {
+			continue
+		}
+		s.Prefixes = append(s.Prefixes, p)
+	}
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if !seen[key] && !s.coveredByPrefix(key) {
+			seen[key] = true
+			s.Keys = append(s.Keys, key)
+		}
+	}
+	sort.Strings(s.Keys)
+	return s
+}
+
+func (s *Selection) coveredByPrefix(key string) bool {
+	for _, p := range s.Prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains tells if key is selected.
+func (s *Selection) Contains(key string) bool {
+	if s.coveredByPrefix(key) {
+		return true
+	}
+	i := sort.SearchStrings(s.Keys, key)
+	return i < len(s.Keys) && s.Keys[i] == key
+}
+
+// String describes the selection, such as "3 keys and 2 namespaces".
+func (s *Selection) String() string {
+	var parts []string
+	if n := len(s.Keys); n == 1 {
+		parts = append(parts, FormatKey(s.Keys[0]))
+	} else if n > 1 {
+		parts = append(parts, strconv.Itoa(n)+" keys")
+	}
+	if n := len(s.Prefixes); n == 1 && s.Prefixes[0] == "" {
+		parts = append(parts, "all keys")
+	} else if n == 1 {
+		parts = append(parts, FormatKey(s.Prefixes[0])+"*")
+	} else if n > 1 {
+		parts = append(parts, strconv.Itoa(n)+" namespaces")
+	}
+	return strings.Join(parts, " and ")
+}
+
+// forEach calls fn with batches of the selected keys: the keys, which may
+// not exist, then the keys found by SCAN for each prefix.
+func (s *Selection) forEach(ctx context.Context, client *redisapi.Redis, fn func(keys []string) error) error {
+	for i := 0; i < len(s.Keys); i += scanBatch {
+		if err := fn(s.Keys[i:min(i+scanBatch, len(s.Keys))]); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	for _, prefix := range s.Prefixes {
+		cursor := "0"
+		for {
+			var keys []string
+			var err error
+			cursor, keys, err = client.Scan(cursor, escapePattern(prefix)+"*", scanBatch)
+			if err != nil {
+				return err
+			}
+			if len(keys) > 0 {
+				if err := fn(keys); err != nil {
+					return err
+				}
+			}
+			if cursor == "0" {
+				break
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// selectionOf returns the selection of a single key when exact is set,
+// otherwise of the keys starting with prefix.
+func selectionOf(index int, prefix string, exact bool) *Selection {
+	if exact {
+		return &Selection{Index: index, Keys: []string{prefix}}
+	}
+	return &Selection{Index: index, Prefixes: []string{prefix}}
+}
+
+// BulkOp is an operation of Bulk.
+type BulkOp int
+
+const (
+	BulkDelete BulkOp = iota
+	BulkExpire
+	BulkPersist
+)
+
+func (op BulkOp) String() string {
+	switch op {
+	case BulkExpire:
+		return "expire"
+	case BulkPersist:
+		return "persist"
+	}
+	return "delete"
+}
+
+// BulkResult counts the keys changed by Bulk.
+type BulkResult struct {
+	Keys        int
+	Errors      int
+	FirstErrors []string
+}
+
+func (r *BulkResult) addError(key string, err error) {
+	r.Errors++
+	if len(r.FirstErrors) < maxImportErrors {
+		r.FirstErrors = append(r.FirstErrors, FormatKey(key)+": "+err.Error())
+	}
+}
+
+// Bulk applies op to the keys of sel on a dedicated connection: UNLINK in
+// batches for BulkDelete, PEXPIRE with ttl milliseconds for BulkExpire and
+// PERSIST for BulkPersist. progress is called with the keys handled after
+// each batch, on the calling goroutine. The result is returned with the
+// error too, counting the keys changed before it.
+func (d *Data) Bulk(ctx context.Context, sel *Selection, op BulkOp, ttl int64, progress func(done int)) (*BulkResult, error) {
+	result := &BulkResult{}
+	if err := d.CheckWrite(); err != nil {
+		return result, err
+	}
+	client, err := d.dialDB(sel.Index)
+	if err != nil {
+		return result, err
+	}
+	defer client.Close()
+	defer d.recordChange(sel.Index, op.String()+" "+sel.String(), "bulk changes are not captured")
+
+	done := 0
+	err = sel.forEach(ctx, client, func(keys []string) error {
+		var cmds [][]string
+		switch op {
+		case BulkDelete:
+			cmds = [][]string{append([]string{"UNLINK"}, keys...)}
+		case BulkExpire:
+			for _, key := range keys {
+				cmds = append(cmds, []string{"PEXPIRE", key, strconv.FormatInt(ttl, 10)})
+			}
+		case BulkPersist:
+			for _, key := range keys {
+				cmds = append(cmds, []string{"PERSIST", key})
+			}
+		}
+		replies, err := d.writePipeline(client, sel.Index, cmds)
+		if err != nil {
+			return err
+		}
+		for i, r := range replies {
+			if err := r.Err(); err != nil {
+				result.addError(cmds[i][1], err)
+				continue
+			}
+			n, _ := r.Int()
+			result.Keys += n
+		}
+		done += len(keys)
+		if progress != nil {
+			progress(done)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// MatchPattern tells if key matches the glob-style pattern of KEYS and
+// SCAN MATCH: * and ? wildcards, [abc], [^abc] and [a-z] classes, and \
+// to escape.
+func MatchPattern(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if MatchPattern(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+			rest, ok := matchClass(pattern[1:], key[0])
+			if !ok {
+				return false
+			}
+			key = key[1:]
+			pattern = rest
+		default:
+			if pattern[0] == '\\' && len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			if len(key) == 0 || key[0] != pattern[0] {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		}
+	}
+	return len(key) == 0
+}
+
+// matchClass matches c against the class at the start of pattern, after
+// the '['. It returns the pattern after the closing ']'.
+func matchClass(pattern string, c byte) (string, bool) {
+	not := len(pattern) > 0 && pattern[0] == '^'
+	if not {
+		pattern = pattern[1:]
+	}
+	match := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) > 1:
+			match = match || pattern[1] == c
+			pattern = pattern[2:]
+		case len(pattern) > 2 && pattern[1] == '-' && pattern[2] != ']':
+			lo, hi := pattern[0], pattern[2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			match = match || c >= lo && c <= hi
+			pattern = pattern[3:]
+		default:
+			match = match || pattern[0] == c
+			pattern = pattern[1:]
+		}
+	}
+	if len(pattern) > 0 {
+		pattern = pattern[1:]
+	}
+	return pattern, match != not
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern, key string
+		match        bool
+	}{
+		{"*", "", true},
+		{"user:*", "user:1", true},
+		{"user:*", "order:1", false},
+		{"user:?", "user:1", true},
+		{"user:?", "user:10", false},
+		{"*:name", "user:1:name", true},
+		{"user:[0-9]", "user:5", true},
+		{"user:[^0-9]", "user:5", false},
+		{"user:[abc]", "user:b", true},
+		{`a\*b`, "a*b", true},
+		{`a\*b`, "axb", false},
+		{"a*b*c", "aXbYc", true},
+		{"a*b*c", "aXbY", false},
+	}
+	for _, tt := range tests {
+		if got := MatchPattern(tt.pattern, tt.key); got != tt.match {
+			t.Errorf("MatchPattern(%q, %q) = %v", tt.pattern, tt.key, got)
+		}
+	}
+}
+
+func TestNewSelection(t *testing.T) {
+	s := NewSelection(0, []string{"b", "user:1", "a", "b"}, []string{"user:1:", "user:", "order:"})
+	if !reflect.DeepEqual(s.Keys, []string{"a", "b"}) || !reflect.DeepEqual(s.Prefixes, []string{"order:", "user:"}) {
+		t.Errorf("NewSelection = %q %q", s.Keys, s.Prefixes)
+	}
+	for key, want := range map[string]bool{"a": true, "user:1:x": true, "c": false, "order": false} {
+		if s.Contains(key) != want {
+			t.Errorf("Contains(%q) = %v", key, !want)
+		}
+	}
+	if s.String() != "2 keys and 2 namespaces" {
+		t.Errorf("String() = %q", s.String())
+	}
+}
+
+func TestBulkCancelled(t *testing.T) {
+	_, addr := newFakeServer(t, func(args []string) string {
+		switch strings.ToUpper(args[0]) {
+		case "SCAN":
+			// an endless namespace of 2 keys per batch.
+			return "*2\r\n$1\r\n1\r\n*2\r\n$3\r\na:1\r\n$3\r\na:2\r\n"
+		case "UNLINK":
+			return ":2\r\n"
+		}
+		return "-ERR unknown\r\n"
+	})
+	d := NewData(addr, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	batches := 0
+	deleted, err := d.DeleteNamespace(ctx, 0, &DataNode{key: "a:"}, func(int) {
+		if batches++; batches == 3 {
+			cancel()
+		}
+	})
+	if err != context.Canceled || deleted != 6 {
+		t.Errorf("DeleteNamespace = %v, %v, want 6 keys deleted before the cancel", deleted, err)
+	}
+}
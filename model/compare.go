@@ -0,0 +1,515 @@
+package model
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/liwnn/redisterm/redisapi"
+)
+
+// DiffKind tells how a key differs between two databases.
+type DiffKind int
+
+const (
+	DiffOnlyA DiffKind = iota
+	DiffOnlyB
+	DiffType
+	DiffValue
+	DiffTTL
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case DiffOnlyA:
+		return "only in A"
+	case DiffOnlyB:
+		return "only in B"
+	case DiffType:
+		return "type"
+	case DiffValue:
+		return "value"
+	}
+	return "TTL"
+}
+
+// ttlTolerance is the TTL difference still considered equal, as TTLs
+// count down between the reads of both sides.
+const ttlTolerance = 1000
+
+// CompareOptions selects the keys of Compare: the keys starting with
+// Prefix in database Index of A and TargetIndex of B.
+type CompareOptions struct {
+	Index       int
+	TargetIndex int
+	Prefix      string
+	// Limit stops the scan of each side after Limit keys, 0 for none.
+	Limit int
+}
+
+// KeyDiff is a key that differs, with the type or TTL of both sides.
+type KeyDiff struct {
+	Key  string
+	Kind DiffKind
+	A, B string
+}
+
+// CompareResult lists the keys that differ, sorted.
+type CompareResult struct {
+	Diffs []KeyDiff
+	Same  int
+	// Digest tells values were compared with DEBUG DIGEST-VALUE, rather
+	// than hashed after reading them.
+	Digest    bool
+	Truncated bool
+}
+
+// Count returns the number of differences of kind.
+func (r *CompareResult) Count(kind DiffKind) int {
+	n := 0
+	for _, d := range r.Diffs {
+		if d.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// keyState is what is compared of a key. Type is "none" when the key
+// doesn't exist.
+type keyState struct {
+	Type   string
+	TTL    int64
+	Digest string
+}
+
+func ttlText(ttl int64) string {
+	if ttl < 0 {
+		return "no TTL"
+	}
+	return strconv.FormatInt(ttl, 10) + "ms"
+}
+
+// compareKey returns how the key differs between a and b, false when equal.
+func compareKey(key string, a, b keyState) (KeyDiff, bool) {
+	d := KeyDiff{Key: key, A: a.Type, B: b.Type}
+	switch {
+	case a.Type == "none" && b.Type == "none":
+		return d, false
+	case b.Type == "none":
+		d.Kind = DiffOnlyA
+	case a.Type == "none":
+		d.Kind = DiffOnlyB
+	case a.Type != b.Type:
+		d.Kind = DiffType
+	case a.Digest != b.Digest:
+		d.Kind = DiffValue
+	case (a.TTL < 0) != (b.TTL < 0) || a.TTL-b.TTL > ttlTolerance || b.TTL-a.TTL > ttlTolerance:
+		d.Kind = DiffTTL
+		d.A, d.B = ttlText(a.TTL), ttlText(b.TTL)
+	default:
+		return d, false
+	}
+	return d, true
+}
+
+// compareSide reads the keys of one database, from a server or an RDB
+// file.
+type compareSide struct {
+	data   *Data
+	client *redisapi.Redis
+	index  int
+	digest bool
+}
+
+func (d *Data) compareSide(index int) (*compareSide, error) {
+	s := &compareSide{data: d, index: index}
+	if d.snapshot != nil {
+		return s, nil
+	}
+	client, err := d.dialDB(index)
+	if err != nil {
+		return nil, err
+	}
+	s.client = client
+	// DEBUG is disabled by default since Redis 7.
+	_, err = client.Do("DEBUG", "DIGEST-VALUE", "")
+	s.digest = err == nil
+	return s, nil
+}
+
+func (s *compareSide) close() {
+	if s.client != nil {
+		s.client.Close()
+	}
+}
+
+// keys returns the keys starting with prefix, at most limit of them.
+func (s *compareSide) keys(ctx context.Context, prefix string, limit int) ([]string, bool, error) {
+	if s.client == nil {
+		var keys []string
+		for _, key := range s.data.snapshot.keys(s.index) {
+			if strings.HasPrefix(key, prefix) {
+				if limit > 0 && len(keys) == limit {
+					return keys, true, nil
+				}
+				keys = append(keys, key)
+			}
+		}
+		return keys, false, nil
+	}
+	var keys []string
+	cursor := "0"
+	for {
+		var batch []string
+		var err error
+		cursor, batch, err = s.client.Scan(cursor, escapePattern(prefix)+"*", scanBatch)
+		if err != nil {
+			return nil, false, err
+		}
+		keys = append(keys, batch...)
+		if limit > 0 && len(keys) >= limit {
+			return keys[:limit], true, nil
+		}
+		if cursor == "0" {
+			return keys, false, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+// states reads the type, TTL and value digest of keys, with DEBUG
+// DIGEST-VALUE when digest is set, otherwise by hashing the values.
+func (s *compareSide) states(keys []string, digest bool) ([]keyState, error) {
+	states := make([]keyState, len(keys))
+	if s.client == nil {
+		now := s.data.snapshot.now()
+		for i, key := range keys {
+			states[i].Type = "none"
+			if e := s.data.snapshot.entry(s.index, key); e != nil {
+				kv, _ := entryKeyValue(e, now)
+				states[i] = keyState{Type: e.Type, TTL: kv.TTL, Digest: kv.digest()}
+			}
+		}
+		return states, nil
+	}
+
+	var cmds [][]string
+	for _, key := range keys {
+		cmds = append(cmds, []string{"TYPE", key}, []string{"PTTL", key})
+		if digest {
+			cmds = append(cmds, []string{"DEBUG", "DIGEST-VALUE", key})
+		}
+	}
+	replies, err := s.client.Pipeline(cmds)
+	if err != nil {
+		return nil, err
+	}
+	step := 2
+	if digest {
+		step = 3
+	}
+	for i := range keys {
+		st := &states[i]
+		st.Type = replies[i*step].String()
+		st.TTL = -1
+		if ttl, err := replies[i*step+1].Int(); err == nil && ttl >= 0 {
+			st.TTL = int64(ttl)
+		}
+		if digest {
+			if d := replies[i*step+2].ToArray(); len(d) == 1 {
+				st.Digest = d[0].String()
+			}
+		}
+	}
+	if digest {
+		return states, nil
+	}
+	values, err := ReadKeys(s.client, keys)
+	if err != nil {
+		return nil, err
+	}
+	digests := make(map[string]string, len(values))
+	for i := range values {
+		digests[values[i].Key] = values[i].digest()
+	}
+	for i, key := range keys {
+		states[i].Digest = digests[key]
+	}
+	return states, nil
+}
+
+// read reads the value of key, nil if it doesn't exist.
+func (s *compareSide) read(key string) (*KeyValue, error) {
+	if s.client == nil {
+		e := s.data.snapshot.entry(s.index, key)
+		if e == nil {
+			return nil, nil
+		}
+		kv, ok := entryKeyValue(e, s.data.snapshot.now())
+		if !ok {
+			kv.Value = "(" + e.Type + " value)"
+		}
+		return &kv, nil
+	}
+	values, err := ReadKeys(s.client, []string{key})
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+	return &values[0], nil
+}
+
+// Compare compares the keys starting with opts.Prefix in database Index
+// of d and TargetIndex of target, by type, TTL and value. Values are
+// compared by DEBUG DIGEST-VALUE when both servers allow it, otherwise by
+// hashing them after reading them; RDB files are always hashed. progress
+// is called after each batch, on the calling goroutine.
+func (d *Data) Compare(ctx context.Context, target *Data, opts CompareOptions, progress func(compared int)) (*CompareResult, error) {
+	a, err := d.compareSide(opts.Index)
+	if err != nil {
+		return nil, err
+	}
+	defer a.close()
+	b, err := target.compareSide(opts.TargetIndex)
+	if err != nil {
+		return nil, err
+	}
+	defer b.close()
+
+	keysA, truncA, err := a.keys(ctx, opts.Prefix, opts.Limit)
+	if err != nil {
+		return nil, err
+	}
+	keysB, truncB, err := b.keys(ctx, opts.Prefix, opts.Limit)
+	if err != nil {
+		return nil, err
+	}
+	result := &CompareResult{
+		Digest:    a.digest && b.digest,
+		Truncated: truncA || truncB,
+	}
+	// Each key is read on both sides, as it may have been created on the
+	// other side since its scan.
+	seen := make(map[string]bool, len(keysA)+len(keysB))
+	keys := make([]string, 0, len(keysA)+len(keysB))
+	for _, key := range append(keysA, keysB...) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for i := 0; i < len(keys); i += scanBatch {
+		batch := keys[i:min(i+scanBatch, len(keys))]
+		statesA, err := a.states(batch, result.Digest)
+		if err != nil {
+			return nil, err
+		}
+		statesB, err := b.states(batch, result.Digest)
+		if err != nil {
+			return nil, err
+		}
+		for j, key := range batch {
+			if diff, ok := compareKey(key, statesA[j], statesB[j]); ok {
+				result.Diffs = append(result.Diffs, diff)
+			} else if statesA[j].Type != "none" {
+				result.Same++
+			}
+		}
+		if progress != nil {
+			progress(i + len(batch))
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// ValueDiff is the line diff of the values of a key on two databases.
+// Changed marks the lines missing from the other side.
+type ValueDiff struct {
+	A, B               *KeyValue
+	LinesA, LinesB     []string
+	ChangedA, ChangedB []bool
+}
+
+// CompareKey reads key from database Index of d and TargetIndex of
+// target, and diffs the lines of both values.
+func (d *Data) CompareKey(target *Data, opts CompareOptions, key string) (*ValueDiff, error) {
+	a, err := d.compareSide(opts.Index)
+	if err != nil {
+		return nil, err
+	}
+	defer a.close()
+	b, err := target.compareSide(opts.TargetIndex)
+	if err != nil {
+		return nil, err
+	}
+	defer b.close()
+
+	diff := &ValueDiff{}
+	if diff.A, err = a.read(key); err != nil {
+		return nil, err
+	}
+	if diff.B, err = b.read(key); err != nil {
+		return nil, err
+	}
+	diff.LinesA = diff.A.Lines()
+	diff.LinesB = diff.B.Lines()
+	diff.ChangedA, diff.ChangedB = DiffLines(diff.LinesA, diff.LinesB)
+	return diff, nil
+}
+
+// Lines returns the value of kv as text lines in a canonical order: set
+// members and hash fields are sorted, so equal values have equal lines.
+func (kv *KeyValue) Lines() []string {
+	if kv == nil {
+		return nil
+	}
+	var lines []string
+	switch kv.Type {
+	case "string":
+		lines = strings.Split(kv.Value, "\n")
+	case "set":
+		lines = append(lines, kv.Elems...)
+		sort.Strings(lines)
+	case "hash":
+		for i := 0; i+1 < len(kv.Elems); i += 2 {
+			lines = append(lines, kv.Elems[i]+": "+kv.Elems[i+1])
+		}
+		sort.Strings(lines)
+	case "zset":
+		for i := 0; i+1 < len(kv.Elems); i += 2 {
+			lines = append(lines, kv.Elems[i+1]+" "+kv.Elems[i])
+		}
+	case "stream":
+		for _, e := range kv.Entries {
+			lines = append(lines, e.ID+" "+strings.Join(e.Fields, " "))
+		}
+	default:
+		if kv.Value != "" {
+			lines = append(lines, kv.Value)
+		}
+		lines = append(lines, kv.Elems...)
+	}
+	return lines
+}
+
+// digest hashes the value in the canonical order of Lines, each element
+// with its length so that different values have different digests.
+func (kv *KeyValue) digest() string {
+	h := sha1.New()
+	write := func(elems ...string) {
+		for _, e := range elems {
+			h.Write([]byte(strconv.Itoa(len(e))))
+			h.Write([]byte{':'})
+			h.Write([]byte(e))
+		}
+	}
+	switch kv.Type {
+	case "set":
+		members := slices.Clone(kv.Elems)
+		sort.Strings(members)
+		write(members...)
+	case "hash":
+		pairs := make([][2]string, 0, len(kv.Elems)/2)
+		for i := 0; i+1 < len(kv.Elems); i += 2 {
+			pairs = append(pairs, [2]string{kv.Elems[i], kv.Elems[i+1]})
+		}
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+		for _, p := range pairs {
+			write(p[0], p[1])
+		}
+	case "stream":
+		for _, e := range kv.Entries {
+			write(e.ID, strconv.Itoa(len(e.Fields)))
+			write(e.Fields...)
+		}
+	default:
+		write(kv.Value)
+		write(kv.Elems...)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// maxDiffCells bounds the work of DiffLines, larger inputs compare lines
+// as sets.
+const maxDiffCells = 4 << 20
+
+// DiffLines marks the lines of a and b that are not in their longest
+// common subsequence.
+func DiffLines(a, b []string) (changedA, changedB []bool) {
+	changedA = make([]bool, len(a))
+	changedB = make([]bool, len(b))
+	if len(a)*len(b) > maxDiffCells {
+		inA := make(map[string]bool, len(a))
+		inB := make(map[string]bool, len(b))
+		for _, line := range a {
+			inA[line] = true
+		}
+		for _, line := range b {
+			inB[line] = true
+		}
+		for i, line := range a {
+			changedA[i] = !inB[line]
+		}
+		for i, line := range b {
+			changedB[i] = !inA[line]
+		}
+		return changedA, changedB
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			changedA[i] = true
+			i++
+		default:
+			changedB[j] = true
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		changedA[i] = true
+	}
+	for ; j < len(b); j++ {
+		changedB[j] = true
+	}
+	return changedA, changedB
+}
+
+// now returns the time TTLs of the snapshot are relative to: the time the
+// file was saved, or the current time.
+func (s *snapshot) now() int64 {
+	if ctime, err := strconv.ParseInt(s.file.Aux["ctime"], 10, 64); err == nil {
+		return ctime * 1000
+	}
+	return time.Now().UnixMilli()
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareKey(t *testing.T) {
+	str := keyState{Type: "string", TTL: -1, Digest: "1"}
+	tests := []struct {
+		a, b keyState
+		kind DiffKind
+		diff bool
+	}{
+		{str, str, 0, false},
+		{str, keyState{Type: "none"}, DiffOnlyA, true},
+		{keyState{Type: "none"}, str, DiffOnlyB, true},
+		{str, keyState{Type: "hash", TTL: -1, Digest: "1"}, DiffType, true},
+		{str, keyState{Type: "string", TTL: -1, Digest: "2"}, DiffValue, true},
+		{str, keyState{Type: "string", TTL: 5000, Digest: "1"}, DiffTTL, true},
+		{keyState{Type: "string", TTL: 5000}, keyState{Type: "string", TTL: 4500}, 0, false},
+		{keyState{Type: "string", TTL: 5000}, keyState{Type: "string", TTL: 3000}, DiffTTL, true},
+	}
+	for i, tt := range tests {
+		d, ok := compareKey("k", tt.a, tt.b)
+		if ok != tt.diff || ok && d.Kind != tt.kind {
+			t.Errorf("%v: compareKey(%+v, %+v) = %v %v", i, tt.a, tt.b, d.Kind, ok)
+		}
+	}
+}
+
+func TestKeyValueDigest(t *testing.T) {
+	a := KeyValue{Key: "h", Type: "hash", Elems: []string{"f", "1", "g", "2"}}
+	b := KeyValue{Key: "h", Type: "hash", Elems: []string{"g", "2", "f", "1"}}
+	if a.digest() != b.digest() {
+		t.Error("hash digest depends on field order")
+	}
+	c := KeyValue{Key: "h", Type: "hash", Elems: []string{"f", "1", "g", "3"}}
+	if a.digest() == c.digest() {
+		t.Error("different hashes have the same digest")
+	}
+	// values with the same lines.
+	for _, pair := range [][2]KeyValue{
+		{{Type: "hash", Elems: []string{"a: b", "c"}}, {Type: "hash", Elems: []string{"a", "b: c"}}},
+		{{Type: "stream", Entries: []StreamEntry{{ID: "1-0", Fields: []string{"a b", "c"}}}}, {Type: "stream", Entries: []StreamEntry{{ID: "1-0", Fields: []string{"a", "b c"}}}}},
+	} {
+		if pair[0].digest() == pair[1].digest() {
+			t.Errorf("%+v and %+v have the same digest", pair[0], pair[1])
+		}
+	}
+	if !reflect.DeepEqual(a.Lines(), []string{"f: 1", "g: 2"}) {
+		t.Errorf("Lines() = %q", a.Lines())
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"a", "b", "c", "d"}
+	b := []string{"a", "c", "d", "e"}
+	changedA, changedB := DiffLines(a, b)
+	if !reflect.DeepEqual(changedA, []bool{false, true, false, false}) ||
+		!reflect.DeepEqual(changedB, []bool{false, false, false, true}) {
+		t.Errorf("DiffLines = %v %v", changedA, changedB)
+	}
+}
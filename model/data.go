@@ -1,13 +1,14 @@
 package model
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"strconv"
 	"strings"
 
+	"github.com/liwnn/redisterm/audit"
 	"github.com/liwnn/redisterm/redis"
 	"github.com/liwnn/redisterm/redisapi"
 	"github.com/liwnn/redisterm/tlog"
@@ -15,6 +16,8 @@ import (
 
 var (
 	ErrDBNotConnect = errors.New("Db not connect")
+	// ErrConnReadOnly is returned by changes on a read-only connection.
+	ErrConnReadOnly = errors.New("connection is read-only")
 )
 
 // Data data
@@ -22,23 +25,68 @@ type Data struct {
 	redis   *redisapi.Redis
 	address string
 	auth    string
+	dial    redisapi.DialOptions
 
 	db    []*DataTree
 	index int
+
+	commands map[string]*redisapi.CommandInfo
+
+	file     string
+	snapshot *snapshot
+	readOnly bool
+	guard    *Guard
+	undo     undoStack
+	auditLog *audit.Logger
+	// conn names the connection in the audit log.
+	conn string
+	// sep splits the keys into namespaces, pageSize is the COUNT of the
+	// SCAN loading them.
+	sep      byte
+	pageSize int
 }
 
+// defaultPageSize is the COUNT of the SCAN loading the keys by default.
+const defaultPageSize = 10000
+
 // NewData new
 func NewData(addr string, auth string) *Data {
 	r := &Data{
-		address: addr,
-		auth:    auth,
+		address:  addr,
+		auth:     auth,
+		sep:      DefaultSeparator,
+		pageSize: defaultPageSize,
 	}
 	return r
 }
 
+// SetSeparator splits the keys into namespaces on sep, before the keys
+// are loaded.
+func (d *Data) SetSeparator(sep byte) {
+	d.sep = sep
+}
+
+// SetDialOptions sets how to connect to the server, besides its address
+// and password.
+func (d *Data) SetDialOptions(o redisapi.DialOptions) {
+	d.dial = o
+}
+
+// SetPageSize sets the number of keys asked per SCAN when loading the
+// keys, the default one when n is 0.
+func (d *Data) SetPageSize(n int) {
+	if n <= 0 {
+		n = defaultPageSize
+	}
+	d.pageSize = n
+}
+
 // Connect db
 func (d *Data) Connect() error {
-	client, err := redisapi.NewRedis(d.address, d.auth)
+	if d.IsRDB() {
+		return d.loadRDB()
+	}
+	client, err := redisapi.NewRedis(d.address, d.auth, d.dial)
 	if err != nil {
 		return err
 	}
@@ -46,18 +94,64 @@ func (d *Data) Connect() error {
 	return nil
 }
 
+// Dial opens a dedicated connection to the server of d, for work that
+// runs outside the UI goroutine.
+func (d *Data) Dial() (*redisapi.Redis, error) {
+	if d.IsRDB() {
+		return nil, ErrOffline
+	}
+	return redisapi.NewRedis(d.address, d.auth, d.dial)
+}
+
+// SetReadOnly makes the connection refuse changes: the methods changing
+// keys return ErrConnReadOnly and Cmd refuses write commands.
+func (d *Data) SetReadOnly(readOnly bool) {
+	d.readOnly = readOnly
+}
+
+// SetGuard sets the guard of the connection.
+func (d *Data) SetGuard(g *Guard) {
+	d.guard = g
+}
+
+// Guard returns the guard of the connection, the default one when unset.
+func (d *Data) Guard() *Guard {
+	if d.guard == nil {
+		d.guard = NewGuard(nil)
+	}
+	return d.guard
+}
+
+// CheckWrite returns why keys can't be changed: ErrReadOnly for RDB files,
+// ErrConnReadOnly for read-only connections, nil otherwise.
+func (d *Data) CheckWrite() error {
+	if d.IsRDB() {
+		return ErrReadOnly
+	}
+	if d.readOnly {
+		return ErrConnReadOnly
+	}
+	return nil
+}
+
 // GetDatabases database name
 func (d *Data) GetDatabases() ([]*DataNode, error) {
-	if d.redis == nil {
+	if d.redis == nil && d.snapshot == nil {
 		return nil, ErrDBNotConnect
 	}
 	if len(d.db) == 0 {
-		dbNum, err := d.redis.GetDatabases()
-		if err != nil {
-			return nil, err
+		var dbNum int
+		if d.snapshot != nil {
+			dbNum = d.snapshot.databases()
+		} else {
+			var err error
+			if dbNum, err = d.redis.GetDatabases(); err != nil {
+				return nil, err
+			}
 		}
 		for index := 0; index < dbNum; index++ {
 			n := NewDataTree("db" + strconv.Itoa(index))
+			n.SetSeparator(d.sep)
 			d.db = append(d.db, n)
 		}
 	}
@@ -69,41 +163,128 @@ func (d *Data) GetDatabases() ([]*DataNode, error) {
 	return r, nil
 }
 
-// Cmd cmd
-func (d *Data) Cmd(w io.Writer, cmd string, params ...string) error {
+// ResetKeys drops the loaded keys of database index, scanned again by
+// ScanAllKeys.
+func (d *Data) ResetKeys(index int) {
+	if index >= 0 && index < len(d.db) {
+		d.db[index].Reset()
+	}
+}
+
+// Cmd runs a command and writes the reply to w, formatted in the given
+// output mode.
+func (d *Data) Cmd(w io.Writer, mode redis.OutputMode, cmd string, params ...string) error {
+	if d.IsRDB() {
+		return ErrOffline
+	}
 	if d.redis == nil {
 		return errors.New("Connection error: Cannot connect to redis-server.")
 	}
+	if d.readOnly && d.IsWriteCommand(cmd, params...) {
+		return fmt.Errorf("%v: %v may change data", ErrConnReadOnly, strings.ToUpper(cmd))
+	}
 
 	r, err := d.redis.Do(cmd, params...)
+	if d.isAudited(cmd, params...) {
+		d.audit(d.index, [][]string{append([]string{cmd}, params...)}, []*redis.Reply{r}, err)
+	}
 	if err != nil {
 		return err
 	}
+	return redis.WriteReply(w, r, mode)
+}
 
-	switch r.Type() {
-	case redis.Nil:
-		fmt.Fprintln(w, "(nil)")
-	case redis.Int:
-		v, err := r.Int()
+// Commands returns the commands supported by the server, loaded once per
+// connection.
+func (d *Data) Commands() map[string]*redisapi.CommandInfo {
+	if d.commands == nil {
+		if d.redis == nil {
+			return nil
+		}
+		commands, err := d.redis.Commands()
 		if err != nil {
-			return err
+			tlog.Warn("[Data] commands", "err", err)
+			commands = make(map[string]*redisapi.CommandInfo)
 		}
-		fmt.Fprint(w, v)
-	case redis.Err, redis.BulkStr, redis.SimpleStr:
-		fmt.Fprintln(w, r.String())
-	case redis.Array:
-		l, _ := r.List()
-		for _, v := range l {
-			fmt.Fprintln(w, v)
+		d.commands = commands
+	}
+	return d.commands
+}
+
+// LoadCommands loads the commands supported by the server on a dedicated
+// connection, for work outside the UI goroutine. Set them with
+// SetCommands.
+func (d *Data) LoadCommands() (map[string]*redisapi.CommandInfo, error) {
+	client, err := d.Dial()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	return client.Commands()
+}
+
+// SetCommands sets the commands returned by LoadCommands, none when it
+// failed, unless Commands loaded them first.
+func (d *Data) SetCommands(commands map[string]*redisapi.CommandInfo) {
+	if d.commands != nil {
+		return
+	}
+	if commands == nil {
+		commands = make(map[string]*redisapi.CommandInfo)
+	}
+	d.commands = commands
+}
+
+// LoadedCommands returns the commands loaded by Commands or set by
+// SetCommands, nil until then.
+func (d *Data) LoadedCommands() map[string]*redisapi.CommandInfo {
+	return d.commands
+}
+
+// IsWriteCommand tells if the command may change data, as flagged by
+// COMMAND: write commands, and scripts and functions that may replicate.
+// The admin commands changing the server, which COMMAND flags admin but
+// not write, and the commands COMMAND doesn't describe, all of them when
+// it failed, may change data too.
+func (d *Data) IsWriteCommand(cmd string, params ...string) bool {
+	name := strings.ToUpper(cmd)
+	if audit.IsAdminCommand(name) || len(params) > 0 && audit.IsAdminCommand(name+" "+strings.ToUpper(params[0])) {
+		return true
+	}
+	commands := d.Commands()
+	info, ok := commands[name]
+	if !ok {
+		return true
+	}
+	if len(params) > 0 {
+		if sub, ok := commands[name+" "+strings.ToUpper(params[0])]; ok {
+			info = sub
+		} else if len(info.Flags) == 0 {
+			// an unknown subcommand of a container such as CONFIG.
+			return true
 		}
-	default:
-		return fmt.Errorf("cmd no implement %v", r.Type())
 	}
-	return nil
+	return info.HasFlag("write") || info.HasFlag("may_replicate")
+}
+
+// CompleteKeys returns loaded keys of the current database starting with
+// prefix.
+func (d *Data) CompleteKeys(prefix string, limit int) []string {
+	if d.index >= len(d.db) {
+		return nil
+	}
+	return d.db[d.index].KeysWithPrefix(prefix, limit)
 }
 
 // ScanAllKeys get all key
 func (d *Data) ScanAllKeys() ([]*DataNode, error) {
+	if d.snapshot != nil {
+		n := d.db[d.index]
+		for _, key := range d.snapshot.keys(d.index) {
+			n.AddKey(key)
+		}
+		return n.GetChildren(n.root), nil
+	}
 	if d.redis == nil {
 		return nil, ErrDBNotConnect
 	}
@@ -113,7 +294,7 @@ func (d *Data) ScanAllKeys() ([]*DataNode, error) {
 	for {
 		var keys []string
 		var err error
-		cursor, keys, err = d.redis.Scan(cursor, "*", 10000)
+		cursor, keys, err = d.redis.Scan(cursor, "*", d.pageSize)
 		if err != nil {
 			return nil, err
 		}
@@ -149,6 +330,10 @@ func (d *Data) GetChildren(node *DataNode) []*DataNode {
 
 // Select select db
 func (d *Data) Select(index int) error {
+	if d.snapshot != nil {
+		d.index = index
+		return nil
+	}
 	if d.redis == nil {
 		return ErrDBNotConnect
 	}
@@ -169,6 +354,12 @@ func (d *Data) Index() int {
 
 // Type returns redis key type string for the given key.
 func (d *Data) Type(key string) string {
+	if d.snapshot != nil {
+		if e := d.snapshot.entry(d.index, key); e != nil {
+			return e.Type
+		}
+		return "none"
+	}
 	if d.redis == nil {
 		return ""
 	}
@@ -176,25 +367,40 @@ func (d *Data) Type(key string) string {
 }
 
 // Rename key -> newKey
-func (d *Data) Rename(node *DataNode, newKey string) {
+func (d *Data) Rename(node *DataNode, newKey string) error {
+	if err := d.CheckWrite(); err != nil {
+		return err
+	}
 	if d.redis == nil {
-		return
+		return ErrDBNotConnect
 	}
+	// RENAME replaces newKey, capture it to restore it on undo.
+	e := d.capture(fmt.Sprintf("rename %v to %v", FormatKey(node.key), FormatKey(newKey)), newKey)
+	e.from = node.key
 	err := d.redis.Rename(node.key, newKey)
+	d.auditCmd(d.index, err, "RENAME", node.key, newKey)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	d.undo.push(e)
 	node.key = newKey
-	index := strings.LastIndex(newKey, ":")
+	index := strings.LastIndexByte(newKey, d.sep)
 	if index != -1 {
 		node.name = newKey[index+1:]
 	} else {
 		node.name = newKey
 	}
+	return nil
 }
 
 // GetValue value
 func (d *Data) GetValue(key string) interface{} {
+	if d.snapshot != nil {
+		if e := d.snapshot.entry(d.index, key); e != nil {
+			return entryValue(e)
+		}
+		return nil
+	}
 	if d.redis == nil {
 		return nil
 	}
@@ -222,24 +428,37 @@ func (d *Data) GetValue(key string) interface{} {
 }
 
 func (d *Data) SetValue(node *DataNode, value string) error {
+	if err := d.CheckWrite(); err != nil {
+		return err
+	}
 	if d.redis == nil {
 		return ErrDBNotConnect
 	}
+	e := d.capture("save "+FormatKey(node.key), node.key)
 	err := d.redis.Set(node.key, value)
+	d.auditCmd(d.index, err, "SET", node.key, value)
 	if err != nil {
 		return err
 	}
+	d.undo.push(e)
 	return nil
 }
 
 // Delete node
 func (d *Data) Delete(node *DataNode) error {
+	if err := d.CheckWrite(); err != nil {
+		return err
+	}
 	if d.redis == nil {
 		return ErrDBNotConnect
 	}
-	if err := d.redis.Del(node.key); err != nil {
+	e := d.capture("delete "+FormatKey(node.key), node.key)
+	err := d.redis.Del(node.key)
+	d.auditCmd(d.index, err, "DEL", node.key)
+	if err != nil {
 		return err
 	}
+	d.undo.push(e)
 	node.removed = true
 	for _, v := range node.GetChildren() {
 		d.Delete(v)
@@ -247,31 +466,66 @@ func (d *Data) Delete(node *DataNode) error {
 	return nil
 }
 
+// DeleteNamespace deletes the keys starting with the key of node from
+// database index, found with SCAN and deleted with UNLINK in batches on a
+// dedicated connection, and returns how many were deleted, before the
+// error too. progress is called after each batch, on the calling
+// goroutine. Call RemoveNode afterwards, or ResetKeys when it failed, to
+// update the tree.
+func (d *Data) DeleteNamespace(ctx context.Context, index int, node *DataNode, progress func(scanned int)) (int, error) {
+	result, err := d.Bulk(ctx, selectionOf(index, node.key, false), BulkDelete, 0, progress)
+	if err != nil {
+		return result.Keys, err
+	}
+	if result.Errors > 0 {
+		return result.Keys, errors.New(result.FirstErrors[0])
+	}
+	return result.Keys, nil
+}
+
+// RemoveNode removes node and the keys below it from the tree of database
+// index, once deleted.
+func (d *Data) RemoveNode(index int, node *DataNode) {
+	if index < len(d.db) {
+		d.db[index].Remove(node)
+	}
+}
+
 // FlushDB remove all keys from current database.
 func (d *Data) FlushDB(node *DataNode) error {
+	if err := d.CheckWrite(); err != nil {
+		return err
+	}
 	if d.redis == nil {
 		return ErrDBNotConnect
 	}
-	if err := d.redis.FlushDB(); err != nil {
+	err := d.redis.FlushDB()
+	d.auditCmd(d.index, err, "FLUSHDB")
+	if err != nil {
 		return err
 	}
+	d.recordChange(d.index, "flushdb", "keys of FLUSHDB are not captured")
 	node.ClearChildren()
 	return nil
 }
 
 // Reload reload.
 func (d *Data) Reload(node *DataNode) error {
+	if d.snapshot != nil {
+		// The file does not change, the keys are all loaded.
+		return nil
+	}
 	if d.redis == nil {
 		return nil
 	}
-	tlog.Log("Data: Reload key %v*", node.key)
+	tlog.Debug("[Data] reload", "key", node.key+"*")
 	node.ClearChildren()
 
 	var cursor = "0"
 	for {
 		var keys []string
 		var err error
-		cursor, keys, err = d.redis.Scan(cursor, node.key+"*", 10000)
+		cursor, keys, err = d.redis.Scan(cursor, escapePattern(node.key)+"*", d.pageSize)
 		if err != nil {
 			return err
 		}
@@ -296,3 +550,74 @@ func (d *Data) Close() {
 	}
 	d.redis.Close()
 }
+
+// Subscribe runs a streaming command such as SUBSCRIBE or MONITOR on a
+// dedicated connection, leaving the data connection untouched.
+func (d *Data) Subscribe(cmd string, args ...string) (*redisapi.Subscriber, error) {
+	if d.IsRDB() {
+		return nil, ErrOffline
+	}
+	s, err := redisapi.NewSubscriber(d.address, d.auth, d.dial)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Start(cmd, args...); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// SlowLog returns the latest count slow log entries.
+func (d *Data) SlowLog(count int) ([]redisapi.SlowLogEntry, error) {
+	if d.IsRDB() {
+		return nil, ErrOffline
+	}
+	if d.redis == nil {
+		return nil, ErrDBNotConnect
+	}
+	return d.redis.SlowLog(count)
+}
+
+// SlowLogReset clears the slow log.
+func (d *Data) SlowLogReset() error {
+	if d.IsRDB() {
+		return ErrOffline
+	}
+	if err := d.CheckWrite(); err != nil {
+		return err
+	}
+	if d.redis == nil {
+		return ErrDBNotConnect
+	}
+	err := d.redis.SlowLogReset()
+	d.auditCmd(d.index, err, "SLOWLOG", "RESET")
+	return err
+}
+
+// ClientList returns the connected clients.
+func (d *Data) ClientList() ([]redisapi.ClientInfo, error) {
+	if d.IsRDB() {
+		return nil, ErrOffline
+	}
+	if d.redis == nil {
+		return nil, ErrDBNotConnect
+	}
+	return d.redis.ClientList()
+}
+
+// ClientKill closes the connection of a client.
+func (d *Data) ClientKill(id int64) error {
+	if d.IsRDB() {
+		return ErrOffline
+	}
+	if err := d.CheckWrite(); err != nil {
+		return err
+	}
+	if d.redis == nil {
+		return ErrDBNotConnect
+	}
+	err := d.redis.ClientKill(id)
+	d.auditCmd(d.index, err, "CLIENT", "KILL", "ID", strconv.FormatInt(id, 10))
+	return err
+}
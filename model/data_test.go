@@ -0,0 +1,160 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/liwnn/redisterm/redisapi"
+)
+
+func TestIsWriteCommand(t *testing.T) {
+	d := NewData("127.0.0.1:0", "")
+	d.commands = map[string]*redisapi.CommandInfo{
+		"GET":         {Name: "GET", Flags: []string{"readonly", "fast"}},
+		"SET":         {Name: "SET", Flags: []string{"write", "denyoom"}},
+		"EVAL":        {Name: "EVAL", Flags: []string{"noscript", "may_replicate"}},
+		"CONFIG":      {Name: "CONFIG"},
+		"CONFIG GET":  {Name: "CONFIG GET", Flags: []string{"admin", "noscript"}},
+		"CONFIG SET":  {Name: "CONFIG SET", Flags: []string{"admin", "noscript", "loading", "stale"}},
+		"SHUTDOWN":    {Name: "SHUTDOWN", Flags: []string{"admin", "noscript", "loading", "stale", "no_multi", "allow_busy"}},
+		"REPLICAOF":   {Name: "REPLICAOF", Flags: []string{"admin", "noscript", "stale", "no_async_loading"}},
+		"CLIENT":      {Name: "CLIENT"},
+		"CLIENT KILL": {Name: "CLIENT KILL", Flags: []string{"admin", "noscript", "loading", "stale"}},
+		"CLIENT LIST": {Name: "CLIENT LIST", Flags: []string{"admin", "noscript", "loading", "stale"}},
+	}
+	for _, tt := range []struct {
+		cmd    string
+		params []string
+		want   bool
+	}{
+		{"get", []string{"a"}, false},
+		{"set", []string{"a", "1"}, true},
+		{"eval", []string{"return 1", "0"}, true},
+		{"config", []string{"get", "maxmemory"}, false},
+		{"config", []string{"set", "maxmemory", "0"}, true},
+		{"config", []string{"unknown"}, true},
+		{"shutdown", nil, true},
+		{"replicaof", []string{"h", "6379"}, true},
+		{"client", []string{"kill", "id", "1"}, true},
+		{"client", []string{"list"}, false},
+		{"unknown", nil, true},
+	} {
+		if got := d.IsWriteCommand(tt.cmd, tt.params...); got != tt.want {
+			t.Errorf("IsWriteCommand(%v %v) = %v", tt.cmd, tt.params, got)
+		}
+	}
+}
+
+func TestIsWriteCommandWithoutCommands(t *testing.T) {
+	// COMMAND could not be loaded: nothing is known to be read-only.
+	d := NewData("127.0.0.1:0", "")
+	d.SetReadOnly(true)
+	for _, cmd := range []string{"get", "set", "flushall"} {
+		if !d.IsWriteCommand(cmd, "a") {
+			t.Errorf("IsWriteCommand(%v) = false without COMMAND", cmd)
+		}
+		if !d.isAudited(cmd, "a") {
+			t.Errorf("isAudited(%v) = false without COMMAND", cmd)
+		}
+	}
+}
+
+func TestCheckWrite(t *testing.T) {
+	d := NewData("127.0.0.1:0", "")
+	if err := d.CheckWrite(); err != nil {
+		t.Errorf("CheckWrite = %v", err)
+	}
+	d.SetReadOnly(true)
+	if err := d.CheckWrite(); !errors.Is(err, ErrConnReadOnly) {
+		t.Errorf("read-only CheckWrite = %v", err)
+	}
+	if err := NewRDBData("dump.rdb").CheckWrite(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("RDB CheckWrite = %v", err)
+	}
+	if err := d.ClientKill(1); !errors.Is(err, ErrConnReadOnly) {
+		t.Errorf("read-only ClientKill = %v", err)
+	}
+	if err := d.SlowLogReset(); !errors.Is(err, ErrConnReadOnly) {
+		t.Errorf("read-only SlowLogReset = %v", err)
+	}
+}
+
+func TestAuditEntries(t *testing.T) {
+	d := NewData("127.0.0.1:0", "")
+	d.conn = "local"
+	d.commands = map[string]*redisapi.CommandInfo{
+		"SET":         {Name: "SET", FirstKey: 1, Flags: []string{"write"}},
+		"OBJECT":      {Name: "OBJECT"},
+		"OBJECT FREQ": {Name: "OBJECT FREQ", FirstKey: 2},
+		"FLUSHDB":     {Name: "FLUSHDB", Flags: []string{"write"}},
+		"CONFIG":      {Name: "CONFIG"},
+		"CONFIG SET":  {Name: "CONFIG SET", Flags: []string{"admin"}},
+		"PUBLISH":     {Name: "PUBLISH", Flags: []string{"may_replicate"}},
+		"CLIENT":      {Name: "CLIENT"},
+		"CLIENT KILL": {Name: "CLIENT KILL", Flags: []string{"admin"}},
+		"CLIENT LIST": {Name: "CLIENT LIST", Flags: []string{"admin"}},
+		"DEBUG":       {Name: "DEBUG", Flags: []string{"admin"}},
+		"UNLINK":      {Name: "UNLINK", FirstKey: 1, Flags: []string{"write"}},
+	}
+	now := time.Now()
+	tests := []struct {
+		cmd  []string
+		want string
+	}{
+		{[]string{"set", "user:1", "secret value"}, "SET user:1 []"},
+		{[]string{"object", "freq", "user:1"}, "OBJECT FREQ user:1 []"},
+		{[]string{"flushdb"}, "FLUSHDB  []"},
+		{[]string{"config", "set", "requirepass", "secret"}, "CONFIG SET  [requirepass ***]"},
+		{[]string{"publish", "channel", "message"}, "PUBLISH  []"},
+		{[]string{"RESTORE", "k", "0", "payload"}, "RESTORE k []"},
+		{[]string{"unlink", "a", "b"}, "UNLINK a []|UNLINK b []"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, e := range d.auditEntries(now, 0, tt.cmd, "ok") {
+			got = append(got, fmt.Sprintf("%v %v %v", e.Command, e.Key, e.Args))
+		}
+		if s := strings.Join(got, "|"); s != tt.want {
+			t.Errorf("auditEntries(%q) = %q, want %q", tt.cmd, s, tt.want)
+		}
+	}
+	if !d.isAudited("client", "kill", "id", "1") || d.isAudited("client", "list") || !d.isAudited("debug", "sleep", "0") {
+		t.Error("isAudited of admin commands")
+	}
+}
+
+func TestAuditEntriesWithoutCommands(t *testing.T) {
+	// COMMAND could not be loaded: every command is audited, without its
+	// passwords.
+	d := NewData("127.0.0.1:0", "")
+	now := time.Now()
+	for _, cmd := range [][]string{
+		{"auth", "s3cret"},
+		{"AUTH", "user", "s3cret"},
+		{"hello", "3", "auth", "user", "s3cret"},
+	} {
+		if d.isAudited(cmd[0], cmd[1:]...) {
+			t.Errorf("isAudited(%q)", cmd)
+		}
+		if entries := d.auditEntries(now, 0, cmd, "ok"); len(entries) != 0 {
+			t.Errorf("auditEntries(%q) = %+v", cmd, entries)
+		}
+	}
+	for _, cmd := range [][]string{
+		{"config", "set", "requirepass", "s3cret"},
+		{"acl", "setuser", "bob", ">s3cret"},
+		{"migrate", "h", "6379", "k", "0", "100", "AUTH", "s3cret"},
+	} {
+		if !d.isAudited(cmd[0], cmd[1:]...) {
+			t.Errorf("isAudited(%q) = false", cmd)
+		}
+		for _, e := range d.auditEntries(now, 0, cmd, "ok") {
+			if s := fmt.Sprintf("%v %v %v", e.Command, e.Key, e.Args); strings.Contains(s, "s3cret") {
+				t.Errorf("auditEntries(%q) kept the password: %v", cmd, s)
+			}
+		}
+	}
+}
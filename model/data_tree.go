@@ -7,6 +7,8 @@ import (
 
 // DataNode node
 type DataNode struct {
+	// name and key hold raw key bytes, which are not necessarily valid
+	// UTF-8; use FormatKey to display them.
 	name    string
 	key     string
 	child   []*DataNode
@@ -14,6 +16,11 @@ type DataNode struct {
 	keyNum  int
 	removed bool
 
+	// memory is the sampled memory usage of the key, or the sum of the
+	// sampled keys below a namespace; memKeys counts those keys.
+	memory  int64
+	memKeys int
+
 	childMap map[string]*DataNode
 }
 
@@ -29,6 +36,16 @@ func (n *DataNode) KeyNum() int {
 	return n.keyNum
 }
 
+// Memory returns the sampled memory usage in bytes, see Data.AnalyzeMemory.
+func (n *DataNode) Memory() int64 {
+	return n.memory
+}
+
+// MemoryKeys returns the number of sampled keys counted in Memory.
+func (n *DataNode) MemoryKeys() int {
+	return n.memKeys
+}
+
 func (n *DataNode) IsRemoved() bool {
 	return n.removed
 }
@@ -117,9 +134,14 @@ func (n *DataNode) RemoveSelf() {
 	}
 }
 
+// DefaultSeparator splits the keys into namespaces by default.
+const DefaultSeparator = ':'
+
 // DataTree 数据
 type DataTree struct {
 	root *DataNode
+	// sep splits the keys into namespaces.
+	sep byte
 }
 
 // NewDataTree new
@@ -128,16 +150,28 @@ func NewDataTree(rootName string) *DataTree {
 		root: &DataNode{
 			name: rootName,
 		},
+		sep: DefaultSeparator,
 	}
 	return t
 }
 
+// SetSeparator splits the keys added after on sep.
+func (t *DataTree) SetSeparator(sep byte) {
+	t.sep = sep
+}
+
+// Reset removes all the keys.
+func (t *DataTree) Reset() {
+	t.root.ClearChildren()
+	t.root.memory, t.root.memKeys = 0, 0
+}
+
 // AddKey 增加key
 func (t *DataTree) AddKey(key string) {
 	var lastColon int = -1
 	var p = t.root
-	for i, c := range key {
-		if c != ':' {
+	for i := 0; i < len(key); i++ {
+		if key[i] != t.sep {
 			continue
 		}
 		prefix := key[:i+1]
@@ -153,9 +187,112 @@ func (t *DataTree) AddKey(key string) {
 	}
 	name := key[lastColon+1:]
 	prefix := key
-	if p.GetChildByKey(prefix) == nil {
-		p.AddChild(name, prefix)
+	if p.GetChildByKey(prefix) != nil {
+		// added already, with its namespaces: uncount it.
+		for n := p; n != t.root; n = n.p {
+			n.keyNum--
+		}
+		return
+	}
+	p.AddChild(name, prefix)
+}
+
+// path returns the nodes from the root to key, or nil if key is not
+// loaded.
+func (t *DataTree) path(key string) []*DataNode {
+	nodes := []*DataNode{t.root}
+	p := t.root
+	for i := 0; i < len(key); i++ {
+		if key[i] != t.sep {
+			continue
+		}
+		node := p.GetChildByKey(key[:i+1])
+		if node == nil {
+			return nil
+		}
+		nodes = append(nodes, node)
+		p = node
+	}
+	leaf := p.GetChildByKey(key)
+	if leaf == nil {
+		return nil
+	}
+	return append(nodes, leaf)
+}
+
+// AddMemory records the memory usage of a key on the key and all its
+// namespaces, adding the key to the tree if needed.
+func (t *DataTree) AddMemory(key string, bytes int64) {
+	nodes := t.path(key)
+	if nodes == nil {
+		t.AddKey(key)
+		nodes = t.path(key)
+	}
+	for _, n := range nodes {
+		n.memory += bytes
+		n.memKeys++
+	}
+}
+
+// ResetMemory clears the memory usage recorded below n, and removes it
+// from the namespaces above n.
+func (t *DataTree) ResetMemory(n *DataNode) {
+	for p := n.p; p != nil; p = p.p {
+		p.memory -= n.memory
+		p.memKeys -= n.memKeys
+	}
+	var clear func(n *DataNode)
+	clear = func(n *DataNode) {
+		n.memory = 0
+		n.memKeys = 0
+		for _, v := range n.child {
+			clear(v)
+		}
+	}
+	clear(n)
+}
+
+// Remove removes n and the nodes below it, and the keys of n from the
+// key counts of the namespaces above.
+func (t *DataTree) Remove(n *DataNode) {
+	if n.removed {
+		return
+	}
+	t.ResetMemory(n)
+	for p := n.p; p != nil && p != t.root; p = p.p {
+		p.keyNum -= n.keyNum
+	}
+	var remove func(n *DataNode)
+	remove = func(n *DataNode) {
+		n.removed = true
+		for _, v := range n.child {
+			remove(v)
+		}
+	}
+	remove(n)
+	n.RemoveSelf()
+}
+
+// KeysWithPrefix returns at most limit loaded keys starting with prefix.
+func (t *DataTree) KeysWithPrefix(prefix string, limit int) []string {
+	var keys []string
+	var walk func(n *DataNode)
+	walk = func(n *DataNode) {
+		for _, v := range n.child {
+			if len(keys) >= limit {
+				return
+			}
+			if v.HasChild() {
+				if strings.HasPrefix(v.key, prefix) || strings.HasPrefix(prefix, v.key) {
+					walk(v)
+				}
+			} else if strings.HasPrefix(v.key, prefix) {
+				keys = append(keys, v.key)
+			}
+		}
 	}
+	walk(t.root)
+	return keys
 }
 
 // GetChildren name
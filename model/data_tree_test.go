@@ -13,6 +13,95 @@ func TestAddKey(t *testing.T) {
 	tree.AddKey("a:c")
 }
 
+func TestAddKeyTwice(t *testing.T) {
+	tree := NewDataTree("root")
+	for i := 0; i < 2; i++ {
+		tree.AddKey("a:b")
+		tree.AddKey("a:c")
+	}
+	a := tree.root.GetChildByKey("a:")
+	if a.KeyNum() != 2 || len(a.GetChildren()) != 2 {
+		t.Errorf("a: counts %v keys, %v children", a.KeyNum(), len(a.GetChildren()))
+	}
+	tree.Reset()
+	tree.AddKey("a:b")
+	if a := tree.root.GetChildByKey("a:"); a.KeyNum() != 1 || len(tree.root.GetChildren()) != 1 {
+		t.Errorf("after Reset a: counts %v keys", a.KeyNum())
+	}
+}
+
+func TestSeparator(t *testing.T) {
+	tree := NewDataTree("root")
+	tree.SetSeparator('/')
+	tree.AddKey("a/b:c")
+	tree.AddKey("a/d")
+	children := tree.GetChildren(tree.root)
+	if len(children) != 1 || children[0].Key() != "a/" {
+		t.Fatalf("children %v", children)
+	}
+	if n := children[0].GetChildByKey("a/b:c"); n == nil || n.Name() != "b:c" {
+		t.Errorf("a/b:c is %v", n)
+	}
+}
+
+func TestKeysWithPrefix(t *testing.T) {
+	tree := NewDataTree("root")
+	for _, key := range []string{"user:1", "user:2", "user:10:name", "order:1", "u"} {
+		tree.AddKey(key)
+	}
+	keys := tree.KeysWithPrefix("user:1", 10)
+	if len(keys) != 2 || keys[0] != "user:1" || keys[1] != "user:10:name" {
+		t.Errorf("KeysWithPrefix = %q", keys)
+	}
+	if keys := tree.KeysWithPrefix("", 3); len(keys) != 3 {
+		t.Errorf("KeysWithPrefix limit = %q", keys)
+	}
+}
+
+func TestAddMemory(t *testing.T) {
+	tree := NewDataTree("root")
+	tree.AddKey("a:b:c")
+	tree.AddKey("a:d")
+	tree.AddMemory("a:b:c", 100)
+	tree.AddMemory("a:d", 50)
+	tree.AddMemory("x:y", 10)
+
+	a := tree.root.GetChildByKey("a:")
+	b := a.GetChildByKey("a:b:")
+	if tree.root.Memory() != 160 || a.Memory() != 150 || a.MemoryKeys() != 2 || b.Memory() != 100 {
+		t.Errorf("memory root %v a %v b %v", tree.root.Memory(), a.Memory(), b.Memory())
+	}
+	if a.KeyNum() != 2 {
+		t.Errorf("AddMemory changed key count %v", a.KeyNum())
+	}
+	if tree.root.GetChildByKey("x:") == nil {
+		t.Error("AddMemory did not add missing key")
+	}
+
+	tree.ResetMemory(b)
+	if tree.root.Memory() != 60 || a.Memory() != 50 || a.MemoryKeys() != 1 || b.Memory() != 0 {
+		t.Errorf("reset memory root %v a %v b %v", tree.root.Memory(), a.Memory(), b.Memory())
+	}
+}
+
+func TestRemove(t *testing.T) {
+	tree := NewDataTree("root")
+	for _, key := range []string{"a:b:1", "a:b:2", "a:c", "x:b:1"} {
+		tree.AddKey(key)
+	}
+	a := tree.root.GetChildByKey("a:")
+	b := a.GetChildByKey("a:b:")
+	key := b.GetChildByKey("a:b:1")
+	tree.Remove(b)
+	if a.KeyNum() != 1 || a.GetChildByKey("a:b:") != nil || !b.IsRemoved() || !key.IsRemoved() {
+		t.Errorf("remove a:b: count %v removed %v %v", a.KeyNum(), b.IsRemoved(), key.IsRemoved())
+	}
+	tree.Remove(a.GetChildByKey("a:c"))
+	if tree.root.GetChildByKey("a:") != nil || !a.IsRemoved() {
+		t.Error("empty namespace a: not removed")
+	}
+}
+
 // BenchmarkAddKey-4   	21802245	        54.9 ns/op	       0 B/op	       0 allocs/op
 // BenchmarkAddKey-4   	   54043	    119964 ns/op	     138 B/op	       2 allocs/op
 func BenchmarkAddKey(b *testing.B) {
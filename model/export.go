@@ -0,0 +1,525 @@
+package model
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/liwnn/redisterm/rdb"
+	"github.com/liwnn/redisterm/redis"
+	"github.com/liwnn/redisterm/redisapi"
+)
+
+// ExportFormat is a file format written by Export.
+type ExportFormat int
+
+const (
+	// ExportJSON writes one JSON object per key.
+	ExportJSON ExportFormat = iota
+	// ExportCSV writes one row per element of hashes, sorted sets, lists,
+	// sets and strings.
+	ExportCSV
+	// ExportCommands writes the commands recreating the keys, to replay
+	// with redis-cli.
+	ExportCommands
+)
+
+// ExportFormats are the formats in menu order.
+var ExportFormats = []ExportFormat{ExportJSON, ExportCSV, ExportCommands}
+
+func (f ExportFormat) String() string {
+	switch f {
+	case ExportCSV:
+		return "CSV"
+	case ExportCommands:
+		return "Redis commands"
+	}
+	return "JSON lines"
+}
+
+// Ext returns the file extension of the format.
+func (f ExportFormat) Ext() string {
+	switch f {
+	case ExportCSV:
+		return ".csv"
+	case ExportCommands:
+		return ".redis"
+	}
+	return ".jsonl"
+}
+
+// commandBatch is the most elements written in one command.
+const commandBatch = 100
+
+// KeyValue is a key with its type, TTL and full value.
+type KeyValue struct {
+	Key  string
+	Type string
+	// TTL is the time to live in milliseconds, -1 without expiration.
+	TTL int64
+	// Value is the value of a string.
+	Value string
+	// Elems holds the elements of lists and sets, the field value pairs of
+	// hashes and the member score pairs of sorted sets.
+	Elems []string
+	// Entries holds the entries of a stream.
+	Entries []StreamEntry
+}
+
+// StreamEntry is a stream entry, Fields holding field value pairs.
+type StreamEntry struct {
+	ID     string
+	Fields []string
+}
+
+// Commands returns the commands recreating kv.
+func (kv *KeyValue) Commands() [][]string {
+	var cmds [][]string
+	batch := func(cmd string, step int) {
+		for i := 0; i < len(kv.Elems); i += commandBatch * step {
+			end := min(i+commandBatch*step, len(kv.Elems))
+			cmds = append(cmds, append([]string{cmd, kv.Key}, kv.Elems[i:end]...))
+		}
+	}
+	switch kv.Type {
+	case "string":
+		cmds = append(cmds, []string{"SET", kv.Key, kv.Value})
+	case "list":
+		batch("RPUSH", 1)
+	case "set":
+		batch("SADD", 1)
+	case "hash":
+		batch("HSET", 2)
+	case "zset":
+		for i := 0; i < len(kv.Elems); i += commandBatch * 2 {
+			cmd := []string{"ZADD", kv.Key}
+			for j := i; j < min(i+commandBatch*2, len(kv.Elems)); j += 2 {
+				cmd = append(cmd, kv.Elems[j+1], kv.Elems[j])
+			}
+			cmds = append(cmds, cmd)
+		}
+	case "stream":
+		for _, e := range kv.Entries {
+			cmds = append(cmds, append([]string{"XADD", kv.Key, e.ID}, e.Fields...))
+		}
+	}
+	if kv.TTL > 0 && len(cmds) > 0 {
+		cmds = append(cmds, []string{"PEXPIRE", kv.Key, strconv.FormatInt(kv.TTL, 10)})
+	}
+	return cmds
+}
+
+// ExportOptions selects the keys of Export.
+type ExportOptions struct {
+	Index int
+	// Prefix selects the keys starting with it, or only the key Prefix
+	// when Exact is set.
+	Prefix string
+	Exact  bool
+	// Selection, when set, replaces Index, Prefix and Exact.
+	Selection *Selection
+	Format    ExportFormat
+}
+
+func (o *ExportOptions) selection() *Selection {
+	if o.Selection != nil {
+		return o.Selection
+	}
+	return selectionOf(o.Index, o.Prefix, o.Exact)
+}
+
+// ExportResult counts the keys handled by Export.
+type ExportResult struct {
+	Keys int
+	// Skipped counts keys the format can't hold, or deleted while
+	// exporting.
+	Skipped int
+}
+
+// Export writes the keys selected by opts to w, with their type, TTL and
+// value. Keys are read with SCAN on a dedicated connection, or from the
+// RDB file. progress is called after each batch, on the calling
+// goroutine.
+func (d *Data) Export(ctx context.Context, opts ExportOptions, w io.Writer, progress func(exported int)) (*ExportResult, error) {
+	e := newExporter(opts.Format, w)
+	result := &ExportResult{}
+	write := func(batch []KeyValue) error {
+		for i := range batch {
+			ok, err := e.write(&batch[i])
+			if err != nil {
+				return err
+			}
+			if ok {
+				result.Keys++
+			} else {
+				result.Skipped++
+			}
+		}
+		if progress != nil {
+			progress(result.Keys)
+		}
+		return ctx.Err()
+	}
+
+	var err error
+	if d.snapshot != nil {
+		err = d.snapshot.export(opts.selection(), write)
+	} else {
+		err = d.exportServer(ctx, opts.selection(), write, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := e.flush(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (d *Data) exportServer(ctx context.Context, sel *Selection, write func([]KeyValue) error, result *ExportResult) error {
+	client, err := d.dialDB(sel.Index)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return sel.forEach(ctx, client, func(keys []string) error {
+		return readAndWrite(client, keys, write, result)
+	})
+}
+
+func readAndWrite(client *redisapi.Redis, keys []string, write func([]KeyValue) error, result *ExportResult) error {
+	batch, err := ReadKeys(client, keys)
+	if err != nil {
+		return err
+	}
+	result.Skipped += len(keys) - len(batch)
+	return write(batch)
+}
+
+// valueCommands are the commands reading the full value of a type.
+var valueCommands = map[string][]string{
+	"string": {"GET"},
+	"list":   {"LRANGE", "", "0", "-1"},
+	"set":    {"SMEMBERS"},
+	"zset":   {"ZRANGE", "", "0", "-1", "WITHSCORES"},
+	"hash":   {"HGETALL"},
+	"stream": {"XRANGE", "", "-", "+"},
+}
+
+func valueCommand(typ, key string) []string {
+	cmd := append([]string(nil), valueCommands[typ]...)
+	if len(cmd) == 1 {
+		return append(cmd, key)
+	}
+	cmd[1] = key
+	return cmd
+}
+
+// ReadKeys reads the type, TTL and value of keys with pipelined commands.
+// Keys that don't exist anymore or of module types are left out.
+func ReadKeys(client *redisapi.Redis, keys []string) ([]KeyValue, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	cmds := make([][]string, 0, len(keys)*2)
+	for _, key := range keys {
+		cmds = append(cmds, []string{"TYPE", key}, []string{"PTTL", key})
+	}
+	replies, err := client.Pipeline(cmds)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]KeyValue, 0, len(keys))
+	cmds = cmds[:0]
+	for i, key := range keys {
+		kv := KeyValue{
+			Key:  key,
+			Type: replies[i*2].String(),
+			TTL:  -1,
+		}
+		if _, ok := valueCommands[kv.Type]; !ok {
+			continue
+		}
+		if ttl, err := replies[i*2+1].Int(); err == nil && ttl >= 0 {
+			kv.TTL = int64(ttl)
+		}
+		result = append(result, kv)
+		cmds = append(cmds, valueCommand(kv.Type, key))
+	}
+
+	replies, err = client.Pipeline(cmds)
+	if err != nil {
+		return nil, err
+	}
+	values := result[:0]
+	for i, kv := range result {
+		r := replies[i]
+		if r.IsNil() || r.Err() != nil {
+			continue
+		}
+		switch kv.Type {
+		case "string":
+			kv.Value = string(r.Byte())
+		case "stream":
+			for _, entry := range r.ToArray() {
+				parts := entry.ToArray()
+				if len(parts) != 2 {
+					continue
+				}
+				fields, _ := parts[1].List()
+				kv.Entries = append(kv.Entries, StreamEntry{ID: parts[0].String(), Fields: fields})
+			}
+		default:
+			if kv.Elems, err = r.List(); err != nil {
+				continue
+			}
+		}
+		values = append(values, kv)
+	}
+	return values, nil
+}
+
+func (s *snapshot) export(sel *Selection, write func([]KeyValue) error) error {
+	now := s.now()
+	var batch []KeyValue
+	for _, key := range s.keys(sel.Index) {
+		if !sel.Contains(key) {
+			continue
+		}
+		kv, ok := entryKeyValue(s.entry(sel.Index, key), now)
+		if !ok {
+			continue
+		}
+		batch = append(batch, kv)
+		if len(batch) == scanBatch {
+			if err := write(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	return write(batch)
+}
+
+func entryKeyValue(e *rdb.Entry, now int64) (KeyValue, bool) {
+	kv := KeyValue{
+		Key:  e.Key,
+		Type: e.Type,
+		TTL:  -1,
+	}
+	if e.Expire != 0 {
+		kv.TTL = max(e.Expire-now, 1)
+	}
+	switch v := e.Value.(type) {
+	case []byte:
+		kv.Value = string(v)
+	case []string:
+		kv.Elems = v
+	case []rdb.Field:
+		for _, f := range v {
+			kv.Elems = append(kv.Elems, f.Field, f.Value)
+		}
+	case []rdb.Member:
+		for _, m := range v {
+			kv.Elems = append(kv.Elems, m.Member, strconv.FormatFloat(m.Score, 'g', -1, 64))
+		}
+	case *rdb.Stream:
+		for _, entry := range v.Entries {
+			kv.Entries = append(kv.Entries, StreamEntry{ID: entry.ID.String(), Fields: entry.Fields})
+		}
+	default:
+		return kv, false
+	}
+	return kv, true
+}
+
+type exporter struct {
+	format ExportFormat
+	w      io.Writer
+	csv    *csv.Writer
+	// started is set once the CSV header is written.
+	started bool
+}
+
+func newExporter(format ExportFormat, w io.Writer) *exporter {
+	e := &exporter{format: format, w: w}
+	if format == ExportCSV {
+		e.csv = csv.NewWriter(w)
+	}
+	return e
+}
+
+// write writes kv, reporting false when the format can't hold it.
+func (e *exporter) write(kv *KeyValue) (bool, error) {
+	switch e.format {
+	case ExportCSV:
+		return e.writeCSV(kv)
+	case ExportCommands:
+		for _, cmd := range kv.Commands() {
+			args := make([]string, len(cmd))
+			for i, arg := range cmd {
+				args[i] = redis.QuoteArg(arg)
+			}
+			if _, err := io.WriteString(e.w, strings.Join(args, " ")+"\n"); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	b, err := json.Marshal(newJSONKey(kv))
+	if err != nil {
+		return false, err
+	}
+	_, err = e.w.Write(append(b, '\n'))
+	return err == nil, err
+}
+
+func (e *exporter) writeCSV(kv *KeyValue) (bool, error) {
+	if !e.started {
+		if err := e.csv.Write([]string{"key", "type", "ttl", "field", "value"}); err != nil {
+			return false, err
+		}
+		e.started = true
+	}
+	ttl := strconv.FormatInt(kv.TTL, 10)
+	row := func(field, value string) error {
+		return e.csv.Write([]string{kv.Key, kv.Type, ttl, field, value})
+	}
+	var err error
+	switch kv.Type {
+	case "string":
+		err = row("", kv.Value)
+	case "list":
+		for i, elem := range kv.Elems {
+			if err = row(strconv.Itoa(i), elem); err != nil {
+				break
+			}
+		}
+	case "set":
+		for _, elem := range kv.Elems {
+			if err = row("", elem); err != nil {
+				break
+			}
+		}
+	case "hash", "zset":
+		for i := 0; i+1 < len(kv.Elems); i += 2 {
+			if err = row(kv.Elems[i], kv.Elems[i+1]); err != nil {
+				break
+			}
+		}
+	default:
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (e *exporter) flush() error {
+	if e.csv == nil {
+		return nil
+	}
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+// jsonKey is a key in the JSON lines format. Strings that aren't valid
+// UTF-8 are base64 encoded, with Encoding set to "base64".
+type jsonKey struct {
+	Key      string      `json:"key"`
+	Type     string      `json:"type"`
+	TTL      int64       `json:"ttl"`
+	Encoding string      `json:"encoding,omitempty"`
+	Value    interface{} `json:"value"`
+}
+
+type jsonMember struct {
+	Member string      `json:"member"`
+	Score  interface{} `json:"score"`
+}
+
+type jsonEntry struct {
+	ID     string   `json:"id"`
+	Fields []string `json:"fields"`
+}
+
+func newJSONKey(kv *KeyValue) *jsonKey {
+	enc := func(s string) string { return s }
+	if !kv.isUTF8() {
+		enc = func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	}
+	j := &jsonKey{
+		Key:  enc(kv.Key),
+		Type: kv.Type,
+		TTL:  kv.TTL,
+	}
+	if !kv.isUTF8() {
+		j.Encoding = "base64"
+	}
+	encAll := func(elems []string) []string {
+		out := make([]string, len(elems))
+		for i, s := range elems {
+			out[i] = enc(s)
+		}
+		return out
+	}
+	switch kv.Type {
+	case "string":
+		j.Value = enc(kv.Value)
+	case "list", "set":
+		j.Value = encAll(kv.Elems)
+	case "hash":
+		h := make(map[string]string, len(kv.Elems)/2)
+		for i := 0; i+1 < len(kv.Elems); i += 2 {
+			h[enc(kv.Elems[i])] = enc(kv.Elems[i+1])
+		}
+		j.Value = h
+	case "zset":
+		members := make([]jsonMember, 0, len(kv.Elems)/2)
+		for i := 0; i+1 < len(kv.Elems); i += 2 {
+			members = append(members, jsonMember{Member: enc(kv.Elems[i]), Score: jsonScore(kv.Elems[i+1])})
+		}
+		j.Value = members
+	case "stream":
+		entries := make([]jsonEntry, 0, len(kv.Entries))
+		for _, e := range kv.Entries {
+			entries = append(entries, jsonEntry{ID: e.ID, Fields: encAll(e.Fields)})
+		}
+		j.Value = entries
+	}
+	return j
+}
+
+// jsonScore returns a score as a JSON number, or as a string for the
+// infinities JSON can't hold.
+func jsonScore(s string) interface{} {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return s
+	}
+	return f
+}
+
+func (kv *KeyValue) isUTF8() bool {
+	if !utf8.ValidString(kv.Key) || !utf8.ValidString(kv.Value) {
+		return false
+	}
+	for _, s := range kv.Elems {
+		if !utf8.ValidString(s) {
+			return false
+		}
+	}
+	for _, e := range kv.Entries {
+		for _, s := range e.Fields {
+			if !utf8.ValidString(s) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+var exportKeys = []KeyValue{
+	{Key: "s", Type: "string", TTL: 5000, Value: "a b"},
+	{Key: "h", Type: "hash", TTL: -1, Elems: []string{"f", "v"}},
+	{Key: "z", Type: "zset", TTL: -1, Elems: []string{"m", "1.5", "n", "inf"}},
+	{Key: "x", Type: "stream", TTL: -1, Entries: []StreamEntry{{ID: "1-0", Fields: []string{"f", "v"}}}},
+	{Key: "\xff", Type: "list", TTL: -1, Elems: []string{"a"}},
+}
+
+func export(t *testing.T, format ExportFormat) (string, int) {
+	t.Helper()
+	var b bytes.Buffer
+	e := newExporter(format, &b)
+	written := 0
+	for i := range exportKeys {
+		ok, err := e.write(&exportKeys[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			written++
+		}
+	}
+	if err := e.flush(); err != nil {
+		t.Fatal(err)
+	}
+	return b.String(), written
+}
+
+func TestExportJSON(t *testing.T) {
+	out, n := export(t, ExportJSON)
+	want := `{"key":"s","type":"string","ttl":5000,"value":"a b"}
+{"key":"h","type":"hash","ttl":-1,"value":{"f":"v"}}
+{"key":"z","type":"zset","ttl":-1,"value":[{"member":"m","score":1.5},{"member":"n","score":"inf"}]}
+{"key":"x","type":"stream","ttl":-1,"value":[{"id":"1-0","fields":["f","v"]}]}
+{"key":"/w==","type":"list","ttl":-1,"encoding":"base64","value":["YQ=="]}
+`
+	if out != want || n != len(exportKeys) {
+		t.Errorf("JSON export %v keys:\n%v", n, out)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	out, n := export(t, ExportCSV)
+	want := "key,type,ttl,field,value\n" +
+		"s,string,5000,,a b\n" +
+		"h,hash,-1,f,v\n" +
+		"z,zset,-1,m,1.5\n" +
+		"z,zset,-1,n,inf\n" +
+		"\xff,list,-1,0,a\n"
+	if out != want || n != len(exportKeys)-1 {
+		t.Errorf("CSV export %v keys:\n%v", n, out)
+	}
+}
+
+func TestExportCommands(t *testing.T) {
+	out, _ := export(t, ExportCommands)
+	want := `SET s "a b"
+PEXPIRE s 5000
+HSET h f v
+ZADD z 1.5 m inf n
+XADD x 1-0 f v
+RPUSH "\xff" a
+`
+	if out != want {
+		t.Errorf("command export:\n%v", out)
+	}
+}
+
+func TestKeyValueCommandsBatch(t *testing.T) {
+	kv := KeyValue{Key: "l", Type: "list", TTL: -1}
+	for i := 0; i < commandBatch+1; i++ {
+		kv.Elems = append(kv.Elems, "e")
+	}
+	cmds := kv.Commands()
+	if len(cmds) != 2 || len(cmds[0]) != commandBatch+2 || !reflect.DeepEqual(cmds[1], []string{"RPUSH", "l", "e"}) {
+		t.Errorf("Commands() = %v", cmds)
+	}
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+)
+
+// Formats of the string values, chosen by key pattern.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatHex  = "hex"
+)
+
+// ValueFormatter chooses the format of the string values by key pattern.
+type ValueFormatter struct {
+	rules []formatRule
+}
+
+type formatRule struct {
+	pattern string
+	format  string
+}
+
+// AddRule shows the values of the keys matching the glob pattern in
+// format, unless an earlier rule matches them.
+func (f *ValueFormatter) AddRule(pattern, format string) {
+	f.rules = append(f.rules, formatRule{pattern: pattern, format: format})
+}
+
+// Format returns the format of the value of key, "" when no rule matches.
+func (f *ValueFormatter) Format(key string) string {
+	if f == nil {
+		return ""
+	}
+	for _, r := range f.rules {
+		if ok, err := path.Match(r.pattern, key); err == nil && ok {
+			return r.format
+		}
+	}
+	return ""
+}
+
+// FormatValue returns b as shown in format and if it can be edited as
+// shown: not in the hex format. Binary values are shown in hex, JSON that
+// is not valid as is.
+func FormatValue(format string, b []byte) (text string, editable bool) {
+	switch {
+	case format == FormatHex:
+		return EncodeToHexString(b), false
+	case !IsText(b):
+		return EncodeToHexString(b), true
+	case format == FormatJSON && json.Valid(b):
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, b, "", "  "); err == nil {
+			return buf.String(), true
+		}
+	}
+	return string(b), true
+}
+
+// ParseValue returns the value of text edited as shown by FormatValue in
+// format: indented JSON is compacted back unless the value was indented.
+func ParseValue(format string, old []byte, text string) (string, error) {
+	if format != FormatJSON || !json.Valid(old) {
+		return text, nil
+	}
+	if !json.Valid([]byte(text)) {
+		return "", fmt.Errorf("the value is not valid JSON")
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, old); err != nil || compact.Len() != len(old) {
+		// the value was indented, it is saved as shown.
+		return text, nil
+	}
+	compact.Reset()
+	if err := json.Compact(&compact, []byte(text)); err != nil {
+		return "", err
+	}
+	return compact.String(), nil
+}
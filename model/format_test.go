@@ -0,0 +1,61 @@
+package model
+
+import "testing"
+
+func TestValueFormatter(t *testing.T) {
+	var f *ValueFormatter
+	if got := f.Format("a"); got != "" {
+		t.Errorf("nil Format = %q", got)
+	}
+	f = &ValueFormatter{}
+	f.AddRule("json:*", FormatJSON)
+	f.AddRule("*", FormatHex)
+	if got := f.Format("json:1"); got != FormatJSON {
+		t.Errorf("Format(json:1) = %q", got)
+	}
+	if got := f.Format("bin"); got != FormatHex {
+		t.Errorf("Format(bin) = %q", got)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		format   string
+		value    string
+		text     string
+		editable bool
+	}{
+		{"", `{"a":1}`, `{"a":1}`, true},
+		{FormatJSON, `{"a":1}`, "{\n  \"a\": 1\n}", true},
+		{FormatJSON, `{"a":`, `{"a":`, true},
+		{FormatHex, "ab", EncodeToHexString([]byte("ab")), false},
+	}
+	for _, tt := range tests {
+		text, editable := FormatValue(tt.format, []byte(tt.value))
+		if text != tt.text || editable != tt.editable {
+			t.Errorf("FormatValue(%q, %q) = %q, %v", tt.format, tt.value, text, editable)
+		}
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		format string
+		old    string
+		text   string
+		value  string
+		err    bool
+	}{
+		{"", `{"a":1}`, "{\n \"a\": 2}", "{\n \"a\": 2}", false},
+		{FormatJSON, `{"a":1}`, "{\n  \"a\": 2\n}", `{"a":2}`, false},
+		{FormatJSON, "{\n  \"a\": 1\n}", "{\n  \"a\": 2\n}", "{\n  \"a\": 2\n}", false},
+		{FormatJSON, `{"a":1}`, `{"a":`, "", true},
+		{FormatJSON, "text", `{"a":`, `{"a":`, false},
+	}
+	for _, tt := range tests {
+		value, err := ParseValue(tt.format, []byte(tt.old), tt.text)
+		if (err != nil) != tt.err || value != tt.value {
+			t.Errorf("ParseValue(%q, %q, %q) = %q, %v", tt.format, tt.old, tt.text, value, err)
+		}
+	}
+}
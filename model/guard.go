@@ -0,0 +1,55 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/liwnn/redisterm/redisapi"
+)
+
+// DefaultGuard is the guard of connections without one in their config.
+var DefaultGuard = redisapi.GuardConfig{
+	Commands: []string{"FLUSHDB", "FLUSHALL", "KEYS", "DEBUG", "SHUTDOWN", "CONFIG SET"},
+	BulkKeys: 1000,
+}
+
+// Guard tells which operations are dangerous enough to be confirmed by
+// typing the database index or the connection name.
+type Guard struct {
+	commands map[string]bool
+	bulkKeys int
+}
+
+// NewGuard returns the guard of c, DefaultGuard when c is nil. The
+// commands of DefaultGuard are guarded too unless c lists its commands,
+// possibly none.
+func NewGuard(c *redisapi.GuardConfig) *Guard {
+	if c == nil {
+		c = &DefaultGuard
+	}
+	commands := c.Commands
+	if commands == nil {
+		commands = DefaultGuard.Commands
+	}
+	g := &Guard{
+		commands: make(map[string]bool, len(commands)),
+		bulkKeys: c.BulkKeys,
+	}
+	for _, cmd := range commands {
+		g.commands[strings.ToUpper(strings.Join(strings.Fields(cmd), " "))] = true
+	}
+	return g
+}
+
+// Command tells if the command with params is dangerous.
+func (g *Guard) Command(cmd string, params ...string) bool {
+	name := strings.ToUpper(cmd)
+	if g.commands[name] {
+		return true
+	}
+	return len(params) > 0 && g.commands[name+" "+strings.ToUpper(params[0])]
+}
+
+// Bulk tells if deleting keys keys at once is dangerous.
+func (g *Guard) Bulk(keys int) bool {
+	return g.bulkKeys > 0 && keys >= g.bulkKeys
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/liwnn/redisterm/redisapi"
+)
+
+func TestGuard(t *testing.T) {
+	g := NewGuard(nil)
+	for _, tt := range []struct {
+		cmd    string
+		params []string
+		want   bool
+	}{
+		{"flushdb", nil, true},
+		{"keys", []string{"*"}, true},
+		{"config", []string{"set", "maxmemory", "0"}, true},
+		{"config", []string{"get", "maxmemory"}, false},
+		{"get", []string{"a"}, false},
+	} {
+		if got := g.Command(tt.cmd, tt.params...); got != tt.want {
+			t.Errorf("Command(%v %v) = %v", tt.cmd, tt.params, got)
+		}
+	}
+	if g.Bulk(999) || !g.Bulk(1000) {
+		t.Error("default bulk threshold")
+	}
+
+	g = NewGuard(&redisapi.GuardConfig{Commands: []string{"del", "script  flush"}})
+	if !g.Command("DEL", "a") || !g.Command("script", "FLUSH") || g.Command("FLUSHDB") {
+		t.Error("configured commands")
+	}
+	if g.Bulk(1 << 30) {
+		t.Error("bulk guarded with BulkKeys 0")
+	}
+
+	g = NewGuard(&redisapi.GuardConfig{BulkKeys: 10})
+	if !g.Command("flushall") || !g.Command("shutdown") || !g.Bulk(10) {
+		t.Error("default commands without commands configured")
+	}
+	g = NewGuard(&redisapi.GuardConfig{Commands: []string{}})
+	if g.Command("flushall") {
+		t.Error("default commands with no commands configured")
+	}
+}
@@ -0,0 +1,593 @@
+package model
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/liwnn/redisterm/redis"
+	"github.com/liwnn/redisterm/redisapi"
+)
+
+// ImportFormat is a file format read by Import.
+type ImportFormat int
+
+const (
+	// ImportAuto detects the format from the first character of the file.
+	ImportAuto ImportFormat = iota
+	// ImportJSON reads the JSON lines written by Export.
+	ImportJSON
+	// ImportCommands reads one command per line, quoted like redis-cli.
+	ImportCommands
+	// ImportRESP reads commands encoded in RESP, as for redis-cli --pipe.
+	ImportRESP
+)
+
+// ImportFormats are the formats in menu order.
+var ImportFormats = []ImportFormat{ImportAuto, ImportJSON, ImportCommands, ImportRESP}
+
+func (f ImportFormat) String() string {
+	switch f {
+	case ImportJSON:
+		return "JSON lines"
+	case ImportCommands:
+		return "Redis commands"
+	case ImportRESP:
+		return "RESP"
+	}
+	return "Auto detect"
+}
+
+// ConflictPolicy tells Import what to do with keys that already exist.
+type ConflictPolicy int
+
+const (
+	// ConflictSkip leaves existing keys alone and drops their commands.
+	ConflictSkip ConflictPolicy = iota
+	// ConflictOverwrite deletes existing keys before writing them.
+	ConflictOverwrite
+	// ConflictRename writes to a free key named after the existing one.
+	ConflictRename
+)
+
+// ConflictPolicies are the policies in menu order.
+var ConflictPolicies = []ConflictPolicy{ConflictSkip, ConflictOverwrite, ConflictRename}
+
+func (p ConflictPolicy) String() string {
+	switch p {
+	case ConflictOverwrite:
+		return "Overwrite"
+	case ConflictRename:
+		return "Rename"
+	}
+	return "Skip"
+}
+
+// maxImportErrors is the number of failed commands kept in ImportResult.
+const maxImportErrors = 10
+
+// renameSuffix is appended to keys renamed on conflict.
+const renameSuffix = ":imported"
+
+// ImportOptions configures Import.
+type ImportOptions struct {
+	Index    int
+	Format   ImportFormat
+	Conflict ConflictPolicy
+	// DryRun checks the file and the conflicts without writing.
+	DryRun bool
+}
+
+// ImportResult counts what Import did, or would do in a dry run.
+type ImportResult struct {
+	DryRun   bool
+	Format   ImportFormat
+	Commands int
+	Keys     int
+	// Skipped, Overwritten and Renamed count the keys that already
+	// existed.
+	Skipped     int
+	Overwritten int
+	Renamed     int
+	Errors      int
+	// FirstErrors holds the first failed commands with their position.
+	FirstErrors []string
+}
+
+func (r *ImportResult) String() string {
+	var b strings.Builder
+	verb := "Imported"
+	if r.DryRun {
+		verb = "Dry run:"
+	}
+	fmt.Fprintf(&b, "%v %v keys with %v commands (%v)", verb, r.Keys, r.Commands, r.Format)
+	fmt.Fprintf(&b, "\nExisting keys: %v skipped, %v overwritten, %v renamed", r.Skipped, r.Overwritten, r.Renamed)
+	if r.Errors > 0 {
+		fmt.Fprintf(&b, "\n%v errors", r.Errors)
+		for _, e := range r.FirstErrors {
+			b.WriteString("\n  " + e)
+		}
+	}
+	return b.String()
+}
+
+func (r *ImportResult) addError(pos string, err error) {
+	r.Errors++
+	if len(r.FirstErrors) < maxImportErrors {
+		r.FirstErrors = append(r.FirstErrors, pos+": "+err.Error())
+	}
+}
+
+// ErrGuarded is the error of the imported commands the guard of the
+// connection asks to confirm, not run.
+var ErrGuarded = errors.New("dangerous command not imported, run it in the console")
+
+// Import runs the commands of a file read from r on database index,
+// pipelined on a dedicated connection. The key of each command, as told
+// by COMMAND, is checked the first time it is seen, and the conflict
+// policy applies to keys that existed before the import. The commands of
+// the guard, such as FLUSHALL, are not run. A dry run checks the file on
+// read-only connections too. progress is called with the bytes read
+// after each batch, on the calling goroutine.
+func (d *Data) Import(ctx context.Context, opts ImportOptions, r io.Reader, progress func(read int64)) (*ImportResult, error) {
+	if !opts.DryRun {
+		if err := d.CheckWrite(); err != nil {
+			return nil, err
+		}
+	}
+	counter := &countingReader{r: r}
+	br := bufio.NewReaderSize(counter, 64*1024)
+	if opts.Format == ImportAuto {
+		opts.Format = detectFormat(br)
+	}
+	src := newCommandSource(opts.Format, br)
+
+	client, err := d.dialDB(opts.Index)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	if !opts.DryRun {
+		defer d.recordChange(opts.Index, "import", "imported keys are not captured")
+	}
+	// Without COMMAND the key is taken to be the first argument.
+	commands, _ := client.Commands()
+
+	im := &importer{
+		data:     d,
+		client:   client,
+		opts:     opts,
+		commands: commands,
+		keys:     make(map[string]string),
+		result:   &ImportResult{DryRun: opts.DryRun, Format: opts.Format},
+	}
+	for {
+		args, pos, err := src.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", pos, err)
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if d.Guard().Command(args[0], args[1:]...) {
+			im.result.addError(pos, ErrGuarded)
+			continue
+		}
+		im.pending = append(im.pending, importCommand{args: args, pos: pos})
+		if len(im.pending) < scanBatch {
+			continue
+		}
+		if err := im.flush(); err != nil {
+			return nil, err
+		}
+		if progress != nil {
+			progress(counter.n)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if err := im.flush(); err != nil {
+		return nil, err
+	}
+	if progress != nil {
+		progress(counter.n)
+	}
+	return im.result, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// detectFormat guesses the format from the first non blank character:
+// '{' for JSON, '*' for RESP, and commands otherwise.
+func detectFormat(br *bufio.Reader) ImportFormat {
+	for i := 1; ; i++ {
+		b, err := br.Peek(i)
+		if err != nil || len(b) < i {
+			return ImportCommands
+		}
+		switch b[i-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '{':
+			return ImportJSON
+		case '*':
+			return ImportRESP
+		}
+		return ImportCommands
+	}
+}
+
+type importCommand struct {
+	args []string
+	pos  string
+}
+
+type importer struct {
+	data     *Data
+	client   *redisapi.Redis
+	opts     ImportOptions
+	commands map[string]*redisapi.CommandInfo
+	// keys maps the keys of the file to the keys written, "" when skipped.
+	keys    map[string]string
+	pending []importCommand
+	result  *ImportResult
+}
+
+// keyIndex returns the position of the key of args, 0 for none.
+func (im *importer) keyIndex(args []string) int {
+	if info, ok := im.commands[strings.ToUpper(args[0])]; ok {
+		if info.FirstKey > 0 && info.FirstKey < len(args) {
+			return info.FirstKey
+		}
+		return 0
+	}
+	if len(args) > 1 {
+		return 1
+	}
+	return 0
+}
+
+// flush resolves the keys seen for the first time in the pending
+// commands, then runs them.
+func (im *importer) flush() error {
+	if len(im.pending) == 0 {
+		return nil
+	}
+	var fresh []string
+	seen := make(map[string]bool)
+	for _, c := range im.pending {
+		if i := im.keyIndex(c.args); i > 0 {
+			key := c.args[i]
+			if _, ok := im.keys[key]; !ok && !seen[key] {
+				seen[key] = true
+				fresh = append(fresh, key)
+			}
+		}
+	}
+	exists, err := im.exists(fresh)
+	if err != nil {
+		return err
+	}
+	overwrite := make(map[string]bool)
+	for i, key := range fresh {
+		target := key
+		if exists[i] {
+			switch im.opts.Conflict {
+			case ConflictSkip:
+				target = ""
+				im.result.Skipped++
+			case ConflictOverwrite:
+				overwrite[key] = true
+				im.result.Overwritten++
+			case ConflictRename:
+				if target, err = im.freeKey(key); err != nil {
+					return err
+				}
+				im.result.Renamed++
+			}
+		}
+		im.keys[key] = target
+		if target != "" {
+			im.result.Keys++
+		}
+	}
+
+	cmds := make([][]string, 0, len(im.pending))
+	positions := make([]string, 0, len(im.pending))
+	for _, c := range im.pending {
+		args := c.args
+		if i := im.keyIndex(args); i > 0 {
+			key := args[i]
+			target := im.keys[key]
+			if target == "" {
+				continue
+			}
+			if overwrite[key] {
+				delete(overwrite, key)
+				cmds = append(cmds, []string{"DEL", key})
+				positions = append(positions, c.pos)
+			}
+			if target != key {
+				args = append([]string(nil), args...)
+				args[i] = target
+			}
+		}
+		cmds = append(cmds, args)
+		positions = append(positions, c.pos)
+	}
+	im.pending = im.pending[:0]
+	im.result.Commands += len(cmds)
+	if im.opts.DryRun || len(cmds) == 0 {
+		return nil
+	}
+	replies, err := im.data.writePipeline(im.client, im.opts.Index, cmds)
+	if err != nil {
+		return err
+	}
+	for i, r := range replies {
+		if err := r.Err(); err != nil {
+			im.result.addError(positions[i], err)
+		}
+	}
+	return nil
+}
+
+func (im *importer) exists(keys []string) ([]bool, error) {
+	cmds := make([][]string, 0, len(keys))
+	for _, key := range keys {
+		cmds = append(cmds, []string{"EXISTS", key})
+	}
+	replies, err := im.client.Pipeline(cmds)
+	if err != nil {
+		return nil, err
+	}
+	exists := make([]bool, len(keys))
+	for i, r := range replies {
+		n, _ := r.Int()
+		exists[i] = n > 0
+	}
+	return exists, nil
+}
+
+// freeKey returns a key named after key that exists neither on the server
+// nor in the file.
+func (im *importer) freeKey(key string) (string, error) {
+	for n := 1; ; n++ {
+		candidate := key + renameSuffix
+		if n > 1 {
+			candidate += strconv.Itoa(n)
+		}
+		if _, ok := im.keys[candidate]; ok {
+			continue
+		}
+		exists, err := im.exists([]string{candidate})
+		if err != nil {
+			return "", err
+		}
+		if !exists[0] {
+			return candidate, nil
+		}
+	}
+}
+
+// commandSource returns the commands of a file with their position, for
+// error messages.
+type commandSource interface {
+	next() (args []string, pos string, err error)
+}
+
+func newCommandSource(format ImportFormat, br *bufio.Reader) commandSource {
+	switch format {
+	case ImportJSON:
+		return &jsonSource{r: br}
+	case ImportRESP:
+		return &respSource{r: redis.NewReader(br)}
+	}
+	return &lineSource{r: br}
+}
+
+// readLine reads a line of any length without its line ending.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+type lineSource struct {
+	r    *bufio.Reader
+	line int
+}
+
+func (s *lineSource) next() ([]string, string, error) {
+	for {
+		line, err := readLine(s.r)
+		s.line++
+		pos := "line " + strconv.Itoa(s.line)
+		if err != nil {
+			return nil, pos, err
+		}
+		if trimmed := strings.TrimSpace(line); trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+		args, err := redis.SplitArgs(line)
+		return args, pos, err
+	}
+}
+
+type respSource struct {
+	r *redis.RESPReader
+	n int
+}
+
+func (s *respSource) next() ([]string, string, error) {
+	s.n++
+	pos := "command " + strconv.Itoa(s.n)
+	o, err := s.r.ReadObject()
+	if err != nil {
+		return nil, pos, err
+	}
+	r := redis.NewReply(o)
+	if r.Type() != redis.Array {
+		return nil, pos, errors.New("expected a RESP array")
+	}
+	args, err := r.List()
+	return args, pos, err
+}
+
+type jsonSource struct {
+	r       *bufio.Reader
+	line    int
+	pending [][]string
+}
+
+func (s *jsonSource) next() ([]string, string, error) {
+	for len(s.pending) == 0 {
+		line, err := readLine(s.r)
+		s.line++
+		if err != nil {
+			return nil, s.pos(), err
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		kv, err := parseJSONKey([]byte(line))
+		if err != nil {
+			return nil, s.pos(), err
+		}
+		s.pending = kv.Commands()
+	}
+	cmd := s.pending[0]
+	s.pending = s.pending[1:]
+	return cmd, s.pos(), nil
+}
+
+func (s *jsonSource) pos() string {
+	return "line " + strconv.Itoa(s.line)
+}
+
+// jsonRecord is a jsonKey being decoded.
+type jsonRecord struct {
+	Key      string          `json:"key"`
+	Type     string          `json:"type"`
+	TTL      *int64          `json:"ttl"`
+	Encoding string          `json:"encoding"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// parseJSONKey parses a line of the JSON lines format of Export.
+func parseJSONKey(line []byte) (*KeyValue, error) {
+	var rec jsonRecord
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return nil, err
+	}
+	kv := &KeyValue{
+		Key:  rec.Key,
+		Type: rec.Type,
+		TTL:  -1,
+	}
+	if rec.TTL != nil {
+		kv.TTL = *rec.TTL
+	}
+	var err error
+	switch rec.Type {
+	case "string":
+		err = json.Unmarshal(rec.Value, &kv.Value)
+	case "list", "set":
+		err = json.Unmarshal(rec.Value, &kv.Elems)
+	case "hash":
+		var h map[string]string
+		if err = json.Unmarshal(rec.Value, &h); err == nil {
+			fields := make([]string, 0, len(h))
+			for f := range h {
+				fields = append(fields, f)
+			}
+			sort.Strings(fields)
+			for _, f := range fields {
+				kv.Elems = append(kv.Elems, f, h[f])
+			}
+		}
+	case "zset":
+		var members []struct {
+			Member string          `json:"member"`
+			Score  json.RawMessage `json:"score"`
+		}
+		if err = json.Unmarshal(rec.Value, &members); err == nil {
+			for _, m := range members {
+				// Scores are numbers, or strings for the infinities.
+				score := string(m.Score)
+				if strings.HasPrefix(score, `"`) {
+					err = json.Unmarshal(m.Score, &score)
+				}
+				kv.Elems = append(kv.Elems, m.Member, score)
+			}
+		}
+	case "stream":
+		var entries []jsonEntry
+		if err = json.Unmarshal(rec.Value, &entries); err == nil {
+			for _, e := range entries {
+				kv.Entries = append(kv.Entries, StreamEntry{ID: e.ID, Fields: e.Fields})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown type %q", rec.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rec.Encoding == "base64" {
+		if err := kv.decodeBase64(); err != nil {
+			return nil, err
+		}
+	}
+	return kv, nil
+}
+
+func (kv *KeyValue) decodeBase64() error {
+	var err error
+	decode := func(s *string) {
+		if err != nil {
+			return
+		}
+		var b []byte
+		if b, err = base64.StdEncoding.DecodeString(*s); err == nil {
+			*s = string(b)
+		}
+	}
+	decode(&kv.Key)
+	decode(&kv.Value)
+	isZSet := kv.Type == "zset"
+	for i := range kv.Elems {
+		// Scores are never encoded.
+		if !isZSet || i%2 == 0 {
+			decode(&kv.Elems[i])
+		}
+	}
+	for _, e := range kv.Entries {
+		for i := range e.Fields {
+			decode(&e.Fields[i])
+		}
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseJSONKey(t *testing.T) {
+	for i := range exportKeys {
+		kv := &exportKeys[i]
+		b, err := json.Marshal(newJSONKey(kv))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := parseJSONKey(b)
+		if err != nil {
+			t.Fatalf("%s: %v", b, err)
+		}
+		if !reflect.DeepEqual(got, kv) {
+			t.Errorf("parseJSONKey(%s) = %+v, want %+v", b, got, kv)
+		}
+	}
+	if _, err := parseJSONKey([]byte(`{"key":"k","type":"module"}`)); err == nil {
+		t.Error("unknown type: expected error")
+	}
+}
+
+func readSource(t *testing.T, format ImportFormat, text string) [][]string {
+	t.Helper()
+	br := bufio.NewReader(strings.NewReader(text))
+	if format == ImportAuto {
+		format = detectFormat(br)
+	}
+	src := newCommandSource(format, br)
+	var cmds [][]string
+	for {
+		args, pos, err := src.next()
+		if err == io.EOF {
+			return cmds
+		}
+		if err != nil {
+			t.Fatalf("%v: %v", pos, err)
+		}
+		cmds = append(cmds, args)
+	}
+}
+
+func TestCommandSources(t *testing.T) {
+	want := [][]string{{"SET", "a b", "1"}, {"PEXPIRE", "a b", "5"}}
+	tests := map[string]string{
+		"commands": "# fixture\nSET \"a b\" 1\n\nPEXPIRE \"a b\" 5",
+		"resp":     "*3\r\n$3\r\nSET\r\n$3\r\na b\r\n$1\r\n1\r\n*3\r\n$7\r\nPEXPIRE\r\n$3\r\na b\r\n$1\r\n5\r\n",
+		"json":     "\n" + `{"key":"a b","type":"string","ttl":5,"value":"1"}` + "\n",
+	}
+	for name, text := range tests {
+		if got := readSource(t, ImportAuto, text); !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestImportGuarded(t *testing.T) {
+	s, addr := newFakeServer(t, func(args []string) string {
+		switch strings.ToUpper(args[0]) {
+		case "EXISTS":
+			return ":0\r\n"
+		case "SET":
+			return "+OK\r\n"
+		}
+		return "-ERR unknown\r\n"
+	})
+	d := NewData(addr, "")
+	file := "SET a 1\nFLUSHALL\nflushdb async\nSET b 2\n"
+
+	d.SetReadOnly(true)
+	result, err := d.Import(context.Background(), ImportOptions{Format: ImportCommands, DryRun: true}, strings.NewReader(file), nil)
+	if err != nil || result.Keys != 2 || result.Errors != 2 {
+		t.Errorf("read-only dry run = %+v, %v", result, err)
+	}
+	if _, err := d.Import(context.Background(), ImportOptions{Format: ImportCommands}, strings.NewReader(file), nil); !errors.Is(err, ErrConnReadOnly) {
+		t.Errorf("read-only import = %v", err)
+	}
+
+	d.SetReadOnly(false)
+	result, err = d.Import(context.Background(), ImportOptions{Format: ImportCommands}, strings.NewReader(file), nil)
+	if err != nil || result.Commands != 2 || result.Errors != 2 {
+		t.Errorf("import = %+v, %v", result, err)
+	}
+	if flushes := append(s.sent("FLUSHALL"), s.sent("flushdb")...); len(flushes) != 0 {
+		t.Errorf("%q sent", flushes)
+	}
+}
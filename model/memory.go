@@ -0,0 +1,170 @@
+package model
+
+import (
+	"context"
+	"sort"
+
+	"github.com/liwnn/redisterm/redisapi"
+)
+
+const scanBatch = 500
+
+// KeyMemory is the memory usage of a key sampled by AnalyzeMemory.
+type KeyMemory struct {
+	Key      string
+	Type     string
+	Bytes    int64
+	Elements int64
+}
+
+// MemoryReport is the result of AnalyzeMemory.
+type MemoryReport struct {
+	Prefix string
+	Keys   []KeyMemory
+	Total  int64
+	// Truncated is set when the scan stopped at the key limit.
+	Truncated bool
+}
+
+// Top returns the n biggest keys.
+func (r *MemoryReport) Top(n int) []KeyMemory {
+	keys := append([]KeyMemory(nil), r.Keys...)
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Bytes > keys[j].Bytes
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
+
+// lengthCommands are the commands returning the element count of a type.
+var lengthCommands = map[string]string{
+	"string": "STRLEN",
+	"list":   "LLEN",
+	"set":    "SCARD",
+	"zset":   "ZCARD",
+	"hash":   "HLEN",
+	"stream": "XLEN",
+}
+
+// dialDB opens a dedicated connection on database index.
+func (d *Data) dialDB(index int) (*redisapi.Redis, error) {
+	client, err := d.Dial()
+	if err != nil {
+		return nil, err
+	}
+	if index != 0 {
+		if err := client.Select(index); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// AnalyzeMemory scans the keys of database index starting with prefix on
+// a dedicated connection, and samples their memory usage and element count
+// with pipelined TYPE, MEMORY USAGE and length commands. It stops after
+// limit keys or when ctx is done. progress is called after each batch, on
+// the calling goroutine.
+func (d *Data) AnalyzeMemory(ctx context.Context, index int, prefix string, limit int, progress func(scanned int)) (*MemoryReport, error) {
+	client, err := d.dialDB(index)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	report := &MemoryReport{Prefix: prefix}
+	cursor := "0"
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var keys []string
+		cursor, keys, err = client.Scan(cursor, escapePattern(prefix)+"*", scanBatch)
+		if err != nil {
+			return nil, err
+		}
+		if len(report.Keys)+len(keys) >= limit {
+			// the keys left in the batch or after it are not analyzed.
+			report.Truncated = len(report.Keys)+len(keys) > limit || cursor != "0"
+			keys = keys[:limit-len(report.Keys)]
+		}
+		batch, err := sampleMemory(client, keys)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range batch {
+			report.Total += k.Bytes
+		}
+		report.Keys = append(report.Keys, batch...)
+		if progress != nil {
+			progress(len(report.Keys))
+		}
+		if cursor == "0" || report.Truncated || len(report.Keys) >= limit {
+			break
+		}
+	}
+	return report, nil
+}
+
+func sampleMemory(client *redisapi.Redis, keys []string) ([]KeyMemory, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	cmds := make([][]string, 0, len(keys)*2)
+	for _, key := range keys {
+		cmds = append(cmds, []string{"TYPE", key}, []string{"MEMORY", "USAGE", key})
+	}
+	replies, err := client.Pipeline(cmds)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]KeyMemory, 0, len(keys))
+	cmds = cmds[:0]
+	for i, key := range keys {
+		k := KeyMemory{
+			Key:  key,
+			Type: replies[i*2].String(),
+		}
+		// MEMORY USAGE needs Redis 4.0, keys deleted meanwhile are nil.
+		if v, err := replies[i*2+1].Int(); err == nil {
+			k.Bytes = int64(v)
+		}
+		result = append(result, k)
+		if cmd, ok := lengthCommands[k.Type]; ok {
+			cmds = append(cmds, []string{cmd, key})
+		}
+	}
+
+	replies, err = client.Pipeline(cmds)
+	if err != nil {
+		return nil, err
+	}
+	j := 0
+	for i := range result {
+		if _, ok := lengthCommands[result[i].Type]; !ok {
+			continue
+		}
+		if v, err := replies[j].Int(); err == nil {
+			result[i].Elements = int64(v)
+		}
+		j++
+	}
+	return result, nil
+}
+
+// ApplyMemory records a report on the tree of database index, replacing
+// earlier results below node.
+func (d *Data) ApplyMemory(index int, node *DataNode, report *MemoryReport) {
+	if index >= len(d.db) {
+		return
+	}
+	tree := d.db[index]
+	tree.ResetMemory(node)
+	for _, k := range report.Keys {
+		tree.AddMemory(k.Key, k.Bytes)
+	}
+}
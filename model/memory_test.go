@@ -0,0 +1,39 @@
+package model
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeMemoryTruncated(t *testing.T) {
+	_, addr := newFakeServer(t, func(args []string) string {
+		switch strings.ToUpper(args[0]) {
+		case "SCAN":
+			// the last batch, of 3 keys.
+			return "*2\r\n$1\r\n0\r\n*3\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\nc\r\n"
+		case "TYPE":
+			return "+string\r\n"
+		case "MEMORY":
+			return ":10\r\n"
+		}
+		return "-ERR unknown\r\n"
+	})
+	d := NewData(addr, "")
+	for _, tt := range []struct {
+		limit     int
+		truncated bool
+	}{
+		{2, true},
+		{3, false},
+		{4, false},
+	} {
+		report, err := d.AnalyzeMemory(context.Background(), 0, "", tt.limit, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Truncated != tt.truncated || len(report.Keys) != min(tt.limit, 3) {
+			t.Errorf("limit %v: %v keys, truncated %v", tt.limit, len(report.Keys), report.Truncated)
+		}
+	}
+}
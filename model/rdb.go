@@ -0,0 +1,122 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/liwnn/redisterm/rdb"
+	"github.com/liwnn/redisterm/redisapi"
+)
+
+var (
+	ErrReadOnly = errors.New("RDB file is read-only")
+	ErrOffline  = errors.New("Not available when browsing an RDB file")
+)
+
+// defaultDatabases is the number of databases shown for an RDB file when
+// it has no key in a higher one.
+const defaultDatabases = 16
+
+// snapshot holds the keys of an RDB file by database.
+type snapshot struct {
+	file *rdb.File
+	dbs  map[int]map[string]*rdb.Entry
+}
+
+// NewRDBData returns data browsing the RDB file name instead of a server.
+// It is read-only.
+func NewRDBData(name string) *Data {
+	return &Data{file: name, sep: DefaultSeparator, pageSize: defaultPageSize}
+}
+
+// IsRDB reports whether d browses an RDB file.
+func (d *Data) IsRDB() bool {
+	return d.file != ""
+}
+
+// RDB returns the RDB file loaded by Connect, or nil.
+func (d *Data) RDB() *rdb.File {
+	if d.snapshot == nil {
+		return nil
+	}
+	return d.snapshot.file
+}
+
+func (d *Data) loadRDB() error {
+	if d.snapshot != nil {
+		return nil
+	}
+	f, err := rdb.ReadFile(d.file)
+	if err != nil {
+		return err
+	}
+	s := &snapshot{
+		file: f,
+		dbs:  make(map[int]map[string]*rdb.Entry),
+	}
+	for _, e := range f.Entries {
+		db, ok := s.dbs[e.DB]
+		if !ok {
+			db = make(map[string]*rdb.Entry)
+			s.dbs[e.DB] = db
+		}
+		db[e.Key] = e
+	}
+	d.snapshot = s
+	return nil
+}
+
+func (s *snapshot) databases() int {
+	n := defaultDatabases
+	for index := range s.dbs {
+		if index >= n {
+			n = index + 1
+		}
+	}
+	return n
+}
+
+func (s *snapshot) keys(index int) []string {
+	keys := make([]string, 0, len(s.dbs[index]))
+	for key := range s.dbs[index] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *snapshot) entry(index int, key string) *rdb.Entry {
+	return s.dbs[index][key]
+}
+
+// entryValue converts the value of an RDB entry to the types returned by
+// GetValue for a server.
+func entryValue(e *rdb.Entry) interface{} {
+	switch v := e.Value.(type) {
+	case []byte, []string:
+		return v
+	case []rdb.Field:
+		h := make([]redisapi.KVText, 0, len(v))
+		for _, f := range v {
+			h = append(h, redisapi.KVText{Key: f.Field, Value: f.Value})
+		}
+		return h
+	case []rdb.Member:
+		z := make([]redisapi.ZSetText, 0, len(v))
+		for _, m := range v {
+			z = append(z, redisapi.ZSetText{Key: m.Member, Value: strconv.FormatFloat(m.Score, 'g', -1, 64)})
+		}
+		return z
+	case *rdb.Stream:
+		h := make([]redisapi.KVText, 0, len(v.Entries))
+		for _, entry := range v.Entries {
+			h = append(h, redisapi.KVText{Key: entry.ID.String(), Value: strings.Join(entry.Fields, " ")})
+		}
+		return h
+	case rdb.Module:
+		return []byte("module " + v.Name + " v" + strconv.Itoa(v.Version) + ", value not readable")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/liwnn/redisterm/redis"
+)
+
+// fakeServer serves the connections of a test with reply, which returns
+// the RESP reply of a command, and returns its address. The commands are
+// recorded in the order received.
+type fakeServer struct {
+	mu       sync.Mutex
+	commands []string
+}
+
+func newFakeServer(t *testing.T, reply func(args []string) string) (*fakeServer, string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	s := &fakeServer{}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, reply)
+		}
+	}()
+	return s, l.Addr().String()
+}
+
+func (s *fakeServer) serve(conn net.Conn, reply func(args []string) string) {
+	defer conn.Close()
+	r := redis.NewReader(conn)
+	for {
+		o, err := r.ReadObject()
+		if err != nil {
+			return
+		}
+		args, _ := redis.NewReply(o).List()
+		if len(args) == 0 {
+			return
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, strings.Join(args, " "))
+		s.mu.Unlock()
+		var out string
+		switch strings.ToUpper(args[0]) {
+		case "CLIENT", "SELECT":
+			out = "+OK\r\n"
+		default:
+			out = reply(args)
+		}
+		if _, err := conn.Write([]byte(out)); err != nil {
+			return
+		}
+	}
+}
+
+// sent returns the commands received starting with prefix.
+func (s *fakeServer) sent(prefix string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var cmds []string
+	for _, c := range s.commands {
+		if strings.HasPrefix(c, prefix) {
+			cmds = append(cmds, c)
+		}
+	}
+	return cmds
+}
@@ -0,0 +1,289 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/liwnn/redisterm/redis"
+	"github.com/liwnn/redisterm/redisapi"
+)
+
+// ErrSameTarget is returned when keys would be copied onto themselves.
+var ErrSameTarget = errors.New("source and target are the same database")
+
+// TransferOptions selects the keys of Transfer and where they go.
+type TransferOptions struct {
+	Index int
+	// Prefix selects the keys starting with it, or only the key Prefix
+	// when Exact is set.
+	Prefix string
+	Exact  bool
+	// Selection, when set, replaces Index, Prefix and Exact.
+	Selection *Selection
+
+	TargetIndex int
+	// Move deletes the keys from the source once copied.
+	Move bool
+}
+
+// TransferResult counts the keys handled by Transfer.
+type TransferResult struct {
+	// Restored counts keys copied with DUMP and RESTORE, Copied keys
+	// rewritten from their value.
+	Restored int
+	Copied   int
+	// Skipped counts keys deleted while copying or of module types that
+	// can't be rewritten.
+	Skipped     int
+	Errors      int
+	FirstErrors []string
+}
+
+// Keys returns the number of keys copied.
+func (r *TransferResult) Keys() int {
+	return r.Restored + r.Copied
+}
+
+func (r *TransferResult) addError(key string, err error) {
+	r.Errors++
+	if len(r.FirstErrors) < maxImportErrors {
+		r.FirstErrors = append(r.FirstErrors, FormatKey(key)+": "+err.Error())
+	}
+}
+
+// Transfer copies the keys selected by opts to database TargetIndex of
+// target, replacing the keys already there. Keys are copied with DUMP
+// and RESTORE ... REPLACE ABSTTL, or rewritten from their type and value
+// when the target refuses the payload, as between different versions.
+// Keys of an RDB file are always rewritten. progress is called after
+// each batch, on the calling goroutine.
+func (d *Data) Transfer(ctx context.Context, target *Data, opts TransferOptions, progress func(done int)) (*TransferResult, error) {
+	if err := target.CheckWrite(); err != nil {
+		return nil, err
+	}
+	if opts.Move {
+		if err := d.CheckWrite(); err != nil {
+			return nil, err
+		}
+	}
+	sel := opts.Selection
+	if sel == nil {
+		sel = selectionOf(opts.Index, opts.Prefix, opts.Exact)
+	}
+	if d.file == "" && d.address == target.address && sel.Index == opts.TargetIndex {
+		return nil, ErrSameTarget
+	}
+	dst, err := target.dialDB(opts.TargetIndex)
+	if err != nil {
+		return nil, err
+	}
+	defer dst.Close()
+	defer target.recordChange(opts.TargetIndex, "copy "+sel.String(), "copied keys are not captured")
+	if opts.Move {
+		defer d.recordChange(sel.Index, "move "+sel.String(), "moved keys are not captured")
+	}
+
+	result := &TransferResult{}
+	write := func(cmds [][]string) ([]*redis.Reply, error) {
+		return target.writePipeline(dst, opts.TargetIndex, cmds)
+	}
+	if d.snapshot != nil {
+		err = d.snapshot.export(sel, func(batch []KeyValue) error {
+			if _, err := rewriteKeys(write, batch, result); err != nil {
+				return err
+			}
+			if progress != nil {
+				progress(result.Keys())
+			}
+			return ctx.Err()
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	src, err := d.dialDB(sel.Index)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	del := func(cmds [][]string) ([]*redis.Reply, error) {
+		return d.writePipeline(src, sel.Index, cmds)
+	}
+	t := &transfer{src: src, write: write, delete: del, move: opts.Move, dump: true, result: result}
+	err = sel.forEach(ctx, src, func(keys []string) error {
+		if err := t.copy(keys); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(result.Keys())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type transfer struct {
+	src *redisapi.Redis
+	// write sends commands to the target, delete to the source, audited.
+	write  func(cmds [][]string) ([]*redis.Reply, error)
+	delete func(cmds [][]string) ([]*redis.Reply, error)
+	move   bool
+	// dump is cleared once the target refuses a DUMP payload.
+	dump   bool
+	result *TransferResult
+}
+
+// copy copies a batch of keys, then deletes the copied keys from the
+// source when moving.
+func (t *transfer) copy(keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	rewrite := keys
+	var copied []string
+	if t.dump {
+		var err error
+		if copied, rewrite, err = t.restore(keys); err != nil {
+			return err
+		}
+	}
+	if len(rewrite) > 0 {
+		values, err := ReadKeys(t.src, rewrite)
+		if err != nil {
+			return err
+		}
+		t.result.Skipped += len(rewrite) - len(values)
+		written, err := rewriteKeys(t.write, values, t.result)
+		if err != nil {
+			return err
+		}
+		copied = append(copied, written...)
+	}
+	if !t.move || len(copied) == 0 {
+		return nil
+	}
+	cmds := make([][]string, 0, len(copied))
+	for _, key := range copied {
+		cmds = append(cmds, []string{"DEL", key})
+	}
+	replies, err := t.delete(cmds)
+	if err != nil {
+		return err
+	}
+	for i, r := range replies {
+		if err := r.Err(); err != nil {
+			t.result.addError(copied[i], err)
+		}
+	}
+	return nil
+}
+
+// restore copies keys with DUMP and RESTORE. It returns the keys copied
+// and the keys to rewrite instead.
+func (t *transfer) restore(keys []string) (copied, rewrite []string, err error) {
+	cmds := make([][]string, 0, len(keys)*2)
+	for _, key := range keys {
+		cmds = append(cmds, []string{"DUMP", key}, []string{"PTTL", key})
+	}
+	replies, err := t.src.Pipeline(cmds)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	restored := make([]string, 0, len(keys))
+	cmds = cmds[:0]
+	for i, key := range keys {
+		dump, pttl := replies[i*2], replies[i*2+1]
+		if dump.Err() != nil {
+			// DUMP may be disabled or renamed.
+			rewrite = append(rewrite, key)
+			continue
+		}
+		if dump.IsNil() {
+			t.result.Skipped++
+			continue
+		}
+		var expire int64
+		if ttl, err := pttl.Int(); err == nil && ttl >= 0 {
+			expire = now + int64(max(ttl, 1))
+		}
+		restored = append(restored, key)
+		cmds = append(cmds, []string{"RESTORE", key, strconv.FormatInt(expire, 10), string(dump.Byte()), "REPLACE", "ABSTTL"})
+	}
+	if len(cmds) == 0 {
+		return nil, rewrite, nil
+	}
+	replies, err = t.write(cmds)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, r := range replies {
+		key := restored[i]
+		err := r.Err()
+		switch {
+		case err == nil:
+			t.result.Restored++
+			copied = append(copied, key)
+		case isPayloadError(err):
+			// Different RDB versions, or ABSTTL unknown to the target.
+			t.dump = false
+			rewrite = append(rewrite, key)
+		default:
+			t.result.addError(key, err)
+		}
+	}
+	return copied, rewrite, nil
+}
+
+func isPayloadError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "payload version") || strings.Contains(msg, "syntax error")
+}
+
+// rewriteKeys writes keys with write from their type and value, replacing
+// the keys already there. It returns the keys written without error.
+func rewriteKeys(write func(cmds [][]string) ([]*redis.Reply, error), values []KeyValue, result *TransferResult) ([]string, error) {
+	var cmds [][]string
+	var owners []string
+	for i := range values {
+		kv := &values[i]
+		cmds = append(cmds, []string{"DEL", kv.Key})
+		owners = append(owners, kv.Key)
+		for _, cmd := range kv.Commands() {
+			cmds = append(cmds, cmd)
+			owners = append(owners, kv.Key)
+		}
+	}
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+	replies, err := write(cmds)
+	if err != nil {
+		return nil, err
+	}
+	failed := make(map[string]bool)
+	for i, r := range replies {
+		if err := r.Err(); err != nil && !failed[owners[i]] {
+			failed[owners[i]] = true
+			result.addError(owners[i], fmt.Errorf("%v: %w", cmds[i][0], err))
+		}
+	}
+	written := make([]string, 0, len(values))
+	for _, kv := range values {
+		if !failed[kv.Key] {
+			written = append(written, kv.Key)
+		}
+	}
+	result.Copied += len(written)
+	return written, nil
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTransferTarget(t *testing.T) {
+	d := NewData("127.0.0.1:0", "")
+	if _, err := d.Transfer(context.Background(), d, TransferOptions{Index: 1, TargetIndex: 1}, nil); err != ErrSameTarget {
+		t.Errorf("same database: %v", err)
+	}
+	f := NewRDBData("dump.rdb")
+	if _, err := d.Transfer(context.Background(), f, TransferOptions{}, nil); err != ErrReadOnly {
+		t.Errorf("to RDB file: %v", err)
+	}
+	if _, err := f.Transfer(context.Background(), d, TransferOptions{Move: true}, nil); err != ErrReadOnly {
+		t.Errorf("move from RDB file: %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/liwnn/redisterm/redis"
+)
+
+// The undo stack of a connection keeps at most maxUndoEntries changes and
+// maxUndoBytes of DUMP payloads, dropping the oldest ones. A value whose
+// payload is above maxUndoValue is not kept, its change can't be undone,
+// nor dumped when MEMORY USAGE already tells it is above.
+//
+// Delete, Rename and SetValue capture the keys they change with DUMP and
+// PTTL and can be undone with RESTORE. Changes of many keys, FlushDB,
+// DeleteNamespace, Bulk, Import and Transfer, are recorded without their
+// keys and can't be undone.
+const (
+	maxUndoEntries = 100
+	maxUndoBytes   = 16 << 20
+	maxUndoValue   = 4 << 20
+)
+
+// ErrNothingToUndo is returned by Undo when no change was recorded.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// UndoEntry is a change of keys recorded in the undo stack.
+type UndoEntry struct {
+	Time  time.Time
+	Index int
+	// Op describes the change, such as `delete "user:1"`.
+	Op string
+	// Reason tells why the change can't be undone, empty when it can.
+	Reason string
+
+	// key is restored from payload, or deleted when payload is nil as it
+	// did not exist.
+	key     string
+	payload []byte
+	// expireAt is the expire time of key in unix milliseconds, 0 for none.
+	expireAt int64
+	// from is the key renamed to key, renamed back before restoring key.
+	from string
+}
+
+// Undoable tells if the change can be undone.
+func (e *UndoEntry) Undoable() bool {
+	return e.Reason == ""
+}
+
+func (e *UndoEntry) String() string {
+	s := fmt.Sprintf("%v db%v %v", e.Time.Format("15:04:05"), e.Index, e.Op)
+	if !e.Undoable() {
+		s += " (can't undo: " + e.Reason + ")"
+	}
+	return s
+}
+
+// undoStack is the bounded undo stack of a connection.
+type undoStack struct {
+	mu      sync.Mutex
+	entries []*UndoEntry
+	size    int
+}
+
+func (s *undoStack) push(e *UndoEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, e)
+	s.size += len(e.payload)
+	for len(s.entries) > maxUndoEntries || s.size > maxUndoBytes {
+		s.size -= len(s.entries[0].payload)
+		s.entries[0] = nil
+		s.entries = s.entries[1:]
+	}
+}
+
+func (s *undoStack) pop() *UndoEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.entries) == 0 {
+		return nil
+	}
+	e := s.entries[len(s.entries)-1]
+	s.entries = s.entries[:len(s.entries)-1]
+	s.size -= len(e.payload)
+	return e
+}
+
+func (s *undoStack) list() []*UndoEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*UndoEntry(nil), s.entries...)
+}
+
+// UndoHistory returns the recorded changes, the oldest first.
+func (d *Data) UndoHistory() []*UndoEntry {
+	return d.undo.list()
+}
+
+// CanUndo tells if the last recorded change can be undone.
+func (d *Data) CanUndo() bool {
+	entries := d.undo.list()
+	return len(entries) > 0 && entries[len(entries)-1].Undoable()
+}
+
+// Undo undoes the last recorded change on a dedicated connection and
+// returns it. A change that can't be undone is dropped with an error.
+func (d *Data) Undo() (*UndoEntry, error) {
+	if err := d.CheckWrite(); err != nil {
+		return nil, err
+	}
+	e := d.undo.pop()
+	if e == nil {
+		return nil, ErrNothingToUndo
+	}
+	if !e.Undoable() {
+		return e, fmt.Errorf("%v can't be undone: %v", e.Op, e.Reason)
+	}
+	client, err := d.dialDB(e.Index)
+	if err != nil {
+		d.undo.push(e)
+		return nil, err
+	}
+	defer client.Close()
+
+	var cmds [][]string
+	if e.from != "" {
+		cmds = append(cmds, []string{"RENAME", e.key, e.from})
+	}
+	if e.payload == nil {
+		if e.from == "" {
+			cmds = append(cmds, []string{"DEL", e.key})
+		}
+	} else {
+		cmds = append(cmds, []string{"RESTORE", e.key, strconv.FormatInt(e.expireAt, 10), string(e.payload), "REPLACE", "ABSTTL"})
+	}
+	replies, err := d.writePipeline(client, e.Index, cmds)
+	if err != nil {
+		d.undo.push(e)
+		return nil, err
+	}
+	for i, r := range replies {
+		if err := r.Err(); err != nil {
+			return e, fmt.Errorf("undo %v: %v: %w", e.Op, cmds[i][0], err)
+		}
+	}
+	return e, nil
+}
+
+// recordChange records a change of many keys, which can't be undone.
+func (d *Data) recordChange(index int, op, reason string) {
+	d.undo.push(&UndoEntry{Time: time.Now(), Index: index, Op: op, Reason: reason})
+}
+
+// capture returns the entry restoring key of the current database as it
+// is now, with Reason set when its value can't be kept.
+func (d *Data) capture(op, key string) *UndoEntry {
+	e := &UndoEntry{Time: time.Now(), Index: d.index, Op: op, key: key}
+	// MEMORY USAGE, estimated from a sample of the elements, tells the
+	// large values not to DUMP. The payload is checked too, exactly.
+	if r, err := d.redis.Do("MEMORY", "USAGE", key); err == nil {
+		if size, err := r.Int(); err == nil && size > maxUndoValue {
+			e.Reason = tooLarge(size)
+			return e
+		}
+	}
+	replies, err := d.redis.Pipeline([][]string{{"DUMP", key}, {"PTTL", key}})
+	if err != nil {
+		e.Reason = err.Error()
+		return e
+	}
+	e.Reason = setPayload(e, replies[0], replies[1])
+	return e
+}
+
+// setPayload sets the payload and expire time of e from the replies of
+// DUMP and PTTL, and returns why they can't be kept.
+func setPayload(e *UndoEntry, dump, pttl *redis.Reply) string {
+	if err := dump.Err(); err != nil {
+		return "DUMP failed: " + err.Error()
+	}
+	if dump.IsNil() {
+		return ""
+	}
+	payload := dump.Byte()
+	if len(payload) > maxUndoValue {
+		return tooLarge(len(payload))
+	}
+	e.payload = payload
+	if ttl, err := pttl.Int(); err == nil && ttl >= 0 {
+		e.expireAt = e.Time.UnixMilli() + int64(max(ttl, 1))
+	}
+	return ""
+}
+
+func tooLarge(size int) string {
+	return fmt.Sprintf("value of %v is larger than %v", FormatBytes(int64(size)), FormatBytes(maxUndoValue))
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/liwnn/redisterm/redis"
+)
+
+func reply(t redis.Type, val string) *redis.Reply {
+	return redis.NewReply(redis.NewObject(t, []byte(val)))
+}
+
+func TestUndoStack(t *testing.T) {
+	var s undoStack
+	for i := 0; i < maxUndoEntries+5; i++ {
+		s.push(&UndoEntry{Index: i})
+	}
+	if entries := s.list(); len(entries) != maxUndoEntries || entries[0].Index != 5 {
+		t.Errorf("entries %v, first %v", len(entries), entries[0].Index)
+	}
+
+	big := make([]byte, maxUndoBytes/2+1)
+	s.push(&UndoEntry{Index: -1, payload: big})
+	s.push(&UndoEntry{Index: -2, payload: big})
+	if e := s.pop(); e.Index != -2 || s.size != 0 {
+		t.Errorf("pop %v, size %v", e.Index, s.size)
+	}
+	if e := s.pop(); e != nil {
+		t.Errorf("entry %v kept above maxUndoBytes", e.Index)
+	}
+}
+
+func TestSetPayload(t *testing.T) {
+	now := time.UnixMilli(1000)
+	e := &UndoEntry{Time: now}
+	if reason := setPayload(e, reply(redis.BulkStr, "payload"), reply(redis.Int, "500")); reason != "" || string(e.payload) != "payload" || e.expireAt != 1500 {
+		t.Errorf("reason %q payload %q expire %v", reason, e.payload, e.expireAt)
+	}
+	e = &UndoEntry{Time: now}
+	if reason := setPayload(e, redis.NewReply(redis.NewObject(redis.Nil, nil)), reply(redis.Int, "-2")); reason != "" || e.payload != nil {
+		t.Errorf("missing key: reason %q payload %q", reason, e.payload)
+	}
+	e = &UndoEntry{Time: now}
+	if reason := setPayload(e, reply(redis.BulkStr, "x"), reply(redis.Int, "-1")); e.expireAt != 0 || reason != "" {
+		t.Errorf("persistent key expire %v", e.expireAt)
+	}
+	if reason := setPayload(&UndoEntry{}, reply(redis.Err, "ERR unknown command 'DUMP'"), reply(redis.Int, "-1")); !strings.HasPrefix(reason, "DUMP failed") {
+		t.Errorf("DUMP error reason %q", reason)
+	}
+	big := strings.Repeat("x", maxUndoValue+1)
+	if reason := setPayload(&UndoEntry{}, reply(redis.BulkStr, big), reply(redis.Int, "-1")); !strings.Contains(reason, "larger") {
+		t.Errorf("large value reason %q", reason)
+	}
+}
+
+func TestCaptureLargeValue(t *testing.T) {
+	s, addr := newFakeServer(t, func(args []string) string {
+		switch strings.ToUpper(args[0]) {
+		case "MEMORY":
+			return ":" + strconv.Itoa(maxUndoValue+1) + "\r\n"
+		case "DUMP":
+			return "$1\r\nx\r\n"
+		case "PTTL":
+			return ":-1\r\n"
+		}
+		return "-ERR unknown\r\n"
+	})
+	d := NewData(addr, "")
+	if err := d.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if e := d.capture("delete big", "big"); !strings.Contains(e.Reason, "larger") || e.payload != nil {
+		t.Errorf("reason %q payload %q", e.Reason, e.payload)
+	}
+	if dumps := s.sent("DUMP"); len(dumps) != 0 {
+		t.Errorf("%v sent for a value above maxUndoValue", dumps)
+	}
+}
@@ -1,5 +1,13 @@
 package model
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
 // EncodeToHexString encode to hex string
 func EncodeToHexString(src []byte) string {
 	const hextable = "0123456789ABCDEF"
@@ -37,3 +45,116 @@ func IsText(b []byte) bool {
 	isBin := count*100 >= len(b)*30
 	return !isBin
 }
+
+// FormatKey returns a printable form of a raw redis key. Backslashes and
+// bytes that are not printable text are written as escape sequences
+// (\\, \n, \r, \t, \xHH) so that ParseKey(FormatKey(k)) == k.
+func FormatKey(key string) string {
+	const hextable = "0123456789abcdef"
+	var b strings.Builder
+	for i := 0; i < len(key); {
+		c := key[i]
+		if c >= 0x80 {
+			r, size := utf8.DecodeRuneInString(key[i:])
+			if r != utf8.RuneError && unicode.IsPrint(r) {
+				b.WriteString(key[i : i+size])
+				i += size
+				continue
+			}
+		}
+		switch {
+		case c == '\\':
+			b.WriteString(`\\`)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c >= 32 && c <= 126:
+			b.WriteByte(c)
+		default:
+			b.WriteString(`\x`)
+			b.WriteByte(hextable[c>>4])
+			b.WriteByte(hextable[c&0x0f])
+		}
+		i++
+	}
+	return b.String()
+}
+
+// ParseKey converts text typed by the user back to the raw key bytes,
+// resolving the escape sequences produced by FormatKey.
+func ParseKey(text string) (string, error) {
+	if strings.IndexByte(text, '\\') == -1 {
+		return text, nil
+	}
+	b := make([]byte, 0, len(text))
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c != '\\' {
+			b = append(b, c)
+			continue
+		}
+		if i+1 >= len(text) {
+			return "", fmt.Errorf("key %q: trailing backslash", text)
+		}
+		i++
+		switch text[i] {
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case 'b':
+			b = append(b, '\b')
+		case 'a':
+			b = append(b, '\a')
+		case 'x':
+			if i+2 >= len(text) {
+				return "", fmt.Errorf("key %q: invalid \\x escape", text)
+			}
+			v, err := strconv.ParseUint(text[i+1:i+3], 16, 8)
+			if err != nil {
+				return "", fmt.Errorf("key %q: invalid \\x escape", text)
+			}
+			b = append(b, byte(v))
+			i += 2
+		default:
+			b = append(b, text[i])
+		}
+	}
+	return string(b), nil
+}
+
+// escapePattern escapes the glob metacharacters of a key so that it can be
+// used as a literal prefix in a MATCH pattern.
+func escapePattern(key string) string {
+	if strings.IndexAny(key, `*?[]\`) == -1 {
+		return key
+	}
+	b := make([]byte, 0, len(key)+4)
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case '*', '?', '[', ']', '\\':
+			b = append(b, '\\')
+		}
+		b = append(b, key[i])
+	}
+	return string(b)
+}
+
+// FormatBytes returns a human readable size such as 1.5K or 20.0M.
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatInt(n, 10) + "B"
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit && exp < 4; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(n)/float64(div), "KMGTP"[exp])
+}
@@ -0,0 +1,58 @@
+package model
+
+import "testing"
+
+func TestFormatKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		text string
+	}{
+		{"user:1", "user:1"},
+		{"a b", "a b"},
+		{"line\nbreak", `line\nbreak`},
+		{"back\\slash", `back\\slash`},
+		{"\x00\x01\xff", `\x00\x01\xff`},
+		{"键:值", "键:值"},
+	}
+	for _, tt := range tests {
+		if got := FormatKey(tt.key); got != tt.text {
+			t.Errorf("FormatKey(%q) = %q, want %q", tt.key, got, tt.text)
+		}
+		key, err := ParseKey(tt.text)
+		if err != nil {
+			t.Errorf("ParseKey(%q) error %v", tt.text, err)
+		}
+		if key != tt.key {
+			t.Errorf("ParseKey(%q) = %q, want %q", tt.text, key, tt.key)
+		}
+	}
+}
+
+func TestParseKeyInvalid(t *testing.T) {
+	for _, text := range []string{`a\`, `\x`, `\x4`, `\xzz`} {
+		if _, err := ParseKey(text); err == nil {
+			t.Errorf("ParseKey(%q) expected error", text)
+		}
+	}
+}
+
+func TestEscapePattern(t *testing.T) {
+	if got := escapePattern(`a*[b]?\`); got != `a\*\[b\]\?\\` {
+		t.Errorf("escapePattern = %q", got)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:                "0B",
+		1023:             "1023B",
+		1536:             "1.5K",
+		20 * 1024 * 1024: "20.0M",
+		3 << 40:          "3.0T",
+	}
+	for n, want := range tests {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
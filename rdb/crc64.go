@@ -0,0 +1,26 @@
+package rdb
+
+// crcTable is the table of the Jones CRC-64 used by Redis, reflected,
+// with no initial or final xor, so hash/crc64 cannot be used.
+var crcTable = func() (t [256]uint64) {
+	const poly = 0x95ac9329ac4bc9b5
+	for i := range t {
+		crc := uint64(i)
+		for j := 0; j < 8; j++ {
+			if crc&1 == 1 {
+				crc = crc>>1 ^ poly
+			} else {
+				crc >>= 1
+			}
+		}
+		t[i] = crc
+	}
+	return
+}()
+
+func crc64(crc uint64, b []byte) uint64 {
+	for _, c := range b {
+		crc = crcTable[byte(crc)^c] ^ crc>>8
+	}
+	return crc
+}
@@ -0,0 +1,256 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// Special string encodings, stored in the low bits of a length byte
+// whose two high bits are set.
+const (
+	encInt8  = 0
+	encInt16 = 1
+	encInt32 = 2
+	encLZF   = 3
+)
+
+func le32(b []byte) uint32 { return binary.LittleEndian.Uint32(b) }
+func le64(b []byte) uint64 { return binary.LittleEndian.Uint64(b) }
+
+func (d *Decoder) read(b []byte) error {
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	d.crc = crc64(d.crc, b)
+	return nil
+}
+
+// readChunk is the most readN allocates ahead of the bytes read, so a
+// corrupt length fails at the end of the file instead of allocating it.
+const readChunk = 1 << 20
+
+func (d *Decoder) readN(n int) ([]byte, error) {
+	b := make([]byte, 0, min(n, readChunk))
+	for len(b) < n {
+		chunk := min(n-len(b), readChunk)
+		b = slices.Grow(b, chunk)[:len(b)+chunk]
+		if err := d.read(b[len(b)-chunk:]); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func (d *Decoder) readByte() (byte, error) {
+	var b [1]byte
+	if err := d.read(b[:]); err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// readLenEnc reads a length. special reports that the value is one of the
+// special string encodings instead.
+func (d *Decoder) readLenEnc() (n uint64, special bool, err error) {
+	b, err := d.readByte()
+	if err != nil {
+		return 0, false, err
+	}
+	switch b >> 6 {
+	case 0:
+		return uint64(b & 0x3f), false, nil
+	case 1:
+		next, err := d.readByte()
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(b&0x3f)<<8 | uint64(next), false, nil
+	case 2:
+		switch b {
+		case 0x80:
+			buf, err := d.readN(4)
+			if err != nil {
+				return 0, false, err
+			}
+			return uint64(binary.BigEndian.Uint32(buf)), false, nil
+		case 0x81:
+			buf, err := d.readN(8)
+			if err != nil {
+				return 0, false, err
+			}
+			return binary.BigEndian.Uint64(buf), false, nil
+		}
+		return 0, false, ErrMalformed
+	default:
+		return uint64(b & 0x3f), true, nil
+	}
+}
+
+func (d *Decoder) readLen() (uint64, error) {
+	n, special, err := d.readLenEnc()
+	if err != nil {
+		return 0, err
+	}
+	if special {
+		return 0, ErrMalformed
+	}
+	return n, nil
+}
+
+// readCount reads a length used as an element count, bounding it so a
+// corrupt file cannot make us allocate without limit.
+func (d *Decoder) readCount() (int, error) {
+	n, err := d.readLen()
+	if err != nil {
+		return 0, err
+	}
+	if n > math.MaxInt32 {
+		return 0, ErrMalformed
+	}
+	return int(n), nil
+}
+
+func (d *Decoder) readString() ([]byte, error) {
+	n, special, err := d.readLenEnc()
+	if err != nil {
+		return nil, err
+	}
+	if !special {
+		if n > math.MaxInt32 {
+			return nil, ErrMalformed
+		}
+		return d.readN(int(n))
+	}
+	switch n {
+	case encInt8:
+		b, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(nil, int64(int8(b)), 10), nil
+	case encInt16:
+		b, err := d.readN(2)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(nil, int64(int16(binary.LittleEndian.Uint16(b))), 10), nil
+	case encInt32:
+		b, err := d.readN(4)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(nil, int64(int32(le32(b))), 10), nil
+	case encLZF:
+		clen, err := d.readLen()
+		if err != nil {
+			return nil, err
+		}
+		ulen, err := d.readLen()
+		if err != nil {
+			return nil, err
+		}
+		if clen > math.MaxInt32 || ulen > math.MaxInt32 {
+			return nil, ErrMalformed
+		}
+		in, err := d.readN(int(clen))
+		if err != nil {
+			return nil, err
+		}
+		return lzfDecompress(in, int(ulen))
+	}
+	return nil, ErrMalformed
+}
+
+func (d *Decoder) readMillis() (int64, error) {
+	b, err := d.readN(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(le64(b)), nil
+}
+
+// readDouble reads a score of the old sorted set encoding, a length
+// prefixed decimal string.
+func (d *Decoder) readDouble() (float64, error) {
+	n, err := d.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 253:
+		return math.NaN(), nil
+	case 254:
+		return math.Inf(1), nil
+	case 255:
+		return math.Inf(-1), nil
+	}
+	b, err := d.readN(int(n))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(string(b), 64)
+}
+
+func (d *Decoder) readBinaryDouble() (float64, error) {
+	b, err := d.readN(8)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(le64(b)), nil
+}
+
+// lzfMaxRatio bounds the expansion of LZF: a back reference of 3 bytes
+// copies at most 264 bytes.
+const lzfMaxRatio = 88
+
+// lzfDecompress decompresses LZF data into a buffer of size n.
+func lzfDecompress(in []byte, n int) ([]byte, error) {
+	if n > len(in)*lzfMaxRatio {
+		return nil, ErrMalformed
+	}
+	out := make([]byte, 0, n)
+	for i := 0; i < len(in); {
+		ctrl := int(in[i])
+		i++
+		if ctrl < 32 {
+			run := ctrl + 1
+			if i+run > len(in) || len(out)+run > n {
+				return nil, ErrMalformed
+			}
+			out = append(out, in[i:i+run]...)
+			i += run
+			continue
+		}
+		length := ctrl >> 5
+		if length == 7 {
+			if i >= len(in) {
+				return nil, ErrMalformed
+			}
+			length += int(in[i])
+			i++
+		}
+		if i >= len(in) {
+			return nil, ErrMalformed
+		}
+		ref := len(out) - (ctrl&0x1f)<<8 - int(in[i]) - 1
+		i++
+		length += 2
+		if ref < 0 || len(out)+length > n {
+			return nil, ErrMalformed
+		}
+		// Copy byte by byte, the reference may overlap the output.
+		for j := 0; j < length; j++ {
+			out = append(out, out[ref+j])
+		}
+	}
+	if len(out) != n {
+		return nil, ErrMalformed
+	}
+	return out, nil
+}
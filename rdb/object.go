@@ -0,0 +1,370 @@
+package rdb
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Module value opcodes.
+const (
+	moduleOpEOF    = 0
+	moduleOpSInt   = 1
+	moduleOpUInt   = 2
+	moduleOpFloat  = 3
+	moduleOpDouble = 4
+	moduleOpString = 5
+)
+
+// hashNoTTL marks a field without expiration in listpack hashes.
+const hashNoTTL = 0
+
+func (d *Decoder) readObject(typ byte, e *Entry) error {
+	var err error
+	switch typ {
+	case typeString:
+		e.Type = "string"
+		e.Value, err = d.readString()
+	case typeList, typeSet:
+		e.Type = "list"
+		if typ == typeSet {
+			e.Type = "set"
+		}
+		e.Value, err = d.readStrings()
+	case typeSetListpack:
+		e.Type = "set"
+		e.Value, err = d.readEncoded(parseListpack)
+	case typeSetIntset:
+		e.Type = "set"
+		e.Value, err = d.readEncoded(parseIntset)
+	case typeListZiplist:
+		e.Type = "list"
+		e.Value, err = d.readEncoded(parseZiplist)
+	case typeListQuicklist:
+		e.Type = "list"
+		e.Value, err = d.readQuicklist()
+	case typeListQuicklist2:
+		e.Type = "list"
+		e.Value, err = d.readQuicklist2()
+	case typeZSet, typeZSet2:
+		e.Type = "zset"
+		e.Value, err = d.readZSet(typ == typeZSet2)
+	case typeZSetZiplist, typeZSetListpack:
+		e.Type = "zset"
+		e.Value, err = d.readZSetPacked(typ)
+	case typeHash:
+		e.Type = "hash"
+		e.Value, err = d.readHash()
+	case typeHashZipmap:
+		e.Type = "hash"
+		var b []byte
+		if b, err = d.readString(); err == nil {
+			e.Value, err = parseZipmap(b)
+		}
+	case typeHashZiplist, typeHashListpack:
+		e.Type = "hash"
+		e.Value, err = d.readHashPacked(typ)
+	case typeHashMetadata, typeHashMetadataPreGA:
+		e.Type = "hash"
+		e.Value, err = d.readHashMetadata(typ == typeHashMetadataPreGA)
+	case typeHashListpackEx, typeHashListpackExPreGA:
+		e.Type = "hash"
+		e.Value, err = d.readHashListpackEx(typ == typeHashListpackExPreGA)
+	case typeStreamListpacks, typeStreamListpacks2, typeStreamListpacks3:
+		e.Type = "stream"
+		e.Value, err = d.readStream(typ)
+	case typeModule2:
+		e.Type = "module"
+		e.Value, err = d.readModule()
+	case typeModulePreGA:
+		return errors.New("pre-release module format is not supported")
+	default:
+		return fmt.Errorf("unknown object type %d", typ)
+	}
+	return err
+}
+
+func (d *Decoder) readStrings() ([]string, error) {
+	n, err := d.readCount()
+	if err != nil {
+		return nil, err
+	}
+	elems := make([]string, 0, min(n, 1024))
+	for i := 0; i < n; i++ {
+		b, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, string(b))
+	}
+	return elems, nil
+}
+
+// readEncoded reads a string holding an encoded blob and parses it.
+func (d *Decoder) readEncoded(parse func([]byte) ([]string, error)) ([]string, error) {
+	b, err := d.readString()
+	if err != nil {
+		return nil, err
+	}
+	return parse(b)
+}
+
+func (d *Decoder) readQuicklist() ([]string, error) {
+	n, err := d.readCount()
+	if err != nil {
+		return nil, err
+	}
+	var elems []string
+	for i := 0; i < n; i++ {
+		entries, err := d.readEncoded(parseZiplist)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, entries...)
+	}
+	return elems, nil
+}
+
+// Quicklist node containers.
+const (
+	quicklistPlain  = 1
+	quicklistPacked = 2
+)
+
+func (d *Decoder) readQuicklist2() ([]string, error) {
+	n, err := d.readCount()
+	if err != nil {
+		return nil, err
+	}
+	var elems []string
+	for i := 0; i < n; i++ {
+		container, err := d.readLen()
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		switch container {
+		case quicklistPlain:
+			elems = append(elems, string(b))
+		case quicklistPacked:
+			entries, err := parseListpack(b)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, entries...)
+		default:
+			return nil, ErrMalformed
+		}
+	}
+	return elems, nil
+}
+
+func (d *Decoder) readZSet(binary bool) ([]Member, error) {
+	n, err := d.readCount()
+	if err != nil {
+		return nil, err
+	}
+	members := make([]Member, 0, min(n, 1024))
+	for i := 0; i < n; i++ {
+		member, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		var score float64
+		if binary {
+			score, err = d.readBinaryDouble()
+		} else {
+			score, err = d.readDouble()
+		}
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, Member{Member: string(member), Score: score})
+	}
+	return members, nil
+}
+
+func (d *Decoder) readZSetPacked(typ byte) ([]Member, error) {
+	parse := parseListpack
+	if typ == typeZSetZiplist {
+		parse = parseZiplist
+	}
+	entries, err := d.readEncoded(parse)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries)%2 != 0 {
+		return nil, ErrMalformed
+	}
+	members := make([]Member, 0, len(entries)/2)
+	for i := 0; i < len(entries); i += 2 {
+		score, err := strconv.ParseFloat(entries[i+1], 64)
+		if err != nil {
+			return nil, ErrMalformed
+		}
+		members = append(members, Member{Member: entries[i], Score: score})
+	}
+	return members, nil
+}
+
+func (d *Decoder) readHash() ([]Field, error) {
+	n, err := d.readCount()
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]Field, 0, min(n, 1024))
+	for i := 0; i < n; i++ {
+		field, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, Field{Field: string(field), Value: string(value)})
+	}
+	return fields, nil
+}
+
+func (d *Decoder) readHashPacked(typ byte) ([]Field, error) {
+	parse := parseListpack
+	if typ == typeHashZiplist {
+		parse = parseZiplist
+	}
+	entries, err := d.readEncoded(parse)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries)%2 != 0 {
+		return nil, ErrMalformed
+	}
+	fields := make([]Field, 0, len(entries)/2)
+	for i := 0; i < len(entries); i += 2 {
+		fields = append(fields, Field{Field: entries[i], Value: entries[i+1]})
+	}
+	return fields, nil
+}
+
+// readHashMetadata reads a hash with field expiration. Expirations are
+// stored relative to the minimum one, or absolute before GA.
+func (d *Decoder) readHashMetadata(preGA bool) ([]Field, error) {
+	var minExpire int64
+	if !preGA {
+		var err error
+		if minExpire, err = d.readMillis(); err != nil {
+			return nil, err
+		}
+	}
+	n, err := d.readCount()
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]Field, 0, min(n, 1024))
+	for i := 0; i < n; i++ {
+		ttl, err := d.readLen()
+		if err != nil {
+			return nil, err
+		}
+		field, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		f := Field{Field: string(field), Value: string(value)}
+		switch {
+		case preGA:
+			f.Expire = int64(ttl)
+		case ttl != 0:
+			f.Expire = int64(ttl) + minExpire - 1
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// readHashListpackEx reads a listpack hash with field expiration, stored
+// as field, value, expire triples.
+func (d *Decoder) readHashListpackEx(preGA bool) ([]Field, error) {
+	if !preGA {
+		if _, err := d.readMillis(); err != nil {
+			return nil, err
+		}
+	}
+	entries, err := d.readEncoded(parseListpack)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries)%3 != 0 {
+		return nil, ErrMalformed
+	}
+	fields := make([]Field, 0, len(entries)/3)
+	for i := 0; i < len(entries); i += 3 {
+		expire, err := strconv.ParseInt(entries[i+2], 10, 64)
+		if err != nil {
+			return nil, ErrMalformed
+		}
+		if expire == hashNoTTL {
+			expire = 0
+		}
+		fields = append(fields, Field{Field: entries[i], Value: entries[i+1], Expire: expire})
+	}
+	return fields, nil
+}
+
+const moduleCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+// moduleFromID decodes a module type id: nine 6 bit characters of name
+// followed by a 10 bit encoding version.
+func moduleFromID(id uint64) Module {
+	name := make([]byte, 9)
+	for i := 0; i < 9; i++ {
+		name[i] = moduleCharset[(id>>(10+6*(8-i)))&0x3f]
+	}
+	return Module{Name: string(name), Version: int(id & 0x3ff)}
+}
+
+func (d *Decoder) readModule() (Module, error) {
+	id, err := d.readLen()
+	if err != nil {
+		return Module{}, err
+	}
+	if err := d.skipModuleValue(); err != nil {
+		return Module{}, err
+	}
+	return moduleFromID(id), nil
+}
+
+// skipModuleValue skips a module value. Since version 2 module values
+// are self describing, each item prefixed with its opcode.
+func (d *Decoder) skipModuleValue() error {
+	for {
+		op, err := d.readLen()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case moduleOpEOF:
+			return nil
+		case moduleOpSInt, moduleOpUInt:
+			_, err = d.readLen()
+		case moduleOpFloat:
+			_, err = d.readN(4)
+		case moduleOpDouble:
+			_, err = d.readN(8)
+		case moduleOpString:
+			_, err = d.readString()
+		default:
+			return ErrMalformed
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
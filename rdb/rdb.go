@@ -0,0 +1,327 @@
+// Package rdb reads Redis RDB snapshot files, versions 6 to 12.
+package rdb
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Supported RDB versions.
+const (
+	MinVersion = 6
+	MaxVersion = 12
+)
+
+// Opcodes.
+const (
+	opSlotInfo      = 244
+	opFunctionPreGA = 245
+	opFunction2     = 246
+	opModuleAux     = 247
+	opIdle          = 248
+	opFreq          = 249
+	opAux           = 250
+	opResizeDB      = 251
+	opExpireTimeMS  = 252
+	opExpireTime    = 253
+	opSelectDB      = 254
+	opEOF           = 255
+)
+
+// Object types.
+const (
+	typeString              = 0
+	typeList                = 1
+	typeSet                 = 2
+	typeZSet                = 3
+	typeHash                = 4
+	typeZSet2               = 5
+	typeModulePreGA         = 6
+	typeModule2             = 7
+	typeHashZipmap          = 9
+	typeListZiplist         = 10
+	typeSetIntset           = 11
+	typeZSetZiplist         = 12
+	typeHashZiplist         = 13
+	typeListQuicklist       = 14
+	typeStreamListpacks     = 15
+	typeHashListpack        = 16
+	typeZSetListpack        = 17
+	typeListQuicklist2      = 18
+	typeStreamListpacks2    = 19
+	typeSetListpack         = 20
+	typeStreamListpacks3    = 21
+	typeHashMetadataPreGA   = 22
+	typeHashListpackExPreGA = 23
+	typeHashMetadata        = 24
+	typeHashListpackEx      = 25
+)
+
+var (
+	ErrNotRDB    = errors.New("rdb: not an RDB file")
+	ErrChecksum  = errors.New("rdb: checksum mismatch")
+	ErrMalformed = errors.New("rdb: malformed data")
+)
+
+// Field is a hash field.
+type Field struct {
+	Field  string
+	Value  string
+	Expire int64 // unix time in milliseconds, 0 if the field does not expire
+}
+
+// Member is a sorted set member.
+type Member struct {
+	Member string
+	Score  float64
+}
+
+// Module is the value of a module type, which can only be skipped.
+type Module struct {
+	Name    string
+	Version int
+}
+
+// Entry is a key read from an RDB file. Value is []byte for strings,
+// []string for lists and sets, []Field for hashes, []Member for sorted
+// sets, *Stream for streams and Module for module types.
+type Entry struct {
+	DB     int
+	Key    string
+	Type   string
+	Expire int64 // unix time in milliseconds, 0 if the key does not expire
+	Value  interface{}
+}
+
+// Function is a function library stored in the file.
+type Function struct {
+	Code string
+}
+
+// ModuleAux is auxiliary data stored by a module.
+type ModuleAux struct {
+	Module Module
+	When   int
+}
+
+// Decoder reads entries from an RDB stream.
+type Decoder struct {
+	r       *bufio.Reader
+	crc     uint64
+	version int
+	db      int
+	done    bool
+
+	// Aux, Functions, ModuleAux and DBSize hold the metadata read so far.
+	Aux       map[string]string
+	Functions []Function
+	ModuleAux []ModuleAux
+	DBSize    map[int]int
+}
+
+// NewDecoder reads the header of r and returns a decoder for its entries.
+func NewDecoder(r io.Reader) (*Decoder, error) {
+	d := &Decoder{
+		r:      bufio.NewReaderSize(r, 64*1024),
+		Aux:    make(map[string]string),
+		DBSize: make(map[int]int),
+	}
+	header := make([]byte, 9)
+	if err := d.read(header); err != nil {
+		return nil, ErrNotRDB
+	}
+	if string(header[:5]) != "REDIS" {
+		return nil, ErrNotRDB
+	}
+	version, err := strconv.Atoi(string(header[5:]))
+	if err != nil {
+		return nil, ErrNotRDB
+	}
+	if version < MinVersion || version > MaxVersion {
+		return nil, fmt.Errorf("rdb: unsupported version %d", version)
+	}
+	d.version = version
+	return d, nil
+}
+
+// Version returns the RDB version of the file.
+func (d *Decoder) Version() int {
+	return d.version
+}
+
+// Next returns the next key. It returns io.EOF after the last key, once
+// the checksum is verified.
+func (d *Decoder) Next() (*Entry, error) {
+	if d.done {
+		return nil, io.EOF
+	}
+	var expire int64
+	for {
+		op, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case opEOF:
+			d.done = true
+			if err := d.readChecksum(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		case opSelectDB:
+			db, err := d.readLen()
+			if err != nil {
+				return nil, err
+			}
+			d.db = int(db)
+		case opResizeDB:
+			size, err := d.readLen()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := d.readLen(); err != nil {
+				return nil, err
+			}
+			d.DBSize[d.db] = int(size)
+		case opExpireTime:
+			b, err := d.readN(4)
+			if err != nil {
+				return nil, err
+			}
+			expire = int64(le32(b)) * 1000
+		case opExpireTimeMS:
+			if expire, err = d.readMillis(); err != nil {
+				return nil, err
+			}
+		case opAux:
+			key, err := d.readString()
+			if err != nil {
+				return nil, err
+			}
+			value, err := d.readString()
+			if err != nil {
+				return nil, err
+			}
+			d.Aux[string(key)] = string(value)
+		case opModuleAux:
+			if err := d.readModuleAux(); err != nil {
+				return nil, err
+			}
+		case opFunction2:
+			code, err := d.readString()
+			if err != nil {
+				return nil, err
+			}
+			d.Functions = append(d.Functions, Function{Code: string(code)})
+		case opFunctionPreGA:
+			return nil, errors.New("rdb: pre-release function format is not supported")
+		case opFreq:
+			if _, err := d.readByte(); err != nil {
+				return nil, err
+			}
+		case opIdle:
+			if _, err := d.readLen(); err != nil {
+				return nil, err
+			}
+		case opSlotInfo:
+			for i := 0; i < 3; i++ {
+				if _, err := d.readLen(); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			key, err := d.readString()
+			if err != nil {
+				return nil, err
+			}
+			e := &Entry{
+				DB:     d.db,
+				Key:    string(key),
+				Expire: expire,
+			}
+			if err := d.readObject(op, e); err != nil {
+				return nil, fmt.Errorf("rdb: key %q: %w", key, err)
+			}
+			return e, nil
+		}
+	}
+}
+
+func (d *Decoder) readChecksum() error {
+	sum := d.crc
+	b, err := d.readN(8)
+	if err != nil {
+		return err
+	}
+	// A zero checksum means the server was configured without one.
+	if expected := le64(b); expected != 0 && expected != sum {
+		return ErrChecksum
+	}
+	return nil
+}
+
+func (d *Decoder) readModuleAux() error {
+	id, err := d.readLen()
+	if err != nil {
+		return err
+	}
+	if _, err := d.readLen(); err != nil { // opcode of when
+		return err
+	}
+	when, err := d.readLen()
+	if err != nil {
+		return err
+	}
+	if err := d.skipModuleValue(); err != nil {
+		return err
+	}
+	d.ModuleAux = append(d.ModuleAux, ModuleAux{
+		Module: moduleFromID(id),
+		When:   int(when),
+	})
+	return nil
+}
+
+// File is the content of an RDB file.
+type File struct {
+	Version   int
+	Aux       map[string]string
+	Functions []Function
+	ModuleAux []ModuleAux
+	Entries   []*Entry
+}
+
+// ReadFile reads all entries of the RDB file name.
+func ReadFile(name string) (*File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d, err := NewDecoder(f)
+	if err != nil {
+		return nil, err
+	}
+	file := &File{
+		Version: d.Version(),
+	}
+	for {
+		e, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		file.Entries = append(file.Entries, e)
+	}
+	file.Aux = d.Aux
+	file.Functions = d.Functions
+	file.ModuleAux = d.ModuleAux
+	return file, nil
+}
@@ -0,0 +1,469 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// rdbWriter builds RDB files for the tests.
+type rdbWriter struct {
+	bytes.Buffer
+}
+
+func (w *rdbWriter) len(n int) {
+	switch {
+	case n < 1<<6:
+		w.WriteByte(byte(n))
+	case n < 1<<14:
+		w.WriteByte(byte(0x40 | n>>8))
+		w.WriteByte(byte(n))
+	default:
+		w.WriteByte(0x80)
+		binary.Write(w, binary.BigEndian, uint32(n))
+	}
+}
+
+func (w *rdbWriter) str(s string) {
+	w.len(len(s))
+	w.WriteString(s)
+}
+
+func (w *rdbWriter) millis(ms int64) {
+	binary.Write(w, binary.LittleEndian, ms)
+}
+
+func (w *rdbWriter) key(typ byte, key string) {
+	w.WriteByte(typ)
+	w.str(key)
+}
+
+// finish appends the EOF opcode and the checksum.
+func (w *rdbWriter) finish() []byte {
+	w.WriteByte(opEOF)
+	binary.Write(w, binary.LittleEndian, crc64(0, w.Bytes()))
+	return w.Bytes()
+}
+
+func newWriter(version string) *rdbWriter {
+	w := &rdbWriter{}
+	w.WriteString("REDIS" + version)
+	return w
+}
+
+// lp builds a listpack from raw encoded entries.
+func lp(entries ...[]byte) string {
+	var b bytes.Buffer
+	b.Write(make([]byte, 4))
+	binary.Write(&b, binary.LittleEndian, uint16(len(entries)))
+	for _, e := range entries {
+		b.Write(e)
+		b.Write(make([]byte, backlenSize(len(e))))
+	}
+	b.WriteByte(0xff)
+	out := b.Bytes()
+	binary.LittleEndian.PutUint32(out, uint32(len(out)))
+	return string(out)
+}
+
+func lpStr(s string) []byte {
+	if len(s) < 64 {
+		return append([]byte{0x80 | byte(len(s))}, s...)
+	}
+	return append([]byte{0xe0 | byte(len(s)>>8), byte(len(s))}, s...)
+}
+
+func lpInt(n int) []byte {
+	if n >= 0 && n < 128 {
+		return []byte{byte(n)}
+	}
+	b := make([]byte, 9)
+	b[0] = 0xf4
+	binary.LittleEndian.PutUint64(b[1:], uint64(n))
+	return b
+}
+
+func lpStrs(elems ...string) string {
+	entries := make([][]byte, len(elems))
+	for i, e := range elems {
+		entries[i] = lpStr(e)
+	}
+	return lp(entries...)
+}
+
+// zl builds a ziplist from raw encoded entries.
+func zl(entries ...[]byte) string {
+	var b bytes.Buffer
+	b.Write(make([]byte, 8))
+	binary.Write(&b, binary.LittleEndian, uint16(len(entries)))
+	for _, e := range entries {
+		b.WriteByte(0)
+		b.Write(e)
+	}
+	b.WriteByte(0xff)
+	return b.String()
+}
+
+func zlStr(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+func readAll(t *testing.T, data []byte) (*Decoder, []*Entry) {
+	t.Helper()
+	d, err := NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []*Entry
+	for {
+		e, err := d.Next()
+		if err == io.EOF {
+			return d, entries
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+}
+
+func TestCRC64(t *testing.T) {
+	if sum := crc64(0, []byte("123456789")); sum != 0xe9c6d914c4b8d9ca {
+		t.Errorf("crc64 = %x", sum)
+	}
+}
+
+func TestLZF(t *testing.T) {
+	// A literal "a" followed by a back reference copying 9 bytes.
+	out, err := lzfDecompress([]byte{0x00, 'a', 0xe0, 0x00, 0x00}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != strings.Repeat("a", 10) {
+		t.Errorf("lzf = %q", out)
+	}
+	if _, err := lzfDecompress([]byte{0x20, 0x05}, 3); err == nil {
+		t.Error("lzf: expected error for reference before start")
+	}
+}
+
+func TestZiplist(t *testing.T) {
+	s := zl(
+		zlStr("abc"),
+		append([]byte{0x40, 70}, strings.Repeat("x", 70)...),
+		[]byte{0xc0, 0x18, 0xfc}, // int16 -1000
+		[]byte{0xd0, 0xa0, 0x86, 0x01, 0x00},
+		[]byte{0xe0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		[]byte{0xf0, 0x60, 0x79, 0xfe},
+		[]byte{0xfe, 0x80},
+		[]byte{0xf1},
+		[]byte{0xfd},
+	)
+	got, err := parseZiplist([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"abc", strings.Repeat("x", 70), "-1000", "100000", "-1", "-100000", "-128", "0", "12"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ziplist = %q, want %q", got, want)
+	}
+}
+
+func TestListpack(t *testing.T) {
+	s := lp(
+		lpStr("abc"),
+		lpStr(strings.Repeat("y", 200)),
+		[]byte{0x05},
+		[]byte{0xdf, 0xfb}, // 13 bit -5
+		[]byte{0xf1, 0xe8, 0x03},
+		[]byte{0xf2, 0x60, 0x79, 0xfe},
+		[]byte{0xf3, 0xff, 0xff, 0xff, 0x7f},
+		lpInt(-2),
+	)
+	got, err := parseListpack([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"abc", strings.Repeat("y", 200), "5", "-5", "1000", "-100000", "2147483647", "-2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("listpack = %q, want %q", got, want)
+	}
+}
+
+func TestIntset(t *testing.T) {
+	b := []byte{2, 0, 0, 0, 3, 0, 0, 0, 0xff, 0xff, 1, 0, 0x10, 0x27}
+	got, err := parseIntset(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"-1", "1", "10000"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("intset = %q, want %q", got, want)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	w := newWriter("0012")
+	w.WriteByte(opAux)
+	w.str("redis-ver")
+	w.str("7.4.0")
+	w.WriteByte(opFunction2)
+	w.str("#!lua name=lib")
+	w.WriteByte(opModuleAux)
+	w.len(0x3f) // arbitrary module id
+	w.len(moduleOpUInt)
+	w.len(2)
+	w.len(moduleOpString)
+	w.str("aux")
+	w.len(moduleOpEOF)
+
+	w.WriteByte(opSelectDB)
+	w.len(0)
+	w.WriteByte(opResizeDB)
+	w.len(16)
+	w.len(1)
+
+	w.WriteByte(opExpireTimeMS)
+	w.millis(1700000000000)
+	w.key(typeString, "str")
+	w.str("hello")
+
+	// Integer encoded and LZF compressed strings.
+	w.key(typeString, "int")
+	w.Write([]byte{0xc1, 0x39, 0x30})
+	w.key(typeString, "lzf")
+	w.Write([]byte{0xc3, 5, 10, 0x00, 'a', 0xe0, 0x00, 0x00})
+
+	w.key(typeList, "list")
+	w.len(2)
+	w.str("a")
+	w.str("b")
+	w.key(typeListQuicklist2, "ql2")
+	w.len(2)
+	w.len(quicklistPacked)
+	w.str(lpStrs("x", "y"))
+	w.len(quicklistPlain)
+	w.str("big")
+	w.key(typeListQuicklist, "ql")
+	w.len(1)
+	w.str(zl(zlStr("z1"), zlStr("z2")))
+
+	w.key(typeSetIntset, "intset")
+	w.str(string([]byte{2, 0, 0, 0, 1, 0, 0, 0, 7, 0}))
+	w.key(typeSetListpack, "setlp")
+	w.str(lpStrs("m"))
+
+	w.key(typeZSet, "zset")
+	w.len(2)
+	w.str("one")
+	w.str("1.5")
+	w.str("inf")
+	w.WriteByte(254)
+	w.key(typeZSet2, "zset2")
+	w.len(1)
+	w.str("two")
+	binary.Write(w, binary.LittleEndian, math.Float64bits(2.25))
+	w.key(typeZSetListpack, "zsetlp")
+	w.str(lpStrs("m", "3"))
+
+	w.key(typeHashZipmap, "zipmap")
+	w.str(string([]byte{1, 1, 'f', 1, 2, 'v', 0, 0, 0xff}))
+	w.key(typeHashListpack, "hashlp")
+	w.str(lpStrs("f", "v"))
+	w.key(typeHashZiplist, "hashzl")
+	w.str(zl(zlStr("f"), zlStr("v")))
+	w.key(typeHashMetadata, "hashmeta")
+	w.millis(1000)
+	w.len(2)
+	w.len(0)
+	w.str("a")
+	w.str("1")
+	w.len(6)
+	w.str("b")
+	w.str("2")
+	w.key(typeHashListpackEx, "hashlpex")
+	w.millis(1000)
+	w.str(lp(lpStr("a"), lpStr("1"), lpInt(0), lpStr("b"), lpStr("2"), lpInt(5000)))
+
+	w.key(typeModule2, "mod")
+	w.len(0x3f)
+	w.len(moduleOpDouble)
+	w.Write(make([]byte, 8))
+	w.len(moduleOpEOF)
+
+	// A stream with one deleted entry, one with the master fields and one
+	// with its own fields.
+	w.key(typeStreamListpacks3, "stream")
+	w.len(1)
+	master := make([]byte, 16)
+	binary.BigEndian.PutUint64(master, 100)
+	w.str(string(master))
+	w.str(lp(
+		lpInt(2), lpInt(1), lpInt(1), lpStr("f"), lpInt(0),
+		lpInt(streamItemSameFields|streamItemDeleted), lpInt(0), lpInt(0), lpStr("x"), lpInt(3),
+		lpInt(streamItemSameFields), lpInt(1), lpInt(0), lpStr("v1"), lpInt(3),
+		lpInt(0), lpInt(2), lpInt(1), lpInt(1), lpStr("g"), lpStr("v2"), lpInt(5),
+	))
+	w.len(2)
+	w.len(102)
+	w.len(1)
+	w.len(100)
+	w.len(0)
+	w.len(100)
+	w.len(0)
+	w.len(3)
+	w.len(1) // groups
+	w.str("grp")
+	w.len(101)
+	w.len(0)
+	w.len(1)
+	w.len(1) // group pending
+	w.Write(make([]byte, 16))
+	w.millis(0)
+	w.len(1)
+	w.len(1) // consumers
+	w.str("alice")
+	w.millis(5)
+	w.millis(6)
+	w.len(1)
+	w.Write(make([]byte, 16))
+
+	w.WriteByte(opSelectDB)
+	w.len(3)
+	w.WriteByte(opExpireTime)
+	binary.Write(w, binary.LittleEndian, uint32(10))
+	w.key(typeString, "db3")
+	w.str("v")
+
+	d, entries := readAll(t, w.finish())
+	if d.Version() != 12 || d.Aux["redis-ver"] != "7.4.0" {
+		t.Errorf("header: version %v aux %v", d.Version(), d.Aux)
+	}
+	if len(d.Functions) != 1 || len(d.ModuleAux) != 1 || d.ModuleAux[0].When != 2 {
+		t.Errorf("functions %v module aux %v", d.Functions, d.ModuleAux)
+	}
+	if d.DBSize[0] != 16 {
+		t.Errorf("db size %v", d.DBSize)
+	}
+
+	values := make(map[string]*Entry)
+	for _, e := range entries {
+		values[e.Key] = e
+	}
+	tests := []struct {
+		key   string
+		typ   string
+		value interface{}
+	}{
+		{"str", "string", []byte("hello")},
+		{"int", "string", []byte("12345")},
+		{"lzf", "string", []byte(strings.Repeat("a", 10))},
+		{"list", "list", []string{"a", "b"}},
+		{"ql2", "list", []string{"x", "y", "big"}},
+		{"ql", "list", []string{"z1", "z2"}},
+		{"intset", "set", []string{"7"}},
+		{"setlp", "set", []string{"m"}},
+		{"zset", "zset", []Member{{"one", 1.5}, {"inf", math.Inf(1)}}},
+		{"zset2", "zset", []Member{{"two", 2.25}}},
+		{"zsetlp", "zset", []Member{{"m", 3}}},
+		{"zipmap", "hash", []Field{{Field: "f", Value: "v"}}},
+		{"hashlp", "hash", []Field{{Field: "f", Value: "v"}}},
+		{"hashzl", "hash", []Field{{Field: "f", Value: "v"}}},
+		{"hashmeta", "hash", []Field{{Field: "a", Value: "1"}, {Field: "b", Value: "2", Expire: 1005}}},
+		{"hashlpex", "hash", []Field{{Field: "a", Value: "1"}, {Field: "b", Value: "2", Expire: 5000}}},
+		{"mod", "module", moduleFromID(0x3f)},
+		{"db3", "string", []byte("v")},
+	}
+	for _, tt := range tests {
+		e := values[tt.key]
+		if e == nil {
+			t.Errorf("%v: missing", tt.key)
+			continue
+		}
+		if e.Type != tt.typ || !reflect.DeepEqual(e.Value, tt.value) {
+			t.Errorf("%v: got %v %v, want %v %v", tt.key, e.Type, e.Value, tt.typ, tt.value)
+		}
+	}
+	if e := values["str"]; e.Expire != 1700000000000 {
+		t.Errorf("str expire %v", e.Expire)
+	}
+	if e := values["db3"]; e.DB != 3 || e.Expire != 10000 {
+		t.Errorf("db3: db %v expire %v", e.DB, e.Expire)
+	}
+
+	s := values["stream"].Value.(*Stream)
+	wantEntries := []StreamEntry{
+		{ID: StreamID{101, 0}, Fields: []string{"f", "v1"}},
+		{ID: StreamID{102, 1}, Fields: []string{"g", "v2"}},
+	}
+	if !reflect.DeepEqual(s.Entries, wantEntries) {
+		t.Errorf("stream entries %v", s.Entries)
+	}
+	if s.Length != 2 || s.LastID.String() != "102-1" || s.EntriesAdded != 3 {
+		t.Errorf("stream %+v", s)
+	}
+	if len(s.Groups) != 1 || s.Groups[0].Name != "grp" || s.Groups[0].Pending != 1 ||
+		len(s.Groups[0].Consumers) != 1 || s.Groups[0].Consumers[0].ActiveTime != 6 {
+		t.Errorf("stream groups %+v", s.Groups)
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	if _, err := NewDecoder(strings.NewReader("NOTRDB0009")); err != ErrNotRDB {
+		t.Errorf("header: %v", err)
+	}
+	if _, err := NewDecoder(strings.NewReader("REDIS0013")); err == nil {
+		t.Error("version 13: expected error")
+	}
+
+	w := newWriter("0009")
+	w.key(typeString, "k")
+	w.str("v")
+	data := w.finish()
+	data[len(data)-1] ^= 1
+	d, err := NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Next(); err != ErrChecksum {
+		t.Errorf("checksum: %v", err)
+	}
+
+	// Truncated in the middle of a value.
+	d, _ = NewDecoder(bytes.NewReader(data[:13]))
+	if _, err := d.Next(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("truncated: %v", err)
+	}
+}
+
+func TestDecoderCorruptLength(t *testing.T) {
+	// lengths of about 2 GB in files of a few bytes.
+	inputs := []string{
+		"REDIS00100\x80z0\x030",
+		"REDIS0009\x00\x01k\x80\x7f\xff\xff\xffv",
+		"REDIS0009\x00\x01k\xc3\x01\x80\x7f\xff\xff\xff\x00v",
+	}
+	for _, in := range inputs {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		d, err := NewDecoder(strings.NewReader(in))
+		if err == nil {
+			_, err = d.Next()
+		}
+		runtime.ReadMemStats(&after)
+		if err == nil {
+			t.Errorf("%q: no error", in)
+		}
+		if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 16<<20 {
+			t.Errorf("%q: %v bytes allocated", in, alloc)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+// Stream entry flags.
+const (
+	streamItemDeleted    = 1
+	streamItemSameFields = 2
+)
+
+// StreamID is a stream entry id.
+type StreamID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+func (id StreamID) String() string {
+	return fmt.Sprintf("%d-%d", id.Ms, id.Seq)
+}
+
+// StreamEntry is a stream entry, Fields holding field value pairs.
+type StreamEntry struct {
+	ID     StreamID
+	Fields []string
+}
+
+// StreamConsumer is a consumer of a consumer group.
+type StreamConsumer struct {
+	Name       string
+	SeenTime   int64
+	ActiveTime int64
+	Pending    int
+}
+
+// StreamGroup is a consumer group.
+type StreamGroup struct {
+	Name        string
+	LastID      StreamID
+	EntriesRead int64
+	Pending     int
+	Consumers   []StreamConsumer
+}
+
+// Stream is the value of a stream.
+type Stream struct {
+	Entries      []StreamEntry
+	Length       int
+	LastID       StreamID
+	FirstID      StreamID
+	MaxDeletedID StreamID
+	EntriesAdded int64
+	Groups       []StreamGroup
+}
+
+func (d *Decoder) readStreamID() (StreamID, error) {
+	ms, err := d.readLen()
+	if err != nil {
+		return StreamID{}, err
+	}
+	seq, err := d.readLen()
+	if err != nil {
+		return StreamID{}, err
+	}
+	return StreamID{Ms: ms, Seq: seq}, nil
+}
+
+func (d *Decoder) readRawStreamID() (StreamID, error) {
+	b, err := d.readN(16)
+	if err != nil {
+		return StreamID{}, err
+	}
+	return StreamID{Ms: binary.BigEndian.Uint64(b), Seq: binary.BigEndian.Uint64(b[8:])}, nil
+}
+
+func (d *Decoder) readStream(typ byte) (*Stream, error) {
+	s := &Stream{}
+	n, err := d.readCount()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		key, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		if len(key) != 16 {
+			return nil, ErrMalformed
+		}
+		master := StreamID{Ms: binary.BigEndian.Uint64(key), Seq: binary.BigEndian.Uint64(key[8:])}
+		lp, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		entries, err := parseListpack(lp)
+		if err != nil {
+			return nil, err
+		}
+		if s.Entries, err = appendStreamEntries(s.Entries, master, entries); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.Length, err = d.readCount(); err != nil {
+		return nil, err
+	}
+	if s.LastID, err = d.readStreamID(); err != nil {
+		return nil, err
+	}
+	if typ >= typeStreamListpacks2 {
+		if s.FirstID, err = d.readStreamID(); err != nil {
+			return nil, err
+		}
+		if s.MaxDeletedID, err = d.readStreamID(); err != nil {
+			return nil, err
+		}
+		added, err := d.readLen()
+		if err != nil {
+			return nil, err
+		}
+		s.EntriesAdded = int64(added)
+	}
+
+	groups, err := d.readCount()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < groups; i++ {
+		g, err := d.readStreamGroup(typ)
+		if err != nil {
+			return nil, err
+		}
+		s.Groups = append(s.Groups, g)
+	}
+	return s, nil
+}
+
+func (d *Decoder) readStreamGroup(typ byte) (StreamGroup, error) {
+	var g StreamGroup
+	name, err := d.readString()
+	if err != nil {
+		return g, err
+	}
+	g.Name = string(name)
+	if g.LastID, err = d.readStreamID(); err != nil {
+		return g, err
+	}
+	if typ >= typeStreamListpacks2 {
+		read, err := d.readLen()
+		if err != nil {
+			return g, err
+		}
+		g.EntriesRead = int64(read)
+	}
+
+	// Group pending entries list: id, delivery time, delivery count.
+	if g.Pending, err = d.readCount(); err != nil {
+		return g, err
+	}
+	for i := 0; i < g.Pending; i++ {
+		if _, err := d.readRawStreamID(); err != nil {
+			return g, err
+		}
+		if _, err := d.readMillis(); err != nil {
+			return g, err
+		}
+		if _, err := d.readLen(); err != nil {
+			return g, err
+		}
+	}
+
+	consumers, err := d.readCount()
+	if err != nil {
+		return g, err
+	}
+	for i := 0; i < consumers; i++ {
+		var c StreamConsumer
+		name, err := d.readString()
+		if err != nil {
+			return g, err
+		}
+		c.Name = string(name)
+		if c.SeenTime, err = d.readMillis(); err != nil {
+			return g, err
+		}
+		if typ >= typeStreamListpacks3 {
+			if c.ActiveTime, err = d.readMillis(); err != nil {
+				return g, err
+			}
+		}
+		// Consumer pending entries, ids only, owned by the group list.
+		if c.Pending, err = d.readCount(); err != nil {
+			return g, err
+		}
+		for j := 0; j < c.Pending; j++ {
+			if _, err := d.readRawStreamID(); err != nil {
+				return g, err
+			}
+		}
+		g.Consumers = append(g.Consumers, c)
+	}
+	return g, nil
+}
+
+// appendStreamEntries decodes the entries of a stream listpack. The
+// listpack starts with a master entry holding the count of valid and
+// deleted entries and the master fields; every entry ends with the count
+// of its listpack elements.
+func appendStreamEntries(dst []StreamEntry, master StreamID, lp []string) ([]StreamEntry, error) {
+	r := &streamReader{elems: lp}
+	valid := r.int()
+	deleted := r.int()
+	n := r.int()
+	if n < 0 || n > int64(len(lp)) {
+		return nil, ErrMalformed
+	}
+	masterFields := make([]string, n)
+	for i := range masterFields {
+		masterFields[i] = r.string()
+	}
+	r.int() // master entry terminator
+	for i := int64(0); i < valid+deleted; i++ {
+		flags := r.int()
+		id := StreamID{
+			Ms:  master.Ms + uint64(r.int()),
+			Seq: master.Seq + uint64(r.int()),
+		}
+		var fields []string
+		if flags&streamItemSameFields != 0 {
+			fields = make([]string, 0, len(masterFields)*2)
+			for _, f := range masterFields {
+				fields = append(fields, f, r.string())
+			}
+		} else {
+			n := r.int()
+			if n < 0 || n > int64(len(lp)) {
+				return nil, ErrMalformed
+			}
+			fields = make([]string, 0, n*2)
+			for j := int64(0); j < n; j++ {
+				fields = append(fields, r.string(), r.string())
+			}
+		}
+		r.int() // lp-count
+		if r.err != nil {
+			return nil, r.err
+		}
+		if flags&streamItemDeleted == 0 {
+			dst = append(dst, StreamEntry{ID: id, Fields: fields})
+		}
+	}
+	return dst, r.err
+}
+
+type streamReader struct {
+	elems []string
+	pos   int
+	err   error
+}
+
+func (r *streamReader) string() string {
+	if r.pos >= len(r.elems) {
+		r.err = ErrMalformed
+		return ""
+	}
+	s := r.elems[r.pos]
+	r.pos++
+	return s
+}
+
+func (r *streamReader) int() int64 {
+	s := r.string()
+	if r.err != nil {
+		return 0
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		r.err = ErrMalformed
+	}
+	return n
+}
@@ -0,0 +1,320 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"strconv"
+)
+
+// byteReader reads the encoded blobs stored as strings: ziplists,
+// listpacks, intsets and zipmaps.
+type byteReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *byteReader) next(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.b) {
+		return nil, ErrMalformed
+	}
+	b := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *byteReader) byte() (byte, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func signExtend(v uint64, bits uint) int64 {
+	shift := 64 - bits
+	return int64(v<<shift) >> shift
+}
+
+// parseZiplist returns the entries of a ziplist.
+func parseZiplist(b []byte) ([]string, error) {
+	r := &byteReader{b: b}
+	header, err := r.next(10)
+	if err != nil {
+		return nil, err
+	}
+	count := int(binary.LittleEndian.Uint16(header[8:]))
+	entries := make([]string, 0, count)
+	for {
+		prev, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if prev == 0xff {
+			return entries, nil
+		}
+		if prev == 0xfe {
+			if _, err := r.next(4); err != nil {
+				return nil, err
+			}
+		}
+		entry, err := ziplistEntry(r)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func ziplistEntry(r *byteReader) (string, error) {
+	enc, err := r.byte()
+	if err != nil {
+		return "", err
+	}
+	var n int
+	switch enc >> 6 {
+	case 0:
+		n = int(enc & 0x3f)
+	case 1:
+		b, err := r.byte()
+		if err != nil {
+			return "", err
+		}
+		n = int(enc&0x3f)<<8 | int(b)
+	case 2:
+		b, err := r.next(4)
+		if err != nil {
+			return "", err
+		}
+		n = int(binary.BigEndian.Uint32(b))
+	default:
+		var v int64
+		switch enc {
+		case 0xc0:
+			b, err := r.next(2)
+			if err != nil {
+				return "", err
+			}
+			v = int64(int16(binary.LittleEndian.Uint16(b)))
+		case 0xd0:
+			b, err := r.next(4)
+			if err != nil {
+				return "", err
+			}
+			v = int64(int32(binary.LittleEndian.Uint32(b)))
+		case 0xe0:
+			b, err := r.next(8)
+			if err != nil {
+				return "", err
+			}
+			v = int64(binary.LittleEndian.Uint64(b))
+		case 0xf0:
+			b, err := r.next(3)
+			if err != nil {
+				return "", err
+			}
+			v = signExtend(uint64(b[0])|uint64(b[1])<<8|uint64(b[2])<<16, 24)
+		case 0xfe:
+			b, err := r.byte()
+			if err != nil {
+				return "", err
+			}
+			v = int64(int8(b))
+		default:
+			// 4 bit immediate, 1 to 13 encoding 0 to 12.
+			imm := enc & 0x0f
+			if imm < 1 || imm > 13 {
+				return "", ErrMalformed
+			}
+			v = int64(imm) - 1
+		}
+		return strconv.FormatInt(v, 10), nil
+	}
+	s, err := r.next(n)
+	if err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+// parseListpack returns the entries of a listpack.
+func parseListpack(b []byte) ([]string, error) {
+	r := &byteReader{b: b}
+	header, err := r.next(6)
+	if err != nil {
+		return nil, err
+	}
+	count := int(binary.LittleEndian.Uint16(header[4:]))
+	entries := make([]string, 0, count)
+	for {
+		if r.pos >= len(r.b) {
+			return nil, ErrMalformed
+		}
+		if r.b[r.pos] == 0xff {
+			return entries, nil
+		}
+		start := r.pos
+		entry, err := listpackEntry(r)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.next(backlenSize(r.pos - start)); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// backlenSize returns the size of the back length of an entry of n bytes.
+func backlenSize(n int) int {
+	switch {
+	case n <= 127:
+		return 1
+	case n < 16383:
+		return 2
+	case n < 2097151:
+		return 3
+	case n < 268435455:
+		return 4
+	}
+	return 5
+}
+
+func listpackEntry(r *byteReader) (string, error) {
+	enc, err := r.byte()
+	if err != nil {
+		return "", err
+	}
+	var n int
+	switch {
+	case enc&0x80 == 0:
+		return strconv.Itoa(int(enc & 0x7f)), nil
+	case enc&0xc0 == 0x80:
+		n = int(enc & 0x3f)
+	case enc&0xe0 == 0xc0:
+		b, err := r.byte()
+		if err != nil {
+			return "", err
+		}
+		v := signExtend(uint64(enc&0x1f)<<8|uint64(b), 13)
+		return strconv.FormatInt(v, 10), nil
+	case enc&0xf0 == 0xe0:
+		b, err := r.byte()
+		if err != nil {
+			return "", err
+		}
+		n = int(enc&0x0f)<<8 | int(b)
+	case enc == 0xf0:
+		b, err := r.next(4)
+		if err != nil {
+			return "", err
+		}
+		n = int(binary.LittleEndian.Uint32(b))
+	case enc >= 0xf1 && enc <= 0xf4:
+		size := [...]int{2, 3, 4, 8}[enc-0xf1]
+		b, err := r.next(size)
+		if err != nil {
+			return "", err
+		}
+		var u uint64
+		for i := size - 1; i >= 0; i-- {
+			u = u<<8 | uint64(b[i])
+		}
+		return strconv.FormatInt(signExtend(u, uint(size*8)), 10), nil
+	default:
+		return "", ErrMalformed
+	}
+	s, err := r.next(n)
+	if err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+// parseIntset returns the members of an intset.
+func parseIntset(b []byte) ([]string, error) {
+	r := &byteReader{b: b}
+	header, err := r.next(8)
+	if err != nil {
+		return nil, err
+	}
+	size := int(binary.LittleEndian.Uint32(header))
+	count := int(binary.LittleEndian.Uint32(header[4:]))
+	if size != 2 && size != 4 && size != 8 {
+		return nil, ErrMalformed
+	}
+	if count*size != len(b)-8 {
+		return nil, ErrMalformed
+	}
+	members := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		v, _ := r.next(size)
+		var n int64
+		switch size {
+		case 2:
+			n = int64(int16(binary.LittleEndian.Uint16(v)))
+		case 4:
+			n = int64(int32(binary.LittleEndian.Uint32(v)))
+		case 8:
+			n = int64(binary.LittleEndian.Uint64(v))
+		}
+		members = append(members, strconv.FormatInt(n, 10))
+	}
+	return members, nil
+}
+
+// parseZipmap returns the fields of a zipmap, used by hashes before
+// Redis 2.6.
+func parseZipmap(b []byte) ([]Field, error) {
+	r := &byteReader{b: b}
+	if _, err := r.byte(); err != nil {
+		return nil, err
+	}
+	var fields []Field
+	for {
+		if r.pos < len(r.b) && r.b[r.pos] == 0xff {
+			return fields, nil
+		}
+		field, err := zipmapString(r, false)
+		if err != nil {
+			return nil, err
+		}
+		value, err := zipmapString(r, true)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, Field{Field: field, Value: value})
+	}
+}
+
+func zipmapString(r *byteReader, value bool) (string, error) {
+	b, err := r.byte()
+	if err != nil {
+		return "", err
+	}
+	n := int(b)
+	switch b {
+	case 254:
+		v, err := r.next(4)
+		if err != nil {
+			return "", err
+		}
+		n = int(binary.LittleEndian.Uint32(v))
+	case 255:
+		return "", ErrMalformed
+	}
+	var free int
+	if value {
+		f, err := r.byte()
+		if err != nil {
+			return "", err
+		}
+		free = int(f)
+	}
+	s, err := r.next(n)
+	if err != nil {
+		return "", err
+	}
+	if _, err := r.next(free); err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
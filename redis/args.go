@@ -0,0 +1,139 @@
+package redis
+
+import "errors"
+
+// ErrUnbalancedQuotes is returned by SplitArgs for an unterminated quote or
+// a closing quote that is not followed by a space.
+var ErrUnbalancedQuotes = errors.New("Invalid argument(s)")
+
+// SplitArgs splits a command line into arguments the way redis-cli does.
+// Double quoted arguments support the \n, \r, \t, \b, \a and \xHH escapes,
+// single quoted arguments only support \'. Quotes allow empty arguments
+// and arguments containing spaces.
+func SplitArgs(line string) ([]string, error) {
+	var args []string
+	i := 0
+	for {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i >= len(line) {
+			return args, nil
+		}
+
+		var (
+			inq, insq bool
+			done      bool
+			current   []byte
+		)
+		for !done {
+			if inq {
+				if i >= len(line) {
+					return nil, ErrUnbalancedQuotes
+				}
+				switch {
+				case line[i] == '\\' && i+3 < len(line) && line[i+1] == 'x' &&
+					isHexDigit(line[i+2]) && isHexDigit(line[i+3]):
+					current = append(current, hexDigitToInt(line[i+2])*16+hexDigitToInt(line[i+3]))
+					i += 3
+				case line[i] == '\\' && i+1 < len(line):
+					i++
+					switch line[i] {
+					case 'n':
+						current = append(current, '\n')
+					case 'r':
+						current = append(current, '\r')
+					case 't':
+						current = append(current, '\t')
+					case 'b':
+						current = append(current, '\b')
+					case 'a':
+						current = append(current, '\a')
+					default:
+						current = append(current, line[i])
+					}
+				case line[i] == '"':
+					// closing quote must be followed by a space or nothing at all.
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, ErrUnbalancedQuotes
+					}
+					done = true
+				default:
+					current = append(current, line[i])
+				}
+			} else if insq {
+				if i >= len(line) {
+					return nil, ErrUnbalancedQuotes
+				}
+				switch {
+				case line[i] == '\\' && i+1 < len(line) && line[i+1] == '\'':
+					i++
+					current = append(current, '\'')
+				case line[i] == '\'':
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, ErrUnbalancedQuotes
+					}
+					done = true
+				default:
+					current = append(current, line[i])
+				}
+			} else {
+				if i >= len(line) {
+					break
+				}
+				switch line[i] {
+				case ' ', '\n', '\r', '\t', 0:
+					done = true
+				case '"':
+					inq = true
+				case '\'':
+					insq = true
+				default:
+					current = append(current, line[i])
+				}
+			}
+			if i < len(line) {
+				i++
+			}
+		}
+		args = append(args, string(current))
+	}
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\v', '\f', '\r':
+		return true
+	}
+	return false
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func hexDigitToInt(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+// QuoteArg quotes an argument so SplitArgs reads it back, when it can't be
+// typed as is.
+func QuoteArg(arg string) string {
+	if arg == "" {
+		return `""`
+	}
+	for i := 0; i < len(arg); i++ {
+		c := arg[i]
+		if c <= ' ' || c > '~' || c == '"' || c == '\'' || c == '\\' {
+			return Quote(arg)
+		}
+	}
+	return arg
+}
@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		args []string
+	}{
+		{"", nil},
+		{"  get  key ", []string{"get", "key"}},
+		{`SET k "hello world"`, []string{"SET", "k", "hello world"}},
+		{`SET k ""`, []string{"SET", "k", ""}},
+		{`SET "\x00\x41" "a\nb\"c"`, []string{"SET", "\x00A", "a\nb\"c"}},
+		{`SET k 'it\'s "raw" \n'`, []string{"SET", "k", `it's "raw" \n`}},
+		{`get a"b c"`, []string{"get", "ab c"}},
+	}
+	for _, tt := range tests {
+		args, err := SplitArgs(tt.line)
+		if err != nil {
+			t.Errorf("SplitArgs(%q) error %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("SplitArgs(%q) = %q, want %q", tt.line, args, tt.args)
+		}
+	}
+}
+
+func TestSplitArgsUnbalanced(t *testing.T) {
+	for _, line := range []string{`get "abc`, `get 'abc`, `get "a"b`, `get 'a'b`} {
+		if _, err := SplitArgs(line); err != ErrUnbalancedQuotes {
+			t.Errorf("SplitArgs(%q) error = %v, want %v", line, err, ErrUnbalancedQuotes)
+		}
+	}
+}
+
+func TestQuoteArg(t *testing.T) {
+	tests := map[string]string{
+		"user:1":  "user:1",
+		"a b":     `"a b"`,
+		"":        `""`,
+		"\x00key": `"\x00key"`,
+	}
+	for arg, want := range tests {
+		got := QuoteArg(arg)
+		if got != want {
+			t.Errorf("QuoteArg(%q) = %q, want %q", arg, got, want)
+		}
+		if args, err := SplitArgs(got); err != nil || len(args) != 1 || args[0] != arg {
+			t.Errorf("SplitArgs(%q) = %q, %v", got, args, err)
+		}
+	}
+}
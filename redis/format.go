@@ -0,0 +1,172 @@
+package redis
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// OutputMode selects how replies are rendered by WriteReply.
+type OutputMode int
+
+// output modes
+const (
+	// OutputCLI renders replies like an interactive redis-cli.
+	OutputCLI OutputMode = iota
+	// OutputRaw renders replies like redis-cli --raw.
+	OutputRaw
+	// OutputJSON renders replies as JSON.
+	OutputJSON
+)
+
+func (m OutputMode) String() string {
+	switch m {
+	case OutputRaw:
+		return "raw"
+	case OutputJSON:
+		return "json"
+	default:
+		return "cli"
+	}
+}
+
+// Next returns the mode following m, cycling back to OutputCLI.
+func (m OutputMode) Next() OutputMode {
+	return (m + 1) % (OutputJSON + 1)
+}
+
+// WriteReply writes the reply to w in the given mode, ending with a newline.
+func WriteReply(w io.Writer, r *Reply, mode OutputMode) error {
+	var b strings.Builder
+	switch mode {
+	case OutputRaw:
+		formatRaw(&b, r.object)
+		b.WriteByte('\n')
+	case OutputJSON:
+		data, err := json.Marshal(jsonValue(r.object))
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	default:
+		formatTTY(&b, r.object, "")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatTTY follows cliFormatReplyTTY of redis-cli. Every element ends with
+// a newline, nested elements are indented by prefix.
+func formatTTY(b *strings.Builder, o *Object, prefix string) {
+	switch o.Type {
+	case Err:
+		b.WriteString("(error) ")
+		b.Write(o.val.([]byte))
+	case SimpleStr:
+		b.Write(o.val.([]byte))
+	case Int:
+		b.WriteString("(integer) ")
+		b.Write(o.val.([]byte))
+	case BulkStr:
+		b.WriteString(Quote(string(o.val.([]byte))))
+	case Nil:
+		b.WriteString("(nil)")
+	case Array:
+		elems := o.val.([]*Object)
+		if len(elems) == 0 {
+			b.WriteString("(empty array)")
+			break
+		}
+		idxlen := len(strconv.Itoa(len(elems)))
+		elemPrefix := prefix + strings.Repeat(" ", idxlen+2)
+		for i, e := range elems {
+			if i > 0 {
+				b.WriteString(prefix)
+			}
+			idx := strconv.Itoa(i + 1)
+			b.WriteString(strings.Repeat(" ", idxlen-len(idx)))
+			b.WriteString(idx)
+			b.WriteString(") ")
+			formatTTY(b, e, elemPrefix)
+		}
+		return
+	}
+	b.WriteByte('\n')
+}
+
+// formatRaw follows cliFormatReplyRaw of redis-cli.
+func formatRaw(b *strings.Builder, o *Object) {
+	switch o.Type {
+	case Err, SimpleStr, Int, BulkStr:
+		b.Write(o.val.([]byte))
+	case Array:
+		for i, e := range o.val.([]*Object) {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			formatRaw(b, e)
+		}
+	}
+}
+
+func jsonValue(o *Object) interface{} {
+	switch o.Type {
+	case Err:
+		return map[string]string{"error": string(o.val.([]byte))}
+	case Int:
+		v, err := strconv.ParseInt(string(o.val.([]byte)), 10, 64)
+		if err != nil {
+			return string(o.val.([]byte))
+		}
+		return v
+	case SimpleStr, BulkStr:
+		return string(o.val.([]byte))
+	case Array:
+		elems := o.val.([]*Object)
+		a := make([]interface{}, 0, len(elems))
+		for _, e := range elems {
+			a = append(a, jsonValue(e))
+		}
+		return a
+	}
+	return nil
+}
+
+// Quote returns s as a double quoted string with non printable bytes
+// escaped, like sdscatrepr. The result can be read back by SplitArgs.
+func Quote(s string) string {
+	const hextable = "0123456789abcdef"
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\\', '"':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		default:
+			if c >= 32 && c <= 126 {
+				b.WriteByte(c)
+			} else {
+				b.WriteString(`\x`)
+				b.WriteByte(hextable[c>>4])
+				b.WriteByte(hextable[c&0x0f])
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"strings"
+	"testing"
+)
+
+func readReply(t *testing.T, resp string) *Reply {
+	t.Helper()
+	o, err := NewReader(strings.NewReader(resp)).readObject()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewReply(o)
+}
+
+func TestWriteReply(t *testing.T) {
+	// XRANGE like reply: ids with nested field lists, plus a nil, an
+	// integer, an error and an empty array.
+	resp := "*6\r\n" +
+		"*2\r\n$3\r\n1-0\r\n*2\r\n$1\r\nf\r\n$2\r\na\n\r\n" +
+		":7\r\n" +
+		"$-1\r\n" +
+		"-ERR bad\r\n" +
+		"*0\r\n" +
+		"+OK\r\n"
+	tests := []struct {
+		mode OutputMode
+		want string
+	}{
+		{OutputCLI, "" +
+			"1) 1) \"1-0\"\n" +
+			"   2) 1) \"f\"\n" +
+			"      2) \"a\\n\"\n" +
+			"2) (integer) 7\n" +
+			"3) (nil)\n" +
+			"4) (error) ERR bad\n" +
+			"5) (empty array)\n" +
+			"6) OK\n"},
+		{OutputRaw, "1-0\nf\na\n\n7\n\nERR bad\n\nOK\n"},
+		{OutputJSON, `[["1-0",["f","a\n"]],7,null,{"error":"ERR bad"},[],"OK"]` + "\n"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := WriteReply(&b, readReply(t, resp), tt.mode); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("WriteReply %v:\n%q\nwant\n%q", tt.mode, b.String(), tt.want)
+		}
+	}
+}
+
+func TestWriteReplyIndexWidth(t *testing.T) {
+	resp := "*10\r\n" + strings.Repeat(":1\r\n", 9) + "*1\r\n:2\r\n"
+	var b strings.Builder
+	WriteReply(&b, readReply(t, resp), OutputCLI)
+	lines := strings.Split(b.String(), "\n")
+	if lines[0] != " 1) (integer) 1" || lines[9] != "10) 1) (integer) 2" {
+		t.Errorf("unexpected output %q", b.String())
+	}
+}
+
+func TestQuote(t *testing.T) {
+	s := "a\"b\\\x00\xe4"
+	q := Quote(s)
+	if q != `"a\"b\\\x00\xe4"` {
+		t.Errorf("Quote = %s", q)
+	}
+	args, err := SplitArgs(q)
+	if err != nil || len(args) != 1 || args[0] != s {
+		t.Errorf("SplitArgs(Quote(s)) = %q, %v", args, err)
+	}
+}
+
+func TestReplyList(t *testing.T) {
+	l, err := readReply(t, "*3\r\n$1\r\na\r\n$-1\r\n:5\r\n").List()
+	if err != nil || len(l) != 3 || l[0] != "a" || l[1] != "" || l[2] != "5" {
+		t.Errorf("List = %q, %v", l, err)
+	}
+	l, err = readReply(t, "*0\r\n").List()
+	if err != nil || len(l) != 0 {
+		t.Errorf("empty List = %q, %v", l, err)
+	}
+}
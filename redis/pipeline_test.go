@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestDoMulti(t *testing.T) {
+	server, conn := net.Pipe()
+	defer server.Close()
+	client := NewClient(conn)
+	defer client.Close()
+
+	go func() {
+		buf := make([]byte, 1024)
+		var got []byte
+		want := []byte("*2\r\n$4\r\nTYPE\r\n$1\r\na\r\n*2\r\n$3\r\nGET\r\n$1\r\nb\r\n")
+		for !bytes.Equal(got, want) {
+			n, err := server.Read(buf)
+			if err != nil {
+				return
+			}
+			got = append(got, buf[:n]...)
+		}
+		server.Write([]byte("+string\r\n-WRONGTYPE bad\r\n"))
+	}()
+
+	replies, err := client.DoMulti([][]string{{"TYPE", "a"}, {"GET", "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replies) != 2 || replies[0].String() != "string" || replies[1].Err() == nil {
+		t.Errorf("replies %v %v", replies[0].String(), replies[1].Err())
+	}
+}
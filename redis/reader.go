@@ -59,8 +59,20 @@ func NewReader(reader io.Reader) *RESPReader {
 	}
 }
 
-// ReadObject read
+// ReadObject read a reply. An error reply is returned as an error; error
+// replies nested in an array are kept as Err objects.
 func (r *RESPReader) ReadObject() (*Object, error) {
+	o, err := r.readObject()
+	if err != nil {
+		return nil, err
+	}
+	if o.Type == Err {
+		return o, fmt.Errorf("(error) %s", o.val)
+	}
+	return o, nil
+}
+
+func (r *RESPReader) readObject() (*Object, error) {
 	line, err := r.readLine()
 	if err != nil {
 		return nil, err
@@ -70,7 +82,7 @@ func (r *RESPReader) ReadObject() (*Object, error) {
 	case SIMPLE_STRING: // +OK\r\n
 		return NewObject(SimpleStr, line[1:len(line)-2]), nil
 	case ERROR:
-		return NewObject(Err, line[1:len(line)-2]), fmt.Errorf("(error) %s", line[1:len(line)-2])
+		return NewObject(Err, line[1:len(line)-2]), nil
 	case INTEGER: // :99\r\n  -ERR unknown command 'GETT'\r\n
 		return NewObject(Int, line[1:len(line)-2]), nil
 	case BULK_STRING: // $13\r\nHello, World!\r\n
@@ -111,9 +123,12 @@ func (r *RESPReader) readArray(line []byte) (*Object, error) {
 	if err != nil {
 		return nil, err
 	}
+	if count < 0 {
+		return NewObject(Nil, line), nil
+	}
 	var elems = make([]*Object, 0, count)
 	for i := 0; i < count; i++ {
-		buf, err := r.ReadObject()
+		buf, err := r.readObject()
 		if err != nil {
 			return nil, err
 		}
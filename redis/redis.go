@@ -45,6 +45,52 @@ func (r *Client) Do(key string, cmd ...string) (*Reply, error) {
 	return NewReply(o), nil
 }
 
+// DoMulti pipelines commands and returns their replies in order. Error
+// replies are returned as replies of type Err, not as an error.
+func (r *Client) DoMulti(cmds [][]string) ([]*Reply, error) {
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+	if r.timeout > 0 {
+		r.conn.SetWriteDeadline(time.Now().Add(r.timeout))
+	}
+	if err := r.writer.WriteCommands(cmds); err != nil {
+		return nil, err
+	}
+
+	replies := make([]*Reply, 0, len(cmds))
+	for range cmds {
+		if r.timeout > 0 {
+			r.conn.SetReadDeadline(time.Now().Add(r.timeout))
+		}
+		o, err := r.reader.readObject()
+		if err != nil {
+			return nil, err
+		}
+		replies = append(replies, NewReply(o))
+	}
+	return replies, nil
+}
+
+// Send writes a command without reading the reply, for connections that
+// stream replies such as SUBSCRIBE or MONITOR.
+func (r *Client) Send(key string, cmd ...string) error {
+	if r.timeout > 0 {
+		r.conn.SetWriteDeadline(time.Now().Add(r.timeout))
+	}
+	return r.writer.WriteCommand(key, cmd...)
+}
+
+// Receive waits for the next reply without timeout.
+func (r *Client) Receive() (*Reply, error) {
+	r.conn.SetReadDeadline(time.Time{})
+	o, err := r.reader.ReadObject()
+	if err != nil {
+		return nil, err
+	}
+	return NewReply(o), nil
+}
+
 // Close the conn
 func (r *Client) Close() {
 	r.conn.Close()
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 // Reply reply
@@ -36,31 +37,48 @@ func (r *Reply) IsNil() bool {
 	return r.object.Type == Nil
 }
 
-// List returns a string slice.
+// List returns a string slice. Nested arrays are rendered as text and nil
+// elements as empty strings.
 func (r *Reply) List() ([]string, error) {
 	if r.object.Type == Err {
-		return nil, r.object.val.(error)
+		return nil, r.Err()
 	}
 	elems, ok := r.object.val.([]*Object)
 	if !ok {
 		return nil, fmt.Errorf("convert to []interface{}")
 	}
-	if len(elems) == 0 {
-		return nil, errors.New("(empty list or set)")
-	}
 
 	s := make([]string, 0, len(elems))
 	for _, ele := range elems {
-		s = append(s, string(ele.val.([]byte)))
+		switch ele.Type {
+		case Array:
+			var b strings.Builder
+			formatRaw(&b, ele)
+			s = append(s, b.String())
+		case Nil:
+			s = append(s, "")
+		default:
+			s = append(s, string(ele.val.([]byte)))
+		}
 	}
 	return s, nil
 }
 
+// Err returns the error of an error reply, otherwise nil.
+func (r *Reply) Err() error {
+	if r.object.Type != Err {
+		return nil
+	}
+	return errors.New(string(r.object.val.([]byte)))
+}
+
 // String return string.
 func (r *Reply) String() string {
 	switch r.object.Type {
 	case Err:
-		return r.object.val.(error).Error()
+		return string(r.object.val.([]byte))
+	case Int:
+		return string(r.object.val.([]byte))
 	case SimpleStr:
 		return string(r.object.val.([]byte))
 	case BulkStr:
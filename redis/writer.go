@@ -27,6 +27,23 @@ func NewRESPWriter(writer io.Writer) *RESPWriter {
 // WriteCommand write
 // @param args - All Redis commands are sent as arrays of bulk strings. *3\r\n$3\r\nSET\r\n$5\r\nmykey\r\n$8\r\nmy value\r\n
 func (w *RESPWriter) WriteCommand(key string, args ...string) (err error) {
+	if err := w.writeCommand(key, args...); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// WriteCommands writes several commands with a single flush, for pipelining.
+func (w *RESPWriter) WriteCommands(cmds [][]string) error {
+	for _, cmd := range cmds {
+		if err := w.writeCommand(cmd[0], cmd[1:]...); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func (w *RESPWriter) writeCommand(key string, args ...string) (err error) {
 	if _, err := w.Write(arrayPrefixSlice); err != nil {
 		return err
 	}
@@ -41,8 +58,7 @@ func (w *RESPWriter) WriteCommand(key string, args ...string) (err error) {
 	for _, arg := range args {
 		w.writeString(arg)
 	}
-
-	return w.Flush()
+	return nil
 }
 
 func (w *RESPWriter) writeString(s string) {
@@ -0,0 +1,97 @@
+package redisapi
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/liwnn/redisterm/tlog"
+)
+
+// ClientName is set with CLIENT SETNAME on every connection, so that
+// redis-term sessions can be found in CLIENT LIST. It is redis-term@host
+// unless set by the client_name preference.
+var ClientName = defaultClientName()
+
+func defaultClientName() string {
+	host, _ := os.Hostname()
+	name := "redis-term"
+	if host != "" {
+		name += "@" + host
+	}
+	// client names can't contain spaces or newlines.
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// ClientInfo is a line of CLIENT LIST.
+type ClientInfo struct {
+	ID     int64
+	Addr   string
+	Name   string
+	DB     int
+	Age    int64
+	Idle   int64
+	Flags  string
+	Cmd    string
+	Memory int64
+	// Fields holds every field of the line.
+	Fields map[string]string
+}
+
+// ParseClientList parses the reply of CLIENT LIST.
+func ParseClientList(text string) []ClientInfo {
+	var clients []ClientInfo
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		c := ClientInfo{Fields: make(map[string]string)}
+		for _, field := range strings.Fields(line) {
+			k, v, _ := strings.Cut(field, "=")
+			c.Fields[k] = v
+		}
+		c.ID, _ = strconv.ParseInt(c.Fields["id"], 10, 64)
+		c.Addr = c.Fields["addr"]
+		c.Name = c.Fields["name"]
+		c.DB, _ = strconv.Atoi(c.Fields["db"])
+		c.Age, _ = strconv.ParseInt(c.Fields["age"], 10, 64)
+		c.Idle, _ = strconv.ParseInt(c.Fields["idle"], 10, 64)
+		c.Flags = c.Fields["flags"]
+		c.Cmd = c.Fields["cmd"]
+		// tot-mem was added in Redis 6.0, older servers only report the
+		// output buffer memory.
+		if v, ok := c.Fields["tot-mem"]; ok {
+			c.Memory, _ = strconv.ParseInt(v, 10, 64)
+		} else {
+			c.Memory, _ = strconv.ParseInt(c.Fields["omem"], 10, 64)
+		}
+		clients = append(clients, c)
+	}
+	return clients
+}
+
+// ClientList returns the connected clients.
+func (r *Redis) ClientList() ([]ClientInfo, error) {
+	result, err := r.client.Do("CLIENT", "LIST")
+	if err != nil {
+		return nil, err
+	}
+	tlog.Debug("[Redis] CLIENT LIST")
+	return ParseClientList(result.String()), nil
+}
+
+// ClientKill closes the connection of a client.
+func (r *Redis) ClientKill(id int64) error {
+	result, err := r.client.Do("CLIENT", "KILL", "ID", strconv.FormatInt(id, 10))
+	if err != nil {
+		return err
+	}
+	tlog.Debug("[Redis] CLIENT KILL", "id", id, "reply", result.String())
+	return nil
+}
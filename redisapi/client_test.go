@@ -0,0 +1,20 @@
+package redisapi
+
+import "testing"
+
+func TestParseClientList(t *testing.T) {
+	text := "id=3 addr=127.0.0.1:51234 laddr=127.0.0.1:6379 fd=8 name=redis-term@box age=120 idle=5 flags=N db=2 sub=0 psub=0 omem=0 tot-mem=22426 cmd=client|list\n" +
+		"id=5 addr=10.0.0.2:4000 fd=9 name= age=1 idle=0 flags=S db=0 omem=100 cmd=replconf\n"
+	clients := ParseClientList(text)
+	if len(clients) != 2 {
+		t.Fatalf("clients %v", clients)
+	}
+	c := clients[0]
+	if c.ID != 3 || c.Addr != "127.0.0.1:51234" || c.Name != "redis-term@box" || c.DB != 2 ||
+		c.Age != 120 || c.Idle != 5 || c.Flags != "N" || c.Cmd != "client|list" || c.Memory != 22426 {
+		t.Errorf("client %+v", c)
+	}
+	if clients[1].Memory != 100 || clients[1].Fields["laddr"] != "" {
+		t.Errorf("client %+v", clients[1])
+	}
+}
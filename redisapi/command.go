@@ -0,0 +1,213 @@
+package redisapi
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/liwnn/redisterm/redis"
+	"github.com/liwnn/redisterm/tlog"
+)
+
+// CommandInfo describes a command supported by the server. Subcommands are
+// named with their container, e.g. "CONFIG GET".
+type CommandInfo struct {
+	Name  string
+	Arity int
+	Flags []string
+	// FirstKey is the position of the first key argument, 0 when the
+	// command takes no key.
+	FirstKey int
+	Summary  string
+	Syntax   string
+}
+
+// HasFlag return whether the command has the given COMMAND INFO flag.
+func (c *CommandInfo) HasFlag(flag string) bool {
+	for _, f := range c.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// Commands returns the commands supported by the server, keyed by upper
+// case name. Flags come from COMMAND; summaries and syntax from COMMAND DOCS
+// when the server supports it (Redis 7+).
+func (r *Redis) Commands() (map[string]*CommandInfo, error) {
+	result, err := r.client.Do("COMMAND")
+	if err != nil {
+		return nil, err
+	}
+	commands := make(map[string]*CommandInfo)
+	for _, v := range result.ToArray() {
+		parseCommandInfo(commands, v)
+	}
+	tlog.Debug("[Redis] COMMAND", "commands", len(commands))
+
+	docs, err := r.client.Do("COMMAND", "DOCS")
+	if err != nil {
+		tlog.Warn("[Redis] COMMAND DOCS", "err", err)
+		return commands, nil
+	}
+	parseCommandDocs(commands, "", docs)
+	return commands, nil
+}
+
+func commandName(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "|", " "))
+}
+
+// parseCommandInfo parses one entry of the COMMAND reply:
+// name, arity, flags, first key, last key, step, [acl categories, tips,
+// key specs, subcommands].
+func parseCommandInfo(commands map[string]*CommandInfo, v *redis.Reply) {
+	fields := v.ToArray()
+	if len(fields) < 3 {
+		return
+	}
+	info := &CommandInfo{
+		Name: commandName(fields[0].String()),
+	}
+	info.Arity, _ = fields[1].Int()
+	info.Flags, _ = fields[2].List()
+	if len(fields) > 3 {
+		info.FirstKey, _ = fields[3].Int()
+	}
+	commands[info.Name] = info
+	if len(fields) > 9 {
+		for _, sub := range fields[9].ToArray() {
+			parseCommandInfo(commands, sub)
+		}
+	}
+}
+
+// parseCommandDocs parses the COMMAND DOCS reply, a map of command name to
+// a map of doc fields.
+func parseCommandDocs(commands map[string]*CommandInfo, parent string, docs *redis.Reply) {
+	elems := docs.ToArray()
+	for i := 0; i+1 < len(elems); i += 2 {
+		name := commandName(elems[i].String())
+		info, ok := commands[name]
+		if !ok {
+			info = &CommandInfo{Name: name}
+			commands[name] = info
+		}
+		doc := replyMap(elems[i+1])
+		info.Summary = mapString(doc, "summary")
+		if args, ok := doc["arguments"]; ok {
+			info.Syntax = formatArgs(args.ToArray(), " ")
+		}
+		if sub, ok := doc["subcommands"]; ok {
+			parseCommandDocs(commands, name, sub)
+		}
+	}
+}
+
+// formatArgs renders command arguments the way redis-cli shows hints.
+func formatArgs(args []*redis.Reply, sep string) string {
+	parts := make([]string, 0, len(args))
+	for _, a := range args {
+		parts = append(parts, formatArg(replyMap(a)))
+	}
+	return strings.Join(parts, sep)
+}
+
+func formatArg(arg map[string]*redis.Reply) string {
+	typ := mapString(arg, "type")
+	var flags []string
+	if f, ok := arg["flags"]; ok {
+		flags, _ = f.List()
+	}
+	hasFlag := func(name string) bool {
+		for _, f := range flags {
+			if f == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	var s string
+	switch typ {
+	case "block", "oneof":
+		sep := " "
+		if typ == "oneof" {
+			sep = " | "
+		}
+		if sub, ok := arg["arguments"]; ok {
+			s = formatArgs(sub.ToArray(), sep)
+		}
+	case "pure-token":
+	default:
+		s = mapString(arg, "name")
+		if d, ok := arg["display_text"]; ok {
+			s = d.String()
+		}
+	}
+	if t, ok := arg["token"]; ok {
+		if s == "" {
+			s = t.String()
+		} else {
+			s = t.String() + " " + s
+		}
+	}
+	if hasFlag("multiple") {
+		if hasFlag("multiple_token") {
+			s = s + " [" + s + " ...]"
+		} else if t, ok := arg["token"]; ok {
+			s = s + " [" + strings.TrimPrefix(s, t.String()+" ") + " ...]"
+		} else {
+			s = s + " [" + s + " ...]"
+		}
+	}
+	if hasFlag("optional") {
+		s = "[" + s + "]"
+	}
+	return s
+}
+
+// replyMap converts a flat key/value array reply to a map.
+func replyMap(r *redis.Reply) map[string]*redis.Reply {
+	elems := r.ToArray()
+	m := make(map[string]*redis.Reply, len(elems)/2)
+	for i := 0; i+1 < len(elems); i += 2 {
+		m[elems[i].String()] = elems[i+1]
+	}
+	return m
+}
+
+func mapString(m map[string]*redis.Reply, key string) string {
+	if v, ok := m[key]; ok {
+		return v.String()
+	}
+	return ""
+}
+
+// IsSubcommandContainer return whether name has subcommands in commands.
+func IsSubcommandContainer(commands map[string]*CommandInfo, name string) bool {
+	prefix := strings.ToUpper(name) + " "
+	for k := range commands {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Hint returns the command syntax, falling back to its arity when the
+// server has no COMMAND DOCS.
+func (c *CommandInfo) Hint() string {
+	if c.Syntax != "" {
+		return c.Name + " " + c.Syntax
+	}
+	// arity counts the command name, subcommands count twice.
+	arity, words := c.Arity, len(strings.Fields(c.Name))
+	switch {
+	case arity < 0:
+		return c.Name + " (>= " + strconv.Itoa(-arity-words) + " args)"
+	case arity > 0:
+		return c.Name + " (" + strconv.Itoa(arity-words) + " args)"
+	}
+	return c.Name
+}
@@ -0,0 +1,74 @@
+package redisapi
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/liwnn/redisterm/redis"
+)
+
+func bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+func array(elems ...string) string {
+	return "*" + strconv.Itoa(len(elems)) + "\r\n" + strings.Join(elems, "")
+}
+
+func TestParseCommandDocs(t *testing.T) {
+	// COMMAND DOCS SET, trimmed to the fields used for hints.
+	resp := array(bulk("set"), array(
+		bulk("summary"), bulk("Sets the string value of a key."),
+		bulk("arguments"), array(
+			array(bulk("name"), bulk("key"), bulk("type"), bulk("key")),
+			array(bulk("name"), bulk("value"), bulk("type"), bulk("string")),
+			array(bulk("name"), bulk("condition"), bulk("type"), bulk("oneof"),
+				bulk("flags"), array(bulk("optional")),
+				bulk("arguments"), array(
+					array(bulk("name"), bulk("nx"), bulk("type"), bulk("pure-token"), bulk("token"), bulk("NX")),
+					array(bulk("name"), bulk("xx"), bulk("type"), bulk("pure-token"), bulk("token"), bulk("XX")),
+				)),
+			array(bulk("name"), bulk("field"), bulk("type"), bulk("string"),
+				bulk("token"), bulk("FIELDS"), bulk("flags"), array(bulk("multiple"), bulk("optional"))),
+		),
+	))
+	o, err := redis.NewReader(strings.NewReader(resp)).ReadObject()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commands := map[string]*CommandInfo{}
+	parseCommandDocs(commands, "", redis.NewReply(o))
+	set := commands["SET"]
+	if set == nil {
+		t.Fatal("SET not parsed")
+	}
+	if set.Summary != "Sets the string value of a key." {
+		t.Errorf("summary %q", set.Summary)
+	}
+	want := "SET key value [NX | XX] [FIELDS field [field ...]]"
+	if set.Hint() != want {
+		t.Errorf("hint %q, want %q", set.Hint(), want)
+	}
+}
+
+func TestCommandHintArity(t *testing.T) {
+	c := &CommandInfo{Name: "CONFIG GET", Arity: -3}
+	if c.Hint() != "CONFIG GET (>= 1 args)" {
+		t.Errorf("hint %q", c.Hint())
+	}
+}
+
+func TestParseCommandInfo(t *testing.T) {
+	resp := array(bulk("get"), ":2\r\n", array("+readonly\r\n", "+fast\r\n"), ":1\r\n", ":1\r\n", ":1\r\n")
+	o, err := redis.NewReader(strings.NewReader(resp)).ReadObject()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commands := map[string]*CommandInfo{}
+	parseCommandInfo(commands, redis.NewReply(o))
+	get := commands["GET"]
+	if get == nil || get.Arity != 2 || get.FirstKey != 1 || !get.HasFlag("readonly") {
+		t.Errorf("GET %+v", get)
+	}
+}
@@ -0,0 +1,142 @@
+package redisapi
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/liwnn/redisterm/tlog"
+)
+
+// InfoField is a "key:value" line of the INFO reply.
+type InfoField struct {
+	Key   string
+	Value string
+}
+
+// InfoSection is a "# Name" section of the INFO reply.
+type InfoSection struct {
+	Name   string
+	Fields []InfoField
+}
+
+// Info is the parsed reply of the INFO command.
+type Info struct {
+	Sections []InfoSection
+	fields   map[string]string
+}
+
+// KeyspaceInfo is a line of the keyspace section, e.g.
+// db0:keys=1,expires=0,avg_ttl=0.
+type KeyspaceInfo struct {
+	DB      int
+	Keys    int64
+	Expires int64
+	AvgTTL  int64
+}
+
+// ParseInfo parses the reply of the INFO command.
+func ParseInfo(text string) *Info {
+	info := &Info{
+		fields: make(map[string]string),
+	}
+	var section *InfoSection
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			info.Sections = append(info.Sections, InfoSection{
+				Name: strings.ToLower(strings.TrimSpace(line[1:])),
+			})
+			section = &info.Sections[len(info.Sections)-1]
+			continue
+		}
+		index := strings.IndexByte(line, ':')
+		if index == -1 {
+			continue
+		}
+		if section == nil {
+			info.Sections = append(info.Sections, InfoSection{})
+			section = &info.Sections[len(info.Sections)-1]
+		}
+		field := InfoField{Key: line[:index], Value: line[index+1:]}
+		section.Fields = append(section.Fields, field)
+		info.fields[field.Key] = field.Value
+	}
+	return info
+}
+
+// Section returns the section with the given lower case name, or nil.
+func (i *Info) Section(name string) *InfoSection {
+	for k := range i.Sections {
+		if i.Sections[k].Name == name {
+			return &i.Sections[k]
+		}
+	}
+	return nil
+}
+
+// Get returns the value of a field of any section.
+func (i *Info) Get(key string) string {
+	return i.fields[key]
+}
+
+// Int returns the value of a field as an integer, 0 if missing.
+func (i *Info) Int(key string) int64 {
+	v, _ := strconv.ParseInt(i.fields[key], 10, 64)
+	return v
+}
+
+// Float returns the value of a field as a float, 0 if missing.
+func (i *Info) Float(key string) float64 {
+	v, _ := strconv.ParseFloat(i.fields[key], 64)
+	return v
+}
+
+// Keyspace returns the databases of the keyspace section.
+func (i *Info) Keyspace() []KeyspaceInfo {
+	section := i.Section("keyspace")
+	if section == nil {
+		return nil
+	}
+	var dbs []KeyspaceInfo
+	for _, f := range section.Fields {
+		if !strings.HasPrefix(f.Key, "db") {
+			continue
+		}
+		db, err := strconv.Atoi(f.Key[2:])
+		if err != nil {
+			continue
+		}
+		ks := KeyspaceInfo{DB: db}
+		for _, kv := range strings.Split(f.Value, ",") {
+			k, v, _ := strings.Cut(kv, "=")
+			n, _ := strconv.ParseInt(v, 10, 64)
+			switch k {
+			case "keys":
+				ks.Keys = n
+			case "expires":
+				ks.Expires = n
+			case "avg_ttl":
+				ks.AvgTTL = n
+			}
+		}
+		dbs = append(dbs, ks)
+	}
+	return dbs
+}
+
+// Info runs INFO with an optional section such as "all".
+func (r *Redis) Info(section string) (*Info, error) {
+	var args []string
+	if section != "" {
+		args = append(args, section)
+	}
+	result, err := r.client.Do("INFO", args...)
+	if err != nil {
+		return nil, err
+	}
+	tlog.Debug("[Redis] INFO", "section", section)
+	return ParseInfo(result.String()), nil
+}
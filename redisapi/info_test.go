@@ -0,0 +1,23 @@
+package redisapi
+
+import "testing"
+
+func TestParseInfo(t *testing.T) {
+	text := "# Server\r\nredis_version:7.2.4\r\nuptime_in_seconds:100\r\n\r\n" +
+		"# Stats\r\nkeyspace_hits:3\r\ninstantaneous_input_kbps:1.50\r\n\r\n" +
+		"# Keyspace\r\ndb0:keys=10,expires=2,avg_ttl=300\r\ndb3:keys=1,expires=0,avg_ttl=0\r\n"
+	info := ParseInfo(text)
+	if len(info.Sections) != 3 || info.Sections[1].Name != "stats" {
+		t.Fatalf("sections %+v", info.Sections)
+	}
+	if info.Get("redis_version") != "7.2.4" || info.Int("keyspace_hits") != 3 || info.Float("instantaneous_input_kbps") != 1.5 {
+		t.Errorf("fields %v", info.fields)
+	}
+	if info.Int("missing") != 0 || info.Section("memory") != nil {
+		t.Error("missing field or section")
+	}
+	ks := info.Keyspace()
+	if len(ks) != 2 || ks[0] != (KeyspaceInfo{DB: 0, Keys: 10, Expires: 2, AvgTTL: 300}) || ks[1].DB != 3 {
+		t.Errorf("keyspace %+v", ks)
+	}
+}
@@ -0,0 +1,81 @@
+package redisapi
+
+import (
+	"strings"
+
+	"github.com/liwnn/redisterm/redis"
+	"github.com/liwnn/redisterm/tlog"
+)
+
+// Message is a pub/sub message or a MONITOR line.
+type Message struct {
+	// Kind is the push type: message, pmessage, smessage, subscribe...,
+	// or monitor for MONITOR output.
+	Kind    string
+	Pattern string
+	Channel string
+	Payload string
+}
+
+// Subscriber streams SUBSCRIBE, PSUBSCRIBE, SSUBSCRIBE or MONITOR replies on
+// a dedicated connection.
+type Subscriber struct {
+	client *redis.Client
+}
+
+// IsStreamCommand return whether cmd switches a connection to push mode.
+func IsStreamCommand(cmd string) bool {
+	switch strings.ToUpper(cmd) {
+	case "SUBSCRIBE", "PSUBSCRIBE", "SSUBSCRIBE", "MONITOR":
+		return true
+	}
+	return false
+}
+
+// NewSubscriber connects a dedicated connection.
+func NewSubscriber(address string, auth string, opts DialOptions) (*Subscriber, error) {
+	client, err := dial(address, auth, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Subscriber{
+		client: client,
+	}, nil
+}
+
+// Start sends the streaming command.
+func (s *Subscriber) Start(cmd string, args ...string) error {
+	tlog.Debug("[Subscriber] subscribe", "cmd", cmd, "args", args)
+	return s.client.Send(cmd, args...)
+}
+
+// Receive blocks until the next message.
+func (s *Subscriber) Receive() (Message, error) {
+	r, err := s.client.Receive()
+	if err != nil {
+		return Message{}, err
+	}
+	if r.Type() != redis.Array {
+		return Message{Kind: "monitor", Payload: r.String()}, nil
+	}
+	elems := r.ToArray()
+	if len(elems) == 0 {
+		return Message{}, nil
+	}
+	m := Message{Kind: elems[0].String()}
+	switch {
+	case m.Kind == "pmessage" && len(elems) >= 4:
+		m.Pattern = elems[1].String()
+		m.Channel = elems[2].String()
+		m.Payload = elems[3].String()
+	case len(elems) >= 3:
+		m.Channel = elems[1].String()
+		m.Payload = elems[2].String()
+	}
+	return m, nil
+}
+
+// Close the connection, which makes a pending Receive return an error.
+func (s *Subscriber) Close() {
+	s.client.Close()
+}
@@ -1,10 +1,12 @@
 package redisapi
 
 import (
+	"crypto/tls"
 	"errors"
 	"net"
 	"strconv"
 
+	"github.com/liwnn/redisterm/audit"
 	"github.com/liwnn/redisterm/redis"
 	"github.com/liwnn/redisterm/tlog"
 )
@@ -14,7 +16,71 @@ type RedisConfig struct {
 	Name string `json:"name"`
 	Host string `json:"host"`
 	Port int    `json:"port"`
-	Auth string `json:"auth"`
+	// Auth is the password, always taken literally. AuthRef, when set,
+	// tells where to read it instead, such as "env:NAME", "cmd:COMMAND" or
+	// "vault:NAME", see package secret.
+	Auth    string `json:"auth"`
+	AuthRef string `json:"auth_ref,omitempty"`
+	// ReadOnly refuses the commands changing the database.
+	ReadOnly bool `json:"readonly,omitempty"`
+	// Guard sets what needs a typed confirmation, the default guard of
+	// the model package when nil.
+	Guard *GuardConfig `json:"guard,omitempty"`
+	// Group is the folder of the connection, such as prod or local, and
+	// Color its colour tag in the connection picker.
+	Group string `json:"group,omitempty"`
+	Color string `json:"color,omitempty"`
+	// Username is the ACL user of Auth, the default user when empty.
+	Username string `json:"username,omitempty"`
+	// Socket is the path of a unix socket, connected instead of Host and
+	// Port.
+	Socket string `json:"socket,omitempty"`
+	// TLS connects with TLS, checking the certificate of the server.
+	TLS bool `json:"tls,omitempty"`
+	// DB is the database selected once connected.
+	DB int `json:"db,omitempty"`
+}
+
+// Address returns the address of the server: host:port or the socket
+// path.
+func (c RedisConfig) Address() string {
+	if c.Socket != "" {
+		return c.Socket
+	}
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// DialOptions returns how to connect to the server of c, besides its
+// address and password.
+func (c RedisConfig) DialOptions() DialOptions {
+	o := DialOptions{Username: c.Username, TLS: c.TLS}
+	if c.Socket != "" {
+		o.Network = "unix"
+	}
+	return o
+}
+
+// DialOptions are how to connect to a server, besides its address and
+// password.
+type DialOptions struct {
+	// Network is "unix" for a socket path, "tcp" when empty.
+	Network string
+	// Username is the ACL user of the password, the default user when
+	// empty.
+	Username string
+	// TLS connects with TLS, checking the certificate of the server.
+	TLS bool
+}
+
+// GuardConfig lists the dangerous operations of a connection.
+type GuardConfig struct {
+	// Commands are command names such as "FLUSHDB", or a command and its
+	// subcommand such as "CONFIG SET". The default ones are guarded when
+	// it is unset, none when it is empty.
+	Commands []string `json:"commands"`
+	// BulkKeys is the number of keys from which deletes are dangerous, 0
+	// to never guard them.
+	BulkKeys int `json:"bulk_keys"`
 }
 
 // KVText kv
@@ -31,22 +97,50 @@ type Redis struct {
 }
 
 // NewRedis new
-func NewRedis(address string, auth string) (*Redis, error) {
-	conn, err := net.Dial("tcp", address)
+func NewRedis(address string, auth string, opts DialOptions) (*Redis, error) {
+	client, err := dial(address, auth, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Redis{
+		client: client,
+	}, nil
+}
+
+func dial(address string, auth string, opts DialOptions) (*redis.Client, error) {
+	network := opts.Network
+	if network == "" {
+		network = "tcp"
+	}
+	var conn net.Conn
+	var err error
+	if opts.TLS {
+		// the server name is the host of address.
+		conn, err = tls.Dial(network, address, &tls.Config{})
+	} else {
+		conn, err = net.Dial(network, address)
+	}
 	if err != nil {
 		return nil, err
 	}
 	client := redis.NewClient(conn)
 	if len(auth) > 0 {
-		r, err := client.Do("AUTH", auth)
+		args := []string{auth}
+		if opts.Username != "" {
+			args = []string{opts.Username, auth}
+		}
+		r, err := client.Do("AUTH", args...)
 		if err != nil {
+			client.Close()
 			return nil, err
 		}
-		tlog.Log("AUTH %v", r.String())
+		tlog.Debug("[Redis] AUTH", "reply", r.String())
 	}
-	return &Redis{
-		client: client,
-	}, nil
+	// older servers and some proxies don't support CLIENT SETNAME.
+	if _, err := client.Do("CLIENT", "SETNAME", ClientName); err != nil {
+		tlog.Warn("[Redis] CLIENT SETNAME", "err", err)
+	}
+	return client, nil
 }
 
 // Close close conn.
@@ -65,7 +159,7 @@ func (r *Redis) GetDatabases() (int, error) {
 		return 0, err
 	}
 
-	tlog.Log("[Redis] config get databases")
+	tlog.Debug("[Redis] CONFIG GET databases")
 	return strconv.Atoi(d[1])
 }
 
@@ -86,7 +180,7 @@ func (r *Redis) Scan(cursor string, match string, count int) (string, []string,
 	nextCursor := d[0].String()
 	keys, _ := d[1].List()
 
-	tlog.Log("[Redis] scan %v MATCH %v COUNT %v", cursor, match, count)
+	tlog.Debug("[Redis] SCAN", "cursor", cursor, "match", match, "count", count)
 	return nextCursor, keys, nil
 }
 
@@ -100,7 +194,7 @@ func (r *Redis) Keys(pattern string) []string {
 	if err != nil {
 		return nil
 	}
-	tlog.Log("[Redis] keys %v", pattern)
+	tlog.Debug("[Redis] KEYS", "pattern", pattern)
 	return d
 }
 
@@ -110,7 +204,7 @@ func (r *Redis) Type(key string) string {
 	if err != nil {
 		return ""
 	}
-	tlog.Log("[Redis] type %v", key)
+	tlog.Debug("[Redis] TYPE", "key", key)
 	return result.String()
 }
 
@@ -121,7 +215,7 @@ func (r *Redis) Get(key string) string {
 		return ""
 	}
 
-	tlog.Log("[Redis] GET %v", key)
+	tlog.Debug("[Redis] GET", "key", key)
 	return result.String()
 }
 
@@ -132,7 +226,7 @@ func (r *Redis) GetByte(key string) ([]byte, error) {
 		return nil, err
 	}
 
-	tlog.Log("[Redis] get %v", key)
+	tlog.Debug("[Redis] GET", "key", key)
 	if result.IsNil() {
 		return nil, errors.New("nil")
 	}
@@ -155,7 +249,7 @@ func (r *Redis) GetHash(key string) []KVText {
 	for i := 0; i < len(elems)/2; i++ {
 		h = append(h, KVText{elems[i*2], elems[i*2+1]})
 	}
-	tlog.Log("[Redis] HGETAll %v", key)
+	tlog.Debug("[Redis] HGETALL", "key", key)
 	return h
 }
 
@@ -170,7 +264,7 @@ func (r *Redis) GetSet(key string) []string {
 	if err != nil {
 		return nil
 	}
-	tlog.Log("[Redis] SMEMBERS %v", key)
+	tlog.Debug("[Redis] SMEMBERS", "key", key)
 	return elems
 }
 
@@ -185,12 +279,12 @@ func (r *Redis) GetList(key string) []string {
 	if err != nil {
 		return nil
 	}
-	tlog.Log("[Redis] lrange %v", key)
+	tlog.Debug("[Redis] LRANGE", "key", key)
 	return elems
 }
 
 func (r *Redis) Do(cmd string, params ...string) (*redis.Reply, error) {
-	tlog.Log("[Redis] cmd[%v] params[%v]", cmd, params)
+	tlog.Debug("[Redis] command", "cmd", cmd, "params", audit.RedactCommand(cmd, params))
 	return r.client.Do(cmd, params...)
 }
 
@@ -203,7 +297,7 @@ func (r *Redis) Select(index int) error {
 	if result.String() != "OK" {
 		return errors.New(result.String())
 	}
-	tlog.Log("[Redis] select %v", index)
+	tlog.Debug("[Redis] SELECT", "index", index)
 	return nil
 }
 
@@ -213,7 +307,7 @@ func (r *Redis) Rename(key, newKey string) error {
 	if err != nil {
 		return err
 	}
-	tlog.Log("[Redis] rename %v -> %v, resp[%v]", key, newKey, result.String())
+	tlog.Debug("[Redis] RENAME", "key", key, "newkey", newKey, "reply", result.String())
 	return nil
 }
 
@@ -223,7 +317,7 @@ func (r *Redis) Set(key, value string) error {
 	if err != nil {
 		return err
 	}
-	tlog.Log("[Redis] set %v -> %v, resp[%v]", key, value, result.String())
+	tlog.Debug("[Redis] SET", "key", key, "reply", result.String())
 	return nil
 }
 
@@ -234,7 +328,7 @@ func (r *Redis) Del(key string) error {
 		return err
 	}
 
-	tlog.Log("[Redis] DEL %v %v", key, result)
+	tlog.Debug("[Redis] DEL", "key", key, "reply", result)
 	return nil
 }
 
@@ -245,25 +339,36 @@ func (r *Redis) FlushDB() error {
 		return err
 	}
 
-	tlog.Log("[Redis] FLUSHDB  %v", result)
+	tlog.Debug("[Redis] FLUSHDB", "reply", result)
 	return nil
 }
 
 func (r *Redis) ZRange(key string, start, stop int) []ZSetText {
 	result, err := r.client.Do("ZRANGE", key, strconv.Itoa(start), strconv.Itoa(stop), "WITHSCORES")
 	if err != nil {
-		tlog.Log("[Redis] ZRange %v", key)
+		tlog.Debug("[Redis] ZRANGE", "key", key)
 		return nil
 	}
 	elems, err := result.List()
 	if err != nil {
-		tlog.Log("[Redis] ZRange %v", key)
+		tlog.Debug("[Redis] ZRANGE", "key", key)
 		return nil
 	}
 	h := make([]ZSetText, 0, len(elems)/2)
 	for i := 0; i < len(elems)/2; i++ {
 		h = append(h, ZSetText{elems[i*2], elems[i*2+1]})
 	}
-	tlog.Log("[Redis] ZRANGE %v %v %v", key, start, stop)
+	tlog.Debug("[Redis] ZRANGE", "key", key, "start", start, "stop", stop)
 	return h
 }
+
+// Pipeline sends commands in one round trip. Error replies are returned
+// as replies, see redis.Reply.Err.
+func (r *Redis) Pipeline(cmds [][]string) ([]*redis.Reply, error) {
+	replies, err := r.client.DoMulti(cmds)
+	if err != nil {
+		return nil, err
+	}
+	tlog.Debug("[Redis] pipeline", "commands", len(cmds))
+	return replies, nil
+}
@@ -3,7 +3,7 @@ package redisapi
 import "testing"
 
 func TestRedis(t *testing.T) {
-	client, err := NewRedis("127.0.0.1:9898", "")
+	client, err := NewRedis("127.0.0.1:9898", "", DialOptions{})
 	if err != nil {
 		return
 	}
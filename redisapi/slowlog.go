@@ -0,0 +1,62 @@
+package redisapi
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/liwnn/redisterm/tlog"
+)
+
+// SlowLogEntry is an entry of SLOWLOG GET.
+type SlowLogEntry struct {
+	ID         int64
+	Time       time.Time
+	Duration   time.Duration
+	Args       []string
+	ClientAddr string
+	ClientName string
+}
+
+// SlowLog returns the latest count slow log entries, newest first.
+func (r *Redis) SlowLog(count int) ([]SlowLogEntry, error) {
+	result, err := r.client.Do("SLOWLOG", "GET", strconv.Itoa(count))
+	if err != nil {
+		return nil, err
+	}
+	tlog.Debug("[Redis] SLOWLOG GET", "count", count)
+
+	items := result.ToArray()
+	entries := make([]SlowLogEntry, 0, len(items))
+	for _, item := range items {
+		fields := item.ToArray()
+		if len(fields) < 4 {
+			continue
+		}
+		id, _ := fields[0].Int()
+		ts, _ := fields[1].Int()
+		micros, _ := fields[2].Int()
+		args, _ := fields[3].List()
+		e := SlowLogEntry{
+			ID:       int64(id),
+			Time:     time.Unix(int64(ts), 0),
+			Duration: time.Duration(micros) * time.Microsecond,
+			Args:     args,
+		}
+		// client address and name were added in Redis 4.0
+		if len(fields) >= 6 {
+			e.ClientAddr = fields[4].String()
+			e.ClientName = fields[5].String()
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// SlowLogReset clears the slow log.
+func (r *Redis) SlowLogReset() error {
+	if _, err := r.client.Do("SLOWLOG", "RESET"); err != nil {
+		return err
+	}
+	tlog.Debug("[Redis] SLOWLOG RESET")
+	return nil
+}
@@ -0,0 +1,110 @@
+package redisapi
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultPort is the port of the URLs without one.
+const DefaultPort = 6379
+
+// ParseURL parses a URL of redis-cli: redis://[[user]:password@]host[:port][/db],
+// rediss:// for TLS, or unix://[[user]:password@]/path[?db=n]. A user
+// without password is taken as the password, as by redis-cli. The
+// connection is named after its address.
+func ParseURL(rawURL string) (RedisConfig, error) {
+	var c RedisConfig
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return c, err
+	}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			c.Username, c.Auth = u.User.Username(), password
+		} else {
+			c.Auth = u.User.Username()
+		}
+	}
+	db := u.Query().Get("db")
+	switch u.Scheme {
+	case "redis", "rediss":
+		c.TLS = u.Scheme == "rediss"
+		c.Host = u.Hostname()
+		if c.Host == "" {
+			c.Host = "127.0.0.1"
+		}
+		c.Port = DefaultPort
+		if port := u.Port(); port != "" {
+			if c.Port, err = strconv.Atoi(port); err != nil {
+				return c, fmt.Errorf("invalid port %q", port)
+			}
+		}
+		if path := strings.Trim(u.Path, "/"); path != "" {
+			db = path
+		}
+	case "unix":
+		if u.Path == "" {
+			return c, fmt.Errorf("no socket path in %q", rawURL)
+		}
+		c.Socket = u.Path
+	default:
+		return c, fmt.Errorf("unknown scheme %q, not redis, rediss or unix", u.Scheme)
+	}
+	if db != "" {
+		if c.DB, err = strconv.Atoi(db); err != nil || c.DB < 0 {
+			return c, fmt.Errorf("invalid database %q", db)
+		}
+	}
+	c.Name = c.Address()
+	return c, nil
+}
+
+// URL returns the URL of c without its password, host:port when the
+// address says it all.
+func (c RedisConfig) URL() string {
+	u := url.URL{Scheme: "redis"}
+	if c.Username != "" {
+		u.User = url.User(c.Username)
+	}
+	query := url.Values{}
+	switch {
+	case c.Socket != "":
+		u.Scheme = "unix"
+		u.Path = c.Socket
+		if c.DB != 0 {
+			query.Set("db", strconv.Itoa(c.DB))
+		}
+	case !c.TLS && c.Username == "" && c.DB == 0:
+		return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	default:
+		if c.TLS {
+			u.Scheme = "rediss"
+		}
+		u.Host = net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+		if c.DB != 0 {
+			u.Path = "/" + strconv.Itoa(c.DB)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// ParseAddress parses host:port or a URL of ParseURL.
+func ParseAddress(address string) (RedisConfig, error) {
+	if strings.Contains(address, "://") {
+		return ParseURL(address)
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return RedisConfig{}, err
+	}
+	c := RedisConfig{Host: host}
+	if c.Port, err = strconv.Atoi(port); err != nil {
+		return c, fmt.Errorf("invalid port %q", port)
+	}
+	c.Name = c.Address()
+	return c, nil
+}
@@ -0,0 +1,121 @@
+// Package secret resolves the passwords of the connections from references
+// kept in the config instead of the passwords: "env:NAME" reads an
+// environment variable, "cmd:COMMAND" runs a command such as
+// "cmd:pass show redis/prod", "vault:NAME" reads the encrypted vault.
+// References are kept apart from the passwords, which are always taken
+// literally whatever they start with.
+package secret
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// commandTimeout bounds the commands run by the cmd: source.
+const commandTimeout = 30 * time.Second
+
+// Source resolves the references of a scheme, the part after "scheme:".
+type Source interface {
+	Secret(ref string) (string, error)
+}
+
+// SourceFunc is a Source as a function.
+type SourceFunc func(ref string) (string, error)
+
+// Secret calls f.
+func (f SourceFunc) Secret(ref string) (string, error) {
+	return f(ref)
+}
+
+// Resolver resolves the references of the registered sources.
+type Resolver struct {
+	sources map[string]Source
+}
+
+// NewResolver returns a resolver with the env: and cmd: sources.
+func NewResolver() *Resolver {
+	r := &Resolver{sources: make(map[string]Source)}
+	r.Register("env", SourceFunc(Env))
+	r.Register("cmd", SourceFunc(Command))
+	return r
+}
+
+// Register makes scheme: references be resolved by s.
+func (r *Resolver) Register(scheme string, s Source) {
+	r.sources[scheme] = s
+}
+
+// Parse splits reference into a registered scheme and the reference of
+// its source.
+func (r *Resolver) Parse(reference string) (scheme, ref string, err error) {
+	scheme, ref, found := strings.Cut(reference, ":")
+	if !found {
+		return "", "", fmt.Errorf("%q is not a scheme:reference", reference)
+	}
+	if _, ok := r.sources[scheme]; !ok {
+		return "", "", fmt.Errorf("unknown secret scheme %q", scheme)
+	}
+	return scheme, ref, nil
+}
+
+// Resolve returns the password reference refers to.
+func (r *Resolver) Resolve(reference string) (string, error) {
+	scheme, ref, err := r.Parse(reference)
+	if err != nil {
+		return "", err
+	}
+	s, err := r.sources[scheme].Secret(ref)
+	if err != nil {
+		return "", fmt.Errorf("%v secret %q: %w", scheme, ref, err)
+	}
+	return s, nil
+}
+
+// Password returns the password of a connection: the one reference refers
+// to, or auth taken literally when reference is empty.
+func (r *Resolver) Password(auth, reference string) (string, error) {
+	if reference == "" {
+		return auth, nil
+	}
+	return r.Resolve(reference)
+}
+
+// Env returns the environment variable name, which must be set.
+func Env(name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", errors.New("not set")
+	}
+	return v, nil
+}
+
+// Command runs command with the shell and returns the first line of its
+// output, as printed by pass.
+func Command(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %v", err, msg)
+		}
+		return "", err
+	}
+	line, _, _ := strings.Cut(string(out), "\n")
+	return strings.TrimRight(line, "\r"), nil
+}
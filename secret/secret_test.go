@@ -0,0 +1,118 @@
+package secret
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("REDIS_TERM_TEST_AUTH", "from-env")
+	r := NewResolver()
+	r.Register("vault", SourceFunc(func(ref string) (string, error) {
+		return "", ErrLocked
+	}))
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"env:REDIS_TERM_TEST_AUTH", "from-env"},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, struct {
+			ref  string
+			want string
+		}{"cmd:printf 'from-cmd\\nmore'", "from-cmd"})
+	}
+	for _, tt := range tests {
+		got, err := r.Resolve(tt.ref)
+		if err != nil || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", tt.ref, got, err, tt.want)
+		}
+	}
+
+	for _, ref := range []string{"secret", "pa:ss", ""} {
+		if _, err := r.Resolve(ref); err == nil {
+			t.Errorf("Resolve(%q) no error", ref)
+		}
+	}
+
+	if _, err := r.Resolve("env:REDIS_TERM_TEST_UNSET"); err == nil {
+		t.Error("unset variable resolved")
+	}
+	if _, err := r.Resolve("vault:prod"); !errors.Is(err, ErrLocked) {
+		t.Errorf("vault error %v", err)
+	}
+}
+
+func TestPasswordLiteral(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh")
+	}
+	ran := filepath.Join(t.TempDir(), "ran")
+	r := NewResolver()
+	for _, auth := range []string{"cmd:touch " + ran, "env:HOME", "vault:prod"} {
+		got, err := r.Password(auth, "")
+		if err != nil || got != auth {
+			t.Errorf("Password(%q) = %q, %v", auth, got, err)
+		}
+	}
+	if _, err := os.Stat(ran); err == nil {
+		t.Error("the plaintext password was run as a command")
+	}
+	if got, err := r.Password("ignored", "cmd:echo ref"); err != nil || got != "ref" {
+		t.Errorf("Password with reference = %q, %v", got, err)
+	}
+}
+
+func TestVault(t *testing.T) {
+	vaultIterations = 1000
+	filename := filepath.Join(t.TempDir(), "redis-term.vault")
+	v := NewVault(filename)
+	if _, err := v.Secret("prod"); !errors.Is(err, ErrLocked) {
+		t.Errorf("locked vault read: %v", err)
+	}
+	if err := v.Unlock("master"); err != nil {
+		t.Fatal(err)
+	}
+	name, err := v.Store("prod", "p1")
+	if err != nil || name != "prod" {
+		t.Fatalf("Store = %q, %v", name, err)
+	}
+	if name, _ := v.Store("prod", "p2"); name != "prod-2" {
+		t.Errorf("Store of another secret = %q", name)
+	}
+	if name, _ := v.Store("prod", "p1"); name != "prod" {
+		t.Errorf("Store of the same secret = %q", name)
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode %v", info.Mode().Perm())
+	}
+	b, _ := os.ReadFile(filename)
+	if strings.Contains(string(b), "p1") || strings.Contains(string(b), "prod") {
+		t.Errorf("vault file in clear: %s", b)
+	}
+
+	v = NewVault(filename)
+	if err := v.Unlock("wrong"); !errors.Is(err, ErrPassphrase) {
+		t.Errorf("Unlock with a wrong passphrase: %v", err)
+	}
+	if err := v.Unlock("master"); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := v.Secret("prod-2"); err != nil || s != "p2" {
+		t.Errorf("Secret = %q, %v", s, err)
+	}
+	if _, err := v.Secret("dev"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown secret: %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+var (
+	// ErrLocked is returned when the vault is read before Unlock.
+	ErrLocked = errors.New("the vault is locked")
+	// ErrPassphrase is returned by Unlock when the passphrase is wrong.
+	ErrPassphrase = errors.New("wrong passphrase")
+	// ErrNotFound is returned by Secret for an unknown name.
+	ErrNotFound = errors.New("not in the vault")
+)
+
+// vaultIterations is the PBKDF2 iteration count of new vaults.
+var vaultIterations = 600000
+
+// vaultFile is the vault as stored: the secrets as JSON, encrypted with
+// AES-256-GCM under a key derived from the passphrase with PBKDF2-SHA256.
+type vaultFile struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// Vault is a local file of secrets encrypted with a master passphrase.
+// It is the Source of the vault: scheme.
+type Vault struct {
+	filename string
+
+	mu      sync.Mutex
+	file    *vaultFile
+	key     []byte
+	secrets map[string]string
+}
+
+// NewVault returns the vault of filename, locked. The file is created by
+// the first Unlock.
+func NewVault(filename string) *Vault {
+	return &Vault{filename: filename}
+}
+
+// Exists tells if the vault file was created.
+func (v *Vault) Exists() bool {
+	_, err := os.Stat(v.filename)
+	return err == nil
+}
+
+// Locked tells if Unlock must be called before reading the vault.
+func (v *Vault) Locked() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.key == nil
+}
+
+// Unlock decrypts the vault with passphrase, or creates an empty vault
+// encrypted with it when there is no file.
+func (v *Vault) Unlock(passphrase string) error {
+	if passphrase == "" {
+		return ErrPassphrase
+	}
+	b, err := os.ReadFile(v.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		f := &vaultFile{Version: 1, Iterations: vaultIterations, Salt: make([]byte, 16)}
+		if _, err := rand.Read(f.Salt); err != nil {
+			return err
+		}
+		key, err := deriveKey(passphrase, f)
+		if err != nil {
+			return err
+		}
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v.file, v.key, v.secrets = f, key, make(map[string]string)
+		return v.save()
+	}
+	if err != nil {
+		return err
+	}
+
+	var f vaultFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("%v: %w", v.filename, err)
+	}
+	if f.Version != 1 {
+		return fmt.Errorf("%v: unsupported version %v", v.filename, f.Version)
+	}
+	key, err := deriveKey(passphrase, &f)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	plain, err := gcm.Open(nil, f.Nonce, f.Data, nil)
+	if err != nil {
+		return ErrPassphrase
+	}
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return fmt.Errorf("%v: %w", v.filename, err)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.file, v.key, v.secrets = &f, key, secrets
+	return nil
+}
+
+// Secret returns the secret name, it is the Source of vault: references.
+func (v *Vault) Secret(name string) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.key == nil {
+		return "", ErrLocked
+	}
+	s, ok := v.secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return s, nil
+}
+
+// Names returns the names of the secrets, sorted.
+func (v *Vault) Names() ([]string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.key == nil {
+		return nil, ErrLocked
+	}
+	names := make([]string, 0, len(v.secrets))
+	for name := range v.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Store saves secret under name, or under name-2, name-3... when name
+// holds another secret, and returns the name used.
+func (v *Vault) Store(name, secret string) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.key == nil {
+		return "", ErrLocked
+	}
+	key := name
+	for i := 2; ; i++ {
+		s, ok := v.secrets[key]
+		if !ok {
+			break
+		}
+		if s == secret {
+			return key, nil
+		}
+		key = name + "-" + strconv.Itoa(i)
+	}
+	v.secrets[key] = secret
+	if err := v.save(); err != nil {
+		delete(v.secrets, key)
+		return "", err
+	}
+	return key, nil
+}
+
+// Delete removes the secret name.
+func (v *Vault) Delete(name string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.key == nil {
+		return ErrLocked
+	}
+	if _, ok := v.secrets[name]; !ok {
+		return nil
+	}
+	delete(v.secrets, name)
+	return v.save()
+}
+
+// save encrypts the secrets with a new nonce and writes the file, readable
+// by the user only.
+func (v *Vault) save() error {
+	plain, err := json.Marshal(v.secrets)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(v.key)
+	if err != nil {
+		return err
+	}
+	f := *v.file
+	f.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Data = gcm.Seal(nil, f.Nonce, plain, nil)
+	b, err := json.MarshalIndent(&f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(v.filename), 0700); err != nil {
+		return err
+	}
+	tmp := v.filename + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, v.filename); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	v.file = &f
+	return nil
+}
+
+func deriveKey(passphrase string, f *vaultFile) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, passphrase, f.Salt, f.Iterations, 32)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
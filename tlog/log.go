@@ -1,21 +1,215 @@
+// Package tlog is the leveled, structured log of redis-term. Records go to
+// the console of the UI, set by SetConsole, and to a log file opened by
+// OpenFile.
 package tlog
 
 import (
+	"context"
+	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
+	"strings"
+	"sync"
+	"time"
 )
 
+// Levels of the records, as slog.
+const (
+	LevelDebug = slog.LevelDebug
+	LevelInfo  = slog.LevelInfo
+	LevelWarn  = slog.LevelWarn
+	LevelError = slog.LevelError
+)
+
+// Record is a record as shown by the console: the message followed by its
+// fields as key=value.
+type Record struct {
+	Time  time.Time
+	Level slog.Level
+	Text  string
+}
+
+// String formats r as a line of the console.
+func (r Record) String() string {
+	return fmt.Sprintf("%v %-5v %v", r.Time.Format("2006/01/02 15:04:05"), r.Level, r.Text)
+}
+
 var (
-	global = log.New(os.Stderr, "", log.LstdFlags)
+	mu      sync.Mutex
+	console func(r Record)
+	output  io.Writer = os.Stderr
+	file    *os.File
+	fileLog slog.Handler
+
+	global = slog.New(&handler{})
 )
 
-// SetLogger set output
-func SetLogger(logger io.Writer) {
-	global.SetOutput(logger)
+// SetConsole makes the records be passed to f instead of written to
+// stderr. f is called from any goroutine.
+func SetConsole(f func(r Record)) {
+	mu.Lock()
+	defer mu.Unlock()
+	console = f
+}
+
+// SetLogger set output, the records are written to w as lines.
+func SetLogger(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	console = nil
+	output = w
+}
+
+// OpenFile appends the records at level and above to filename, as
+// key=value lines. It replaces the file opened before.
+func OpenFile(filename string, level slog.Level) error {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if file != nil {
+		file.Close()
+	}
+	file = f
+	fileLog = slog.NewTextHandler(f, &slog.HandlerOptions{Level: level})
+	return nil
+}
+
+// Close closes the log file.
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if file == nil {
+		return nil
+	}
+	err := file.Close()
+	file, fileLog = nil, nil
+	return err
+}
+
+// ParseLevel parses debug, info, warn or error.
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	err := l.UnmarshalText([]byte(s))
+	return l, err
 }
 
-// Log log
+// Log log at info level, formatted as fmt.Printf.
 func Log(format string, params ...interface{}) {
-	global.Printf(format, params...)
+	global.Info(fmt.Sprintf(format, params...))
+}
+
+// Debug logs msg with the key-value pairs args at debug level.
+func Debug(msg string, args ...any) {
+	global.Debug(msg, args...)
+}
+
+// Info logs msg with the key-value pairs args at info level.
+func Info(msg string, args ...any) {
+	global.Info(msg, args...)
+}
+
+// Warn logs msg with the key-value pairs args at warn level.
+func Warn(msg string, args ...any) {
+	global.Warn(msg, args...)
+}
+
+// Error logs msg with the key-value pairs args at error level.
+func Error(msg string, args ...any) {
+	global.Error(msg, args...)
+}
+
+// With returns a logger adding the key-value pairs args to its records.
+func With(args ...any) *slog.Logger {
+	return global.With(args...)
+}
+
+// handler passes the records to the console and the log file. The
+// console takes every level, it filters them itself.
+type handler struct {
+	attrs  []slog.Attr
+	groups []string
+}
+
+func (h *handler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Message)
+	prefix := ""
+	if len(h.groups) > 0 {
+		prefix = strings.Join(h.groups, ".") + "."
+	}
+	for _, a := range h.attrs {
+		writeAttr(&b, a.Key, a.Value)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&b, prefix+a.Key, a.Value)
+		return true
+	})
+	rec := Record{Time: r.Time, Level: r.Level, Text: b.String()}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if console != nil {
+		console(rec)
+	} else {
+		fmt.Fprintln(output, rec)
+	}
+	if fileLog != nil && fileLog.Enabled(ctx, r.Level) {
+		return h.fileHandler().Handle(ctx, r)
+	}
+	return nil
+}
+
+// fileHandler returns the handler of the log file with the attributes and
+// groups of h.
+func (h *handler) fileHandler() slog.Handler {
+	fh := fileLog
+	if len(h.attrs) > 0 {
+		fh = fh.WithAttrs(h.attrs)
+	}
+	for _, g := range h.groups {
+		fh = fh.WithGroup(g)
+	}
+	return fh
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	prefix := ""
+	if len(h.groups) > 0 {
+		prefix = strings.Join(h.groups, ".") + "."
+	}
+	n := &handler{attrs: append([]slog.Attr(nil), h.attrs...), groups: h.groups}
+	for _, a := range attrs {
+		n.attrs = append(n.attrs, slog.Attr{Key: prefix + a.Key, Value: a.Value})
+	}
+	return n
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &handler{attrs: h.attrs, groups: append(append([]string(nil), h.groups...), name)}
+}
+
+func writeAttr(b *strings.Builder, key string, v slog.Value) {
+	v = v.Resolve()
+	if v.Kind() == slog.KindGroup {
+		for _, a := range v.Group() {
+			writeAttr(b, key+"."+a.Key, a.Value)
+		}
+		return
+	}
+	s := v.String()
+	if s == "" || strings.ContainsAny(s, " =\"\n") {
+		s = fmt.Sprintf("%q", s)
+	}
+	fmt.Fprintf(b, " %v=%v", key, s)
 }
@@ -0,0 +1,70 @@
+package tlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConsole(t *testing.T) {
+	var records []Record
+	SetConsole(func(r Record) {
+		records = append(records, r)
+	})
+	defer SetLogger(os.Stderr)
+
+	Debug("[Redis] SCAN", "cursor", 0, "match", "user:*")
+	With("conn", "local").WithGroup("db").Error("failed", "index", 1, "err", "ERR x y")
+	Log("%v keys", 3)
+
+	want := []string{
+		"[Redis] SCAN cursor=0 match=user:*",
+		`failed conn=local db.index=1 db.err="ERR x y"`,
+		"3 keys",
+	}
+	if len(records) != len(want) {
+		t.Fatalf("records %v", records)
+	}
+	for i, r := range records {
+		if r.Text != want[i] {
+			t.Errorf("record %v = %q, want %q", i, r.Text, want[i])
+		}
+	}
+	if records[0].Level != LevelDebug || records[1].Level != LevelError || records[2].Level != LevelInfo {
+		t.Errorf("levels %v %v %v", records[0].Level, records[1].Level, records[2].Level)
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	SetConsole(func(Record) {})
+	defer SetLogger(os.Stderr)
+
+	filename := filepath.Join(t.TempDir(), "redis-term.log")
+	if err := OpenFile(filename, LevelWarn); err != nil {
+		t.Fatal(err)
+	}
+	Info("[App] hidden")
+	Warn("[App] shown", "key", "a b")
+	if err := Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(b)
+	if strings.Contains(s, "hidden") || !strings.Contains(s, `level=WARN msg="[App] shown" key="a b"`) {
+		t.Errorf("log file %q", s)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if l, err := ParseLevel("warn"); err != nil || l != LevelWarn {
+		t.Errorf("ParseLevel(warn) = %v, %v", l, err)
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("ParseLevel(loud) succeeded")
+	}
+}
@@ -2,6 +2,9 @@ package view
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/liwnn/redisterm/redis"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -11,6 +14,9 @@ const (
 	PromptColor = "[#00aa00]"
 	InputColor  = "[blue]"
 	ResultColor = "[white]"
+
+	// ReadOnlyPromptColor is the prompt color of read-only connections.
+	ReadOnlyPromptColor = "[red]"
 )
 
 type CmdConsole struct {
@@ -18,11 +24,24 @@ type CmdConsole struct {
 	view  *tview.TextView
 	input *tview.InputField
 
-	onCmdLineEnter func(string)
+	onCmdLineEnter func([]string)
 	title          string
 
-	address string
-	index   int
+	address  string
+	index    int
+	readOnly bool
+	mode     redis.OutputMode
+
+	hint         *tview.TextView
+	history      *History
+	completeFunc func(text string) []string
+	hintFunc     func(text string) string
+
+	// reverse search state, active while searching is true.
+	searching   bool
+	searchQuery string
+	searchIndex int
+	searchDraft string
 }
 
 func NewCmdConsole(title string) *CmdConsole {
@@ -42,37 +61,202 @@ func (c *CmdConsole) init() {
 	cmdLine.SetPlaceholderStyle(tcell.StyleDefault.Foreground(ThemeControlFG).Background(ThemeControlBG))
 	cmdLine.SetFieldStyle(tcell.StyleDefault.Foreground(ThemeControlFG).Background(ThemeControlBG))
 	cmdLine.SetInputCapture(c.OnEnter)
+	cmdLine.SetChangedFunc(c.updateHint)
+
+	hint := tview.NewTextView()
+	hint.SetTextColor(tcell.ColorGray)
 
 	flex := tview.NewFlex().
 		SetDirection(tview.FlexRow).
 		AddItem(view, 0, 1, false).
+		AddItem(hint, 1, 0, false).
 		AddItem(cmdLine, 1, 1, true)
 	flex.SetBorder(true)
 
 	c.view = view
 	c.input = cmdLine
+	c.hint = hint
 	c.Flex = flex
 }
 
 func (c *CmdConsole) OnEnter(event *tcell.EventKey) *tcell.EventKey {
+	if c.searching {
+		return c.onSearchKey(event)
+	}
 	switch event.Key() {
 	case tcell.KeyEnter:
 		text := c.input.GetText()
 		c.input.SetText("")
+		if c.history != nil {
+			c.history.Add(text)
+		}
 
 		fmt.Fprintln(c, text)
-		if c.onCmdLineEnter != nil {
-			fmt.Fprint(c.view, ResultColor)
-			c.onCmdLineEnter(text)
+		fmt.Fprint(c.view, ResultColor)
+		if args, err := redis.SplitArgs(text); err != nil {
+			fmt.Fprintln(c, err)
+		} else if len(args) > 0 && c.onCmdLineEnter != nil {
+			c.onCmdLineEnter(args)
 		}
 
 		c.printPromt()
 		return nil
+	case tcell.KeyCtrlO:
+		c.mode = c.mode.Next()
+		c.updateTitle()
+		return nil
+	case tcell.KeyUp:
+		if c.history != nil {
+			if text, ok := c.history.Prev(c.input.GetText()); ok {
+				c.input.SetText(text)
+			}
+		}
+		return nil
+	case tcell.KeyDown:
+		if c.history != nil {
+			if text, ok := c.history.Next(); ok {
+				c.input.SetText(text)
+			}
+		}
+		return nil
+	case tcell.KeyCtrlR:
+		if c.history != nil {
+			c.searching = true
+			c.searchQuery = ""
+			c.searchIndex = c.history.Len() - 1
+			c.searchDraft = c.input.GetText()
+			c.updateSearch()
+		}
+		return nil
+	case tcell.KeyTab:
+		c.complete()
+		return nil
 	}
 	return event
 }
 
-func (c *CmdConsole) SetEnterHandler(handler func(string)) {
+// onSearchKey handles keys during Ctrl-R reverse search. Enter runs the
+// match, Esc or Ctrl-G restores the input, other control keys keep the
+// match for editing.
+func (c *CmdConsole) onSearchKey(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyRune:
+		c.searchQuery += string(event.Rune())
+		c.updateSearch()
+		return nil
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(c.searchQuery) > 0 {
+			r := []rune(c.searchQuery)
+			c.searchQuery = string(r[:len(r)-1])
+			c.searchIndex = c.history.Len() - 1
+			c.updateSearch()
+		}
+		return nil
+	case tcell.KeyCtrlR:
+		if c.searchIndex > 0 {
+			c.searchIndex--
+			c.updateSearch()
+		}
+		return nil
+	case tcell.KeyEscape, tcell.KeyCtrlG:
+		c.stopSearch()
+		c.input.SetText(c.searchDraft)
+		return nil
+	case tcell.KeyEnter:
+		c.stopSearch()
+		return c.OnEnter(event)
+	}
+	c.stopSearch()
+	return event
+}
+
+func (c *CmdConsole) updateSearch() {
+	index := c.history.Search(c.searchQuery, c.searchIndex)
+	label := "(reverse-i-search)`" + c.searchQuery + "': "
+	if index == -1 {
+		label = "(failed " + label[1:]
+	} else {
+		c.searchIndex = index
+		c.input.SetText(c.history.Line(index))
+	}
+	c.input.SetLabel(tview.Escape(label))
+}
+
+func (c *CmdConsole) stopSearch() {
+	c.searching = false
+	c.input.SetLabel("")
+	c.history.Reset()
+}
+
+// complete completes the input with the candidates of the complete
+// function. Ambiguous candidates are listed in the console.
+func (c *CmdConsole) complete() {
+	if c.completeFunc == nil {
+		return
+	}
+	text := c.input.GetText()
+	candidates := c.completeFunc(text)
+	switch len(candidates) {
+	case 0:
+		return
+	case 1:
+		c.input.SetText(candidates[0])
+		return
+	}
+	prefix := candidates[0]
+	for _, v := range candidates[1:] {
+		for !strings.HasPrefix(v, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(prefix) > len(text) {
+		c.input.SetText(prefix)
+		return
+	}
+	fmt.Fprint(c.view, "\n", ResultColor)
+	fmt.Fprintln(c, strings.Join(candidates, "  "))
+	c.printPromt()
+}
+
+func (c *CmdConsole) updateHint(text string) {
+	if c.hintFunc == nil {
+		return
+	}
+	c.hint.SetText(c.hintFunc(text))
+}
+
+// SetHistory set the history used for navigation and reverse search.
+func (c *CmdConsole) SetHistory(h *History) {
+	c.history = h
+}
+
+// SetCompleteFunc set the function returning the completed input texts
+// for a partial input, used on Tab.
+func (c *CmdConsole) SetCompleteFunc(f func(text string) []string) {
+	c.completeFunc = f
+}
+
+// SetHintFunc set the function returning the syntax hint for an input.
+func (c *CmdConsole) SetHintFunc(f func(text string) string) {
+	c.hintFunc = f
+}
+
+// OutputMode return how replies are rendered, toggled with Ctrl-O.
+func (c *CmdConsole) OutputMode() redis.OutputMode {
+	return c.mode
+}
+
+func (c *CmdConsole) updateTitle() {
+	if c.mode == redis.OutputCLI {
+		c.Flex.SetTitle("")
+		return
+	}
+	c.Flex.SetTitle(fmt.Sprintf(" output: %v (Ctrl-O) ", c.mode))
+}
+
+// SetEnterHandler set the handler called with the arguments of each
+// command line, split with redis-cli quoting rules.
+func (c *CmdConsole) SetEnterHandler(handler func(args []string)) {
 	c.onCmdLineEnter = handler
 }
 
@@ -84,8 +268,12 @@ func (c *CmdConsole) View() *tview.TextView {
 	return c.view
 }
 
+// Write writes text to the console verbatim, without style tags.
 func (c *CmdConsole) Write(p []byte) (n int, err error) {
-	return c.view.Write(p)
+	if _, err := c.view.Write([]byte(tview.Escape(string(p)))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
 }
 
 func (c *CmdConsole) SetPromt(address string, index int) {
@@ -102,11 +290,37 @@ func (c *CmdConsole) SetPromt(address string, index int) {
 	c.printPromt()
 }
 
+// Resume runs a command entered before, such as once confirmed: it echoes
+// text after the prompt, calls run to print the result and prompts again.
+func (c *CmdConsole) Resume(text string, run func()) {
+	fmt.Fprintln(c, text)
+	fmt.Fprint(c.view, ResultColor)
+	run()
+	c.printPromt()
+}
+
 func (c *CmdConsole) SetIndex(index int) {
 	c.index = index
 }
 
+// SetReadOnly shows the prompt in red with a (ro) mark when set.
+func (c *CmdConsole) SetReadOnly(readOnly bool) {
+	if c.readOnly == readOnly {
+		return
+	}
+	c.readOnly = readOnly
+	if c.address != "" {
+		fmt.Fprintf(c.view, "\n")
+		c.printPromt()
+	}
+}
+
 func (c *CmdConsole) printPromt() {
+	if c.readOnly {
+		fmt.Fprintf(c.view, "%v%v:%v(ro)> %v", ReadOnlyPromptColor, c.address, c.index, InputColor)
+		c.view.ScrollToEnd()
+		return
+	}
 	fmt.Fprintf(c.view, "%v%v:%v> %v", PromptColor, c.address, c.index, InputColor)
 	c.view.ScrollToEnd()
 }
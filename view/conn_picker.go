@@ -0,0 +1,310 @@
+package view
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// TagColors are the colour tags a connection can have, "" for none.
+var TagColors = []string{"", "red", "yellow", "green", "blue", "magenta", "cyan"}
+
+// ConnItem is a connection shown by the picker.
+type ConnItem struct {
+	Name    string
+	Address string
+	Group   string
+	// Color is a colour tag such as red for production, "" for none.
+	Color    string
+	ReadOnly bool
+}
+
+// label returns the name with its colour tag, for tview.
+func (c ConnItem) label() string {
+	color := c.Color
+	if color == "" {
+		color = "gray"
+	}
+	return fmt.Sprintf("[%v]●[-] %v", color, tview.Escape(c.Name))
+}
+
+// connRow is a row of the picker: a group header, or the connection
+// items[index].
+type connRow struct {
+	group string
+	index int
+}
+
+// groupConnections returns the rows of the connections matching filter:
+// the groups sorted by name, the connections without group last, each
+// group in the order of items.
+func groupConnections(items []ConnItem, filter string) []connRow {
+	filter = strings.ToLower(filter)
+	groups := make(map[string][]int)
+	var names []string
+	for i, c := range items {
+		text := strings.ToLower(c.Name + " " + c.Address + " " + c.Group)
+		if filter != "" && !strings.Contains(text, filter) {
+			continue
+		}
+		if _, ok := groups[c.Group]; !ok {
+			names = append(names, c.Group)
+		}
+		groups[c.Group] = append(groups[c.Group], i)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == "" || names[j] == "" {
+			return names[j] == ""
+		}
+		return names[i] < names[j]
+	})
+	var rows []connRow
+	for _, name := range names {
+		if len(names) > 1 || name != "" {
+			rows = append(rows, connRow{group: name, index: -1})
+		}
+		for _, i := range groups[name] {
+			rows = append(rows, connRow{group: name, index: i})
+		}
+	}
+	return rows
+}
+
+// ConnPicker is the searchable list of the connections, by group.
+// Keys: Enter open, e edit, n new, i import, c duplicate, d delete, K/J or
+// Shift+Up/Down move in the group, / search, Esc close.
+type ConnPicker struct {
+	*tview.Flex
+	filter *tview.InputField
+	table  *tview.Table
+
+	items   []ConnItem
+	rows    []connRow
+	current int
+	// keep is the connection selected by the next SetItems, after it was
+	// moved or duplicated, -1 for current.
+	keep int
+
+	setFocus  func(p tview.Primitive)
+	close     func()
+	selected  func(index int)
+	edit      func(index int)
+	add       func()
+	load      func()
+	duplicate func(index int)
+	remove    func(index int)
+	move      func(index, to int)
+}
+
+// NewConnPicker new, setFocus moves the focus between the search input and
+// the list, close hides the picker.
+func NewConnPicker(setFocus func(p tview.Primitive), close func()) *ConnPicker {
+	p := &ConnPicker{
+		setFocus: setFocus,
+		close:    close,
+		keep:     -1,
+	}
+	p.init()
+	return p
+}
+
+func (p *ConnPicker) init() {
+	filter := tview.NewInputField().SetLabel("Search: ")
+	filter.SetFieldStyle(tcell.StyleDefault.Foreground(ThemeControlFG).Background(ThemeControlBG))
+	filter.SetChangedFunc(func(string) {
+		p.render(-1)
+	})
+	filter.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEscape:
+			p.close()
+		case tcell.KeyEnter:
+			// opens the first match.
+			row, _ := p.table.GetSelection()
+			if index := p.indexAt(row); index >= 0 && p.selected != nil {
+				p.close()
+				p.selected(index)
+			}
+		default:
+			p.setFocus(p.table)
+		}
+	})
+	filter.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyDown {
+			p.setFocus(p.table)
+			return nil
+		}
+		return event
+	})
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetSelectedStyle(tcell.StyleDefault.Foreground(ThemeBtnRenameFG).Background(ThemeBtnRenameBG))
+	table.SetSelectedFunc(func(row, column int) {
+		if index := p.indexAt(row); index >= 0 && p.selected != nil {
+			p.close()
+			p.selected(index)
+		}
+	})
+	table.SetInputCapture(p.onKey)
+
+	help := tview.NewTextView().
+		SetText("Enter:open e:edit n:new i:import c:copy d:delete K/J:move /:search")
+	help.SetTextColor(tcell.ColorGray)
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(filter, 1, 0, true).
+		AddItem(table, 0, 1, false).
+		AddItem(help, 1, 0, false)
+	flex.SetBorder(true).SetTitle(" Connections ")
+
+	p.Flex = flex
+	p.filter = filter
+	p.table = table
+}
+
+// SetItems sets the connections, the one at current being selected.
+func (p *ConnPicker) SetItems(items []ConnItem, current int) {
+	p.items = items
+	p.current = current
+	if p.keep >= 0 {
+		current, p.keep = p.keep, -1
+	}
+	p.render(current)
+}
+
+// Reset clears the search and focuses it.
+func (p *ConnPicker) Reset() {
+	p.filter.SetText("")
+	p.render(p.current)
+	p.setFocus(p.filter)
+}
+
+// SetSelectedFunc sets the function opening connection index.
+func (p *ConnPicker) SetSelectedFunc(f func(index int)) {
+	p.selected = f
+}
+
+// SetEditFunc sets the function editing connection index.
+func (p *ConnPicker) SetEditFunc(f func(index int)) {
+	p.edit = f
+}
+
+// SetAddFunc sets the function adding a connection.
+func (p *ConnPicker) SetAddFunc(f func()) {
+	p.add = f
+}
+
+// SetImportFunc sets the function importing connections.
+func (p *ConnPicker) SetImportFunc(f func()) {
+	p.load = f
+}
+
+// SetDuplicateFunc sets the function duplicating connection index.
+func (p *ConnPicker) SetDuplicateFunc(f func(index int)) {
+	p.duplicate = f
+}
+
+// SetDeleteFunc sets the function deleting connection index.
+func (p *ConnPicker) SetDeleteFunc(f func(index int)) {
+	p.remove = f
+}
+
+// SetMoveFunc sets the function moving connection index to the place of
+// connection to, its neighbour in the group.
+func (p *ConnPicker) SetMoveFunc(f func(index, to int)) {
+	p.move = f
+}
+
+func (p *ConnPicker) onKey(event *tcell.EventKey) *tcell.EventKey {
+	row, _ := p.table.GetSelection()
+	index := p.indexAt(row)
+	switch {
+	case event.Key() == tcell.KeyEscape:
+		p.close()
+	case event.Rune() == '/':
+		p.setFocus(p.filter)
+	case event.Rune() == 'n' && p.add != nil:
+		p.close()
+		p.add()
+	case event.Rune() == 'i' && p.load != nil:
+		p.close()
+		p.load()
+	case index < 0:
+		return event
+	case event.Rune() == 'e' && p.edit != nil:
+		p.close()
+		p.edit(index)
+	case event.Rune() == 'c' && p.duplicate != nil:
+		p.keep = index + 1
+		p.duplicate(index)
+	case event.Rune() == 'd' && p.remove != nil:
+		p.remove(index)
+	case event.Rune() == 'K' || event.Key() == tcell.KeyUp && event.Modifiers()&tcell.ModShift != 0:
+		p.moveBy(row, -1)
+	case event.Rune() == 'J' || event.Key() == tcell.KeyDown && event.Modifiers()&tcell.ModShift != 0:
+		p.moveBy(row, 1)
+	default:
+		return event
+	}
+	return nil
+}
+
+// moveBy moves the connection of row before or after its neighbour in the
+// group, as shown.
+func (p *ConnPicker) moveBy(row, delta int) {
+	if p.move == nil {
+		return
+	}
+	next := row + delta
+	if next < 0 || next >= len(p.rows) || p.rows[next].index < 0 {
+		return
+	}
+	p.keep = p.rows[next].index
+	p.move(p.rows[row].index, p.keep)
+}
+
+func (p *ConnPicker) indexAt(row int) int {
+	if row < 0 || row >= len(p.rows) {
+		return -1
+	}
+	return p.rows[row].index
+}
+
+// render fills the table, selecting connection index or, when it is not
+// shown, the first connection.
+func (p *ConnPicker) render(index int) {
+	p.rows = groupConnections(p.items, p.filter.GetText())
+	p.table.Clear()
+	selected := -1
+	for i, r := range p.rows {
+		if r.index < 0 {
+			group := r.group
+			if group == "" {
+				group = "(no group)"
+			}
+			p.table.SetCell(i, 0, tview.NewTableCell(tview.Escape(group)).
+				SetTextColor(tcell.ColorYellow).
+				SetSelectable(false))
+			p.table.SetCell(i, 1, tview.NewTableCell("").SetSelectable(false))
+			continue
+		}
+		c := p.items[r.index]
+		name := "  " + c.label()
+		if c.ReadOnly {
+			name += " [red](ro)[-]"
+		}
+		p.table.SetCell(i, 0, tview.NewTableCell(name).SetExpansion(1))
+		p.table.SetCell(i, 1, tview.NewTableCell(tview.Escape(c.Address)).SetTextColor(tcell.ColorGray))
+		if selected < 0 || r.index == index {
+			selected = i
+		}
+	}
+	if selected >= 0 {
+		p.table.Select(selected, 0)
+	}
+}
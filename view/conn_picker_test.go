@@ -0,0 +1,33 @@
+package view
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupConnections(t *testing.T) {
+	items := []ConnItem{
+		{Name: "laptop", Address: "127.0.0.1:6379"},
+		{Name: "prod-2", Address: "10.0.0.2:6379", Group: "prod"},
+		{Name: "stage", Address: "10.1.0.1:6379", Group: "staging"},
+		{Name: "prod-1", Address: "10.0.0.1:6379", Group: "prod"},
+	}
+	tests := []struct {
+		filter string
+		want   []connRow
+	}{
+		{"", []connRow{
+			{"prod", -1}, {"prod", 1}, {"prod", 3},
+			{"staging", -1}, {"staging", 2},
+			{"", -1}, {"", 0},
+		}},
+		{"PROD", []connRow{{"prod", -1}, {"prod", 1}, {"prod", 3}}},
+		{"127.0", []connRow{{"", 0}}},
+		{"none", nil},
+	}
+	for _, tt := range tests {
+		if got := groupConnections(items, tt.filter); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("groupConnections(%q) = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
@@ -3,17 +3,24 @@ package view
 import (
 	"strings"
 
-	"github.com/liwnn/redisterm/tlog"
-
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
 type Setting struct {
 	Name string
-	Host string
-	Port string
-	Auth string
+	// Address is host:port or a redis://, rediss:// or unix:// URL.
+	Address string
+	Auth    string
+	// AuthRef reads the password from a source instead of Auth, such as
+	// env:NAME, cmd:COMMAND or vault:NAME.
+	AuthRef string
+	// ReadOnly refuses the commands changing the database.
+	ReadOnly bool
+	// Group is the folder of the connection in the picker, Color its
+	// colour tag, one of TagColors.
+	Group string
+	Color string
 }
 
 type ConnSetting struct {
@@ -47,15 +54,19 @@ func Center(width, height int, p tview.Primitive) *tview.Flex {
 func (s *ConnSetting) init() {
 	form := tview.NewForm().
 		AddInputField("Name:", "", 20, nil, nil).
-		AddInputField("Address:", "", 20, nil, nil).
+		AddInputField("Address:", "", 0, nil, nil).
 		AddPasswordField("Auth:", "", 20, '*', nil).
+		AddInputField("Auth source:", "", 0, nil, nil).
+		AddCheckbox("Read-only:", false, nil).
+		AddInputField("Group:", "", 20, nil, nil).
+		AddDropDown("Color:", colorOptions(), 0, nil).
 		AddButton("  OK  ", s.OnOk).
 		AddButton("Cancel", s.OnCancel)
 	form.SetButtonsAlign(tview.AlignCenter)
 	form.SetFieldTextColor(ThemeControlFG)
 	form.SetFieldBackgroundColor(ThemeControlBG)
 	form.SetBorder(true).SetTitle("Connection setting")
-	p := Center(36, 15, form)
+	p := Center(48, 23, form)
 	p.SetMouseCapture(s.onMousecapture)
 	s.Primitive = p
 	s.form = form
@@ -66,15 +77,22 @@ func (s *ConnSetting) OnOk() {
 		name := s.form.GetFormItem(0).(*tview.InputField).GetText()
 		address := s.form.GetFormItem(1).(*tview.InputField).GetText()
 		auth := s.form.GetFormItem(2).(*tview.InputField).GetText()
-		t := strings.Split(address, ":")
-		if len(t) != 2 {
+		authRef := s.form.GetFormItem(3).(*tview.InputField).GetText()
+		readOnly := s.form.GetFormItem(4).(*tview.Checkbox).IsChecked()
+		group := s.form.GetFormItem(5).(*tview.InputField).GetText()
+		color, _ := s.form.GetFormItem(6).(*tview.DropDown).GetCurrentOption()
+		address = strings.TrimSpace(address)
+		if address == "" {
 			return
 		}
 		s.ok(Setting{
-			Name: name,
-			Host: t[0],
-			Port: t[1],
-			Auth: auth,
+			Name:     name,
+			Address:  address,
+			Auth:     auth,
+			AuthRef:  strings.TrimSpace(authRef),
+			ReadOnly: readOnly,
+			Group:    strings.TrimSpace(group),
+			Color:    TagColors[max(color, 0)],
 		}, s.edit)
 	}
 	s.Clear()
@@ -97,9 +115,26 @@ func (s *ConnSetting) SetEdit(edit bool) {
 
 func (s *ConnSetting) Init(c Setting) {
 	s.form.GetFormItem(0).(*tview.InputField).SetText(c.Name)
-	s.form.GetFormItem(1).(*tview.InputField).SetText(c.Host + ":" + c.Port)
-	tlog.Log("pass %v", c.Auth)
+	s.form.GetFormItem(1).(*tview.InputField).SetText(c.Address)
 	s.form.GetFormItem(2).(*tview.InputField).SetText(c.Auth)
+	s.form.GetFormItem(3).(*tview.InputField).SetText(c.AuthRef)
+	s.form.GetFormItem(4).(*tview.Checkbox).SetChecked(c.ReadOnly)
+	s.form.GetFormItem(5).(*tview.InputField).SetText(c.Group)
+	color := 0
+	for i, tag := range TagColors {
+		if tag == c.Color {
+			color = i
+		}
+	}
+	s.form.GetFormItem(6).(*tview.DropDown).SetCurrentOption(color)
+}
+
+// colorOptions returns the options of the colour drop down, TagColors with
+// "none" for no colour.
+func colorOptions() []string {
+	options := append([]string(nil), TagColors...)
+	options[0] = "none"
+	return options
 }
 
 func (s *ConnSetting) onMousecapture(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
@@ -0,0 +1,62 @@
+package view
+
+import (
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// DiffPane is one side of a DiffPreview. Changed marks the lines missing
+// from the other side.
+type DiffPane struct {
+	Title   string
+	Lines   []string
+	Changed []bool
+}
+
+// DiffPreview shows two values side by side, lines only in the left one
+// in red and lines only in the right one in green.
+type DiffPreview struct {
+	*tview.Flex
+	left, right *tview.TextView
+}
+
+// NewDiffPreview new
+func NewDiffPreview() *DiffPreview {
+	p := &DiffPreview{
+		left:  newDiffView(),
+		right: newDiffView(),
+	}
+	p.Flex = tview.NewFlex().
+		AddItem(p.left, 0, 1, false).
+		AddItem(p.right, 0, 1, false)
+	return p
+}
+
+func newDiffView() *tview.TextView {
+	v := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false)
+	v.SetBorder(true).SetBorderColor(ThemeBorder)
+	return v
+}
+
+// Update shows a on the left and b on the right.
+func (p *DiffPreview) Update(a, b DiffPane) {
+	p.show(p.left, a, "red")
+	p.show(p.right, b, "green")
+}
+
+func (p *DiffPreview) show(v *tview.TextView, pane DiffPane, color string) {
+	var sb strings.Builder
+	for i, line := range pane.Lines {
+		if i < len(pane.Changed) && pane.Changed[i] {
+			sb.WriteString("[" + color + "]" + tview.Escape(line) + "[-]\n")
+		} else {
+			sb.WriteString(tview.Escape(line) + "\n")
+		}
+	}
+	v.SetTitle(pane.Title)
+	v.SetText(sb.String())
+	v.ScrollToBeginning()
+}
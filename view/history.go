@@ -0,0 +1,104 @@
+package view
+
+import "strings"
+
+const historySize = 1000
+
+// History keeps the command lines entered in a console for navigation and
+// reverse search.
+type History struct {
+	lines []string
+	pos   int
+	draft string
+
+	onAdd func(string)
+}
+
+// NewHistory new
+func NewHistory(lines []string) *History {
+	if len(lines) > historySize {
+		lines = lines[len(lines)-historySize:]
+	}
+	return &History{
+		lines: lines,
+		pos:   len(lines),
+	}
+}
+
+// SetAddFunc set the function called with every line added to the history.
+func (h *History) SetAddFunc(f func(line string)) {
+	h.onAdd = f
+}
+
+// Add append a line, unless it is empty or repeats the last line.
+func (h *History) Add(line string) {
+	defer h.Reset()
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if len(h.lines) > 0 && h.lines[len(h.lines)-1] == line {
+		return
+	}
+	h.lines = append(h.lines, line)
+	if len(h.lines) > historySize {
+		h.lines = h.lines[len(h.lines)-historySize:]
+	}
+	if h.onAdd != nil {
+		h.onAdd(line)
+	}
+}
+
+// Reset moves the navigation position past the newest line.
+func (h *History) Reset() {
+	h.pos = len(h.lines)
+	h.draft = ""
+}
+
+// Prev returns the line before the navigation position. current is kept as
+// draft when navigation starts, so Next can restore it.
+func (h *History) Prev(current string) (string, bool) {
+	if h.pos == len(h.lines) {
+		h.draft = current
+	}
+	if h.pos == 0 {
+		return "", false
+	}
+	h.pos--
+	return h.lines[h.pos], true
+}
+
+// Next returns the line after the navigation position, or the draft.
+func (h *History) Next() (string, bool) {
+	if h.pos >= len(h.lines) {
+		return "", false
+	}
+	h.pos++
+	if h.pos == len(h.lines) {
+		return h.draft, true
+	}
+	return h.lines[h.pos], true
+}
+
+// Search returns the index of the newest line at or before from containing
+// query, or -1.
+func (h *History) Search(query string, from int) int {
+	if from >= len(h.lines) {
+		from = len(h.lines) - 1
+	}
+	for i := from; i >= 0; i-- {
+		if strings.Contains(h.lines[i], query) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Len return line count.
+func (h *History) Len() int {
+	return len(h.lines)
+}
+
+// Line return the line at index.
+func (h *History) Line(index int) string {
+	return h.lines[index]
+}
@@ -0,0 +1,122 @@
+package view
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// InfoMetric is a computed value shown with a sparkline of its history.
+type InfoMetric struct {
+	Name    string
+	Value   string
+	History []float64
+}
+
+// InfoGroup is an INFO section shown as a list of fields.
+type InfoGroup struct {
+	Name   string
+	Fields [][2]string
+}
+
+// InfoPanel shows server INFO as metrics with sparklines and sections.
+type InfoPanel struct {
+	*tview.Flex
+	metrics  *tview.TextView
+	sections *tview.TextView
+	title    string
+}
+
+// NewInfoPanel new
+func NewInfoPanel() *InfoPanel {
+	p := &InfoPanel{
+		title: "INFO",
+	}
+	p.init()
+	return p
+}
+
+func (p *InfoPanel) init() {
+	metrics := tview.NewTextView()
+	metrics.SetDynamicColors(true).SetWrap(false)
+
+	sections := tview.NewTextView()
+	sections.SetDynamicColors(true).SetScrollable(true).SetWrap(false)
+
+	flex := tview.NewFlex().
+		AddItem(metrics, 0, 1, false).
+		AddItem(sections, 0, 1, true)
+	flex.SetBorder(true)
+
+	p.Flex = flex
+	p.metrics = metrics
+	p.sections = sections
+}
+
+// Title return the page title.
+func (p *InfoPanel) Title() string {
+	return p.title
+}
+
+// SetStatus shows a status such as the refresh time or an error in the
+// border title.
+func (p *InfoPanel) SetStatus(status string) {
+	p.Flex.SetTitle(" " + tview.Escape(status) + " ")
+}
+
+// Update redraws the metrics and the sections.
+func (p *InfoPanel) Update(metrics []InfoMetric, groups []InfoGroup) {
+	width := 0
+	for _, m := range metrics {
+		if len(m.Name) > width {
+			width = len(m.Name)
+		}
+	}
+	var b strings.Builder
+	for _, m := range metrics {
+		fmt.Fprintf(&b, "[yellow]%-*s[white] %-12s [green]%s[white]\n", width, m.Name,
+			tview.Escape(m.Value), Sparkline(m.History, 30))
+	}
+	p.metrics.SetText(b.String())
+
+	b.Reset()
+	for _, g := range groups {
+		fmt.Fprintf(&b, "[yellow]# %s[white]\n", tview.Escape(g.Name))
+		for _, f := range g.Fields {
+			fmt.Fprintf(&b, "%s: %s\n", tview.Escape(f[0]), tview.Escape(f[1]))
+		}
+		b.WriteByte('\n')
+	}
+	row, col := p.sections.GetScrollOffset()
+	p.sections.SetText(b.String())
+	p.sections.ScrollTo(row, col)
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders the last width values as block characters scaled
+// between their minimum and maximum.
+func Sparkline(values []float64, width int) string {
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	s := make([]rune, 0, len(values))
+	for _, v := range values {
+		i := 0
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1))
+		}
+		s = append(s, sparkBlocks[i])
+	}
+	return string(s)
+}
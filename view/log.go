@@ -0,0 +1,128 @@
+package view
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/liwnn/redisterm/tlog"
+	"github.com/rivo/tview"
+)
+
+// consoleMaxRecords is the number of records kept by the console, the
+// oldest ones are dropped.
+const consoleMaxRecords = 5000
+
+// LogView is the CONSOLE, showing the log records at or above a level.
+// Keys: d debug, i info, w warn, e error, c clear.
+type LogView struct {
+	*tview.TextView
+	title string
+
+	mu      sync.Mutex
+	records []tlog.Record
+	level   slog.Level
+}
+
+// NewLogView new, showing the records at info level and above.
+func NewLogView() *LogView {
+	l := &LogView{
+		TextView: tview.NewTextView(),
+		title:    "CONSOLE",
+		level:    tlog.LevelInfo,
+	}
+	l.SetDynamicColors(true).
+		SetScrollable(true).
+		SetMaxLines(consoleMaxRecords).
+		SetBorder(true)
+	l.SetInputCapture(l.onKey)
+	l.updateTitle()
+	return l
+}
+
+// Title return the page title.
+func (l *LogView) Title() string {
+	return l.title
+}
+
+// Add appends a record, shown if at or above the level. It can be called
+// from any goroutine.
+func (l *LogView) Add(r tlog.Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, r)
+	if len(l.records) > consoleMaxRecords {
+		l.records = l.records[len(l.records)-consoleMaxRecords:]
+	}
+	if r.Level >= l.level {
+		l.write(r)
+	}
+}
+
+// SetLevel shows the records at level and above.
+func (l *LogView) SetLevel(level slog.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+	l.TextView.Clear()
+	for _, r := range l.records {
+		if r.Level >= level {
+			l.write(r)
+		}
+	}
+	l.ScrollToEnd()
+	l.updateTitle()
+}
+
+// Level returns the lowest level shown.
+func (l *LogView) Level() slog.Level {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.level
+}
+
+// Clear drops the records.
+func (l *LogView) Clear() *LogView {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = nil
+	l.TextView.Clear()
+	return l
+}
+
+func (l *LogView) onKey(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Rune() {
+	case 'd':
+		l.SetLevel(tlog.LevelDebug)
+	case 'i':
+		l.SetLevel(tlog.LevelInfo)
+	case 'w':
+		l.SetLevel(tlog.LevelWarn)
+	case 'e':
+		l.SetLevel(tlog.LevelError)
+	case 'c':
+		l.Clear()
+	default:
+		return event
+	}
+	return nil
+}
+
+func (l *LogView) write(r tlog.Record) {
+	color := "white"
+	switch {
+	case r.Level >= tlog.LevelError:
+		color = "red"
+	case r.Level >= tlog.LevelWarn:
+		color = "yellow"
+	case r.Level < tlog.LevelInfo:
+		color = "gray"
+	}
+	fmt.Fprintf(l.TextView, "[%v]%v[white]\n", color, tview.Escape(r.String()))
+}
+
+func (l *LogView) updateTitle() {
+	l.SetTitle(fmt.Sprintf(" %v (%v and above)  d/i/w/e:level c:clear ", l.title, strings.ToLower(l.level.String())))
+}
@@ -2,7 +2,6 @@ package view
 
 import (
 	"fmt"
-	"io"
 
 	"github.com/rivo/tview"
 )
@@ -14,13 +13,20 @@ type MainView struct {
 	leftFlexBox  *tview.Flex
 	rightFlexBox *tview.Flex
 	modal        *tview.Modal
+	menu         *tview.List
 
 	bottomPanel tview.Primitive
-	console     *tview.TextView
+	bottomPages *tview.Pages
+	bottomTabs  *tview.TextView
+	console     *LogView
+	stream      *StreamView
+	info        *InfoPanel
+	onBottom    func(title string)
 
 	opLine      *OpLine
 	cmdConsole  *CmdConsole
 	connSetting *ConnSetting
+	connPicker  *ConnPicker
 }
 
 // NewMainView new
@@ -35,11 +41,12 @@ func NewMainView() *MainView {
 func (m *MainView) init() {
 	m.opLine = NewOpLine()
 	m.opLine.SetSaveClickFunc(func() {
-		m.pages.ShowPage("conn_setting")
+		m.ShowConnSetting(Setting{}, false)
 	})
 	m.leftFlexBox = tview.NewFlex().SetDirection(tview.FlexRow)
 	m.rightFlexBox = tview.NewFlex().SetDirection(tview.FlexRow)
 	m.modal = m.createModal()
+	m.menu = newMenu()
 	mainFlexBox := tview.NewFlex().SetDirection(tview.FlexColumn).
 		AddItem(m.leftFlexBox, 0, 1, true).
 		AddItem(m.rightFlexBox, 0, 4, false)
@@ -47,8 +54,16 @@ func (m *MainView) init() {
 	m.connSetting.SetCancelHandler(func() {
 		m.pages.HidePage("conn_setting")
 	})
+	m.connPicker = NewConnPicker(func(p tview.Primitive) {
+		m.SetFocus(p)
+	}, func() {
+		m.pages.HidePage("picker")
+	})
+	m.opLine.SetPickFunc(m.ShowConnPicker)
 	m.pages = tview.NewPages()
 	m.pages.AddPage("main", mainFlexBox, true, true)
+	// below the modal, which confirms the deletes of the picker.
+	m.pages.AddPage("picker", Center(70, 20, m.connPicker), true, false)
 	m.pages.AddPage("modal", m.modal, true, false)
 	m.pages.AddPage("conn_setting", m.connSetting, true, false)
 
@@ -84,11 +99,33 @@ func (m *MainView) ShowModal(text string, okFunc func()) {
 	m.pages.ShowPage("modal")
 }
 
+// ShowModalChoice shows text with Ok and Cancel, done tells which one was
+// pressed once the modal is hidden.
+func (m *MainView) ShowModalChoice(text string, done func(ok bool)) {
+	m.modal.ClearButtons()
+	m.modal.AddButtons([]string{"Ok", "Cancel"})
+	m.modal.SetText(text).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			m.pages.HidePage("modal")
+			done(buttonIndex == 0)
+		})
+	m.pages.ShowPage("modal")
+}
+
 func (m *MainView) ShowModalOK(text string) {
+	m.ShowModalOKFunc(text, nil)
+}
+
+// ShowModalOKFunc shows text with Ok, calling done once the modal is
+// hidden.
+func (m *MainView) ShowModalOKFunc(text string, done func()) {
 	m.modal.ClearButtons()
 	m.modal.AddButtons([]string{"Ok"})
 	m.modal.SetText(text).SetDoneFunc(func(buttonIndex int, buttonLabel string) {
 		m.pages.HidePage("modal")
+		if done != nil {
+			done()
+		}
 	})
 	m.pages.ShowPage("modal")
 }
@@ -98,12 +135,24 @@ func (m *MainView) Run() error {
 	return m.SetRoot(m.pages, true).EnableMouse(true).Run()
 }
 
-func (m *MainView) RefreshOpLine(names []string, handler func(index int)) {
-	m.opLine.ClearAllSelect()
-	for _, name := range names {
-		m.opLine.AddSelect(name)
-	}
+// RefreshOpLine sets the connections of the server line and the picker,
+// current being the shown one, handler being called when one is selected.
+func (m *MainView) RefreshOpLine(items []ConnItem, current int, handler func(index int)) {
+	m.opLine.SetItems(items, current)
 	m.opLine.SetSelectedFunc(handler)
+	m.connPicker.SetItems(items, m.opLine.GetSelect())
+}
+
+// ShowConnPicker shows the connection picker, its search focused.
+func (m *MainView) ShowConnPicker() {
+	m.connPicker.SetItems(m.opLine.Items(), m.opLine.GetSelect())
+	m.pages.ShowPage("picker")
+	m.connPicker.Reset()
+}
+
+// GetConnPicker returns the connection picker.
+func (m *MainView) GetConnPicker() *ConnPicker {
+	return m.connPicker
 }
 
 func (m *MainView) GetOpLine() *OpLine {
@@ -121,7 +170,7 @@ func (m *MainView) SetTree(tree *tview.TreeView) {
 	opBar.AddItem(nil, 2, 0, false)
 	opBar.AddItem(m.opLine.editBtn, 5, 0, false)
 	m.leftFlexBox.AddItem(opBar, 1, 0, false)
-	m.leftFlexBox.AddItem(m.opLine.selectDrop, 1, 0, false)
+	m.leftFlexBox.AddItem(m.opLine.selectText, 1, 0, false)
 	m.leftFlexBox.AddItem(tree, 0, 1, true)
 }
 
@@ -131,7 +180,8 @@ func (m *MainView) SetPreview(preview *tview.Flex) {
 	m.rightFlexBox.AddItem(m.bottomPanel, 0, 1, false)
 }
 
-func (m *MainView) GetOutput() io.Writer {
+// GetConsole returns the CONSOLE showing the log.
+func (m *MainView) GetConsole() *LogView {
 	return m.console
 }
 
@@ -139,6 +189,31 @@ func (m *MainView) GetCmd() *CmdConsole {
 	return m.cmdConsole
 }
 
+func (m *MainView) GetStream() *StreamView {
+	return m.stream
+}
+
+func (m *MainView) GetInfo() *InfoPanel {
+	return m.info
+}
+
+// SetBottomPageFunc set the function called when the bottom panel switches
+// to another page.
+func (m *MainView) SetBottomPageFunc(f func(title string)) {
+	m.onBottom = f
+}
+
+// AddBottomPage add a page with a tab to the bottom panel.
+func (m *MainView) AddBottomPage(title string, p tview.Primitive) {
+	m.bottomPages.AddPage(title, p, true, false)
+	fmt.Fprintf(m.bottomTabs, `["%v"][slategrey]%s[white][""] `, title, title)
+}
+
+// ShowBottomPage switch the bottom panel to the page with title.
+func (m *MainView) ShowBottomPage(title string) {
+	m.bottomTabs.Highlight(title)
+}
+
 func (m *MainView) createBottom() tview.Primitive {
 	pages := tview.NewPages()
 
@@ -147,20 +222,20 @@ func (m *MainView) createBottom() tview.Primitive {
 		SetRegions(true).
 		SetWrap(false).
 		SetHighlightedFunc(func(added, removed, remaining []string) {
-			pages.SwitchToPage(added[0])
+			if len(added) > 0 {
+				pages.SwitchToPage(added[0])
+				if m.onBottom != nil {
+					m.onBottom(added[0])
+				}
+			}
 		})
 
 	{
-		title := "CONSOLE"
-		console := tview.NewTextView()
-		console.
-			SetScrollable(true).
-			SetTitle(title).
-			SetBorder(true)
+		console := NewLogView()
 		m.console = console
-		pages.GetPageCount()
-		pages.AddPage(title, console, true, true)
-		fmt.Fprintf(info, `["%v"][slategrey]%s[white][""] `, title, title)
+
+		pages.AddPage(console.Title(), console, true, true)
+		fmt.Fprintf(info, `["%v"][slategrey]%s[white][""] `, console.Title(), console.Title())
 	}
 
 	{
@@ -171,7 +246,27 @@ func (m *MainView) createBottom() tview.Primitive {
 		fmt.Fprintf(info, `["%v"][slategrey]%s[white][""] `, cmd.Title(), cmd.Title())
 	}
 
+	{
+		stream := NewStreamView(func(p tview.Primitive) {
+			m.SetFocus(p)
+		})
+		m.stream = stream
+
+		pages.AddPage(stream.Title(), stream, true, false)
+		fmt.Fprintf(info, `["%v"][slategrey]%s[white][""] `, stream.Title(), stream.Title())
+	}
+
+	{
+		panel := NewInfoPanel()
+		m.info = panel
+
+		pages.AddPage(panel.Title(), panel, true, false)
+		fmt.Fprintf(info, `["%v"][slategrey]%s[white][""] `, panel.Title(), panel.Title())
+	}
+
 	info.Highlight("CONSOLE")
+	m.bottomPages = pages
+	m.bottomTabs = info
 
 	layout := tview.NewFlex().
 		SetDirection(tview.FlexRow).
@@ -0,0 +1,66 @@
+package view
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// MenuItem is an entry of the action menu.
+type MenuItem struct {
+	Label  string
+	Action func()
+}
+
+func newMenu() *tview.List {
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetMainTextColor(ThemeControlFG)
+	list.SetSelectedBackgroundColor(ThemeBtnRenameBG)
+	list.SetSelectedTextColor(ThemeBtnRenameFG)
+	list.SetBorder(true).SetBorderColor(ThemeBorder)
+	return list
+}
+
+// ShowMenu show a list of actions, Esc closes it.
+func (m *MainView) ShowMenu(title string, items []MenuItem) {
+	m.menu.Clear()
+	m.menu.SetTitle(" " + tview.Escape(title) + " ")
+	for i, item := range items {
+		action := item.Action
+		shortcut := rune(0)
+		if i < 9 {
+			shortcut = rune('1' + i)
+		}
+		m.menu.AddItem(tview.Escape(item.Label), "", shortcut, func() {
+			m.pages.HidePage("menu")
+			if action != nil {
+				action()
+			}
+		})
+	}
+	m.menu.SetDoneFunc(func() {
+		m.pages.HidePage("menu")
+	})
+	m.menu.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			m.pages.HidePage("menu")
+			return nil
+		}
+		return event
+	})
+	m.pages.RemovePage("menu")
+	m.pages.AddPage("menu", Center(50, len(items)+2, m.menu), true, true)
+}
+
+// ShowProgress show a progress modal, cancel is called by its Cancel
+// button. Hide the modal with Progress.Hide.
+func (m *MainView) ShowProgress(text string, cancel func()) *Progress {
+	p := newProgress()
+	p.cancel = cancel
+	p.hide = func() {
+		m.pages.RemovePage("progress")
+	}
+	p.Update(text, 0, 0)
+	m.pages.RemovePage("progress")
+	m.pages.AddPage("progress", p, true, true)
+	return p
+}
@@ -7,11 +7,17 @@ import (
 
 type OpLine struct {
 	*tview.Flex
-	selectDrop  *tview.DropDown
+	selectText  *tview.TextView
 	saveBtn     *tview.Button
 	editBtn     *tview.Button
 	saveHandler func()
 	editHandler func()
+	pickHandler func()
+	selected    func(index int)
+
+	items    []ConnItem
+	current  int
+	readOnly bool
 }
 
 func NewOpLine() *OpLine {
@@ -21,14 +27,23 @@ func NewOpLine() *OpLine {
 }
 
 func (o *OpLine) init() {
-	drop := tview.NewDropDown().SetLabel("Select server:")
-	drop.SetLabelColor(ThemeControlFG)
-	drop.SetFieldTextColor(ThemeControlFG)
-	drop.SetFieldBackgroundColor(ThemeControlBG)
-	// 调整下拉列表样式以避免高亮浅色
-	normal := tcell.StyleDefault.Foreground(ThemeControlFG).Background(ThemeControlBG)
-	selected := tcell.StyleDefault.Foreground(ThemeControlFG).Background(ThemeBtnRenameBG)
-	drop.SetListStyles(normal, selected)
+	// the shown server, opening the connection picker.
+	selectText := tview.NewTextView().SetDynamicColors(true).SetWrap(false)
+	selectText.SetBackgroundColor(ThemeControlBG)
+	selectText.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEnter || event.Rune() == ' ' {
+			o.pick()
+			return nil
+		}
+		return event
+	})
+	selectText.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		if action == tview.MouseLeftDown {
+			o.pick()
+			return action, nil
+		}
+		return action, event
+	})
 
 	saveBtn := tview.NewButton(" + ")
 	saveBtn.SetBackgroundColor(ThemeBtnRenameBG)
@@ -50,42 +65,52 @@ func (o *OpLine) init() {
 
 	flex := tview.NewFlex().
 		SetDirection(tview.FlexColumn).
-		AddItem(drop, 0, 1, false).
+		AddItem(selectText, 0, 1, false).
 		AddItem(saveBtn, 3, 1, false).
 		AddItem(editBtn, 3, 1, false)
 
 	o.Flex = flex
-	o.selectDrop = drop
+	o.selectText = selectText
 	o.saveBtn = saveBtn
 	o.editBtn = editBtn
+	o.render()
 }
 
-// AddSelect add select
-func (o *OpLine) AddSelect(text string) {
-	o.selectDrop.AddOption(text, nil)
+// SetItems sets the connections, current being the selected one, without
+// calling the selected function.
+func (o *OpLine) SetItems(items []ConnItem, current int) {
+	o.items = items
+	o.current = min(max(current, 0), len(items)-1)
+	o.render()
 }
-func (o *OpLine) ClearAllSelect() {
-	o.selectDrop.SetOptions(nil, nil)
+
+// Items returns the connections.
+func (o *OpLine) Items() []ConnItem {
+	return o.items
 }
 
-// Select db
+// Select selects connection index and calls the selected function.
 func (o *OpLine) Select(index int) {
-	o.selectDrop.SetCurrentOption(index)
+	if index < 0 || index >= len(o.items) {
+		return
+	}
+	o.current = index
+	o.render()
+	if o.selected != nil {
+		o.selected(index)
+	}
 }
 
 func (o *OpLine) GetOptionCount() int {
-	return o.selectDrop.GetOptionCount()
+	return len(o.items)
 }
 
 func (o *OpLine) GetSelect() int {
-	index, _ := o.selectDrop.GetCurrentOption()
-	return index
+	return o.current
 }
 
 func (o *OpLine) SetSelectedFunc(handler func(index int)) {
-	o.selectDrop.SetSelectedFunc(func(text string, index int) {
-		handler(index)
-	})
+	o.selected = handler
 }
 
 func (o *OpLine) SetSaveClickFunc(handler func()) {
@@ -95,3 +120,32 @@ func (o *OpLine) SetSaveClickFunc(handler func()) {
 func (o *OpLine) SetEditClickFunc(handler func()) {
 	o.editHandler = handler
 }
+
+// SetPickFunc sets the function showing the connection picker.
+func (o *OpLine) SetPickFunc(handler func()) {
+	o.pickHandler = handler
+}
+
+func (o *OpLine) pick() {
+	if o.pickHandler != nil {
+		o.pickHandler()
+	}
+}
+
+// SetReadOnly marks the selected server as read-only.
+func (o *OpLine) SetReadOnly(readOnly bool) {
+	o.readOnly = readOnly
+	o.render()
+}
+
+func (o *OpLine) render() {
+	label := "[white]Select server:"
+	if o.readOnly {
+		label = "[" + ThemeReadOnlyFG.String() + "]Select server (read-only):"
+	}
+	name := ""
+	if o.current >= 0 && o.current < len(o.items) {
+		name = o.items[o.current].label()
+	}
+	o.selectText.SetText(label + "[white] " + name + " [white]▾")
+}
@@ -18,11 +18,15 @@ type Preview struct {
 	delBtn    *tview.Button
 	reloadBtn *tview.Button
 	renameBtn *tview.Button
+	actionBtn *tview.Button
 	keyInput  *tview.InputField
 	grid      *tview.Grid
+	// readOnly hides the buttons changing the database.
+	readOnly bool
 
 	textPreview  *TextPreview
 	tablePreview *TablePreview
+	diffPreview  *DiffPreview
 }
 
 // NewPreview new
@@ -51,9 +55,12 @@ func NewPreview() *Preview {
 	renameBtn := tview.NewButton("Rename")
 	renameBtn.SetBackgroundColor(ThemeBtnRenameBG)
 	renameBtn.SetLabelColor(ThemeBtnRenameFG)
+	actionBtn := tview.NewButton("Actions")
+	actionBtn.SetBackgroundColor(ThemeBtnRenameBG)
+	actionBtn.SetLabelColor(ThemeBtnRenameFG)
 	grid := tview.NewGrid().
 		SetRows(-1).
-		SetColumns(16, 16, 10, 10, 30, 10, -1).
+		SetColumns(16, 16, 10, 10, 30, 10, 10, -1).
 		SetBorders(false).
 		SetGap(0, 2).
 		SetMinSize(5, 5)
@@ -77,11 +84,13 @@ func NewPreview() *Preview {
 		delBtn:    delBtn,
 		reloadBtn: reloadBtn,
 		renameBtn: renameBtn,
+		actionBtn: actionBtn,
 		keyInput:  keyInput,
 		grid:      grid,
 
 		textPreview:  NewTextPreview(),
 		tablePreview: NewTablePreview(),
+		diffPreview:  NewDiffPreview(),
 	}
 	p.init()
 	return p
@@ -150,10 +159,14 @@ func (p *Preview) SetTypeText(text string) {
 func (p *Preview) SetOpBtnVisible(visible bool) {
 	if visible {
 		p.grid.AddItem(p.reloadBtn, 0, 2, 1, 1, 0, 0, false)
-		p.grid.AddItem(p.delBtn, 0, 3, 1, 1, 0, 0, false)
+		if !p.readOnly {
+			p.grid.AddItem(p.delBtn, 0, 3, 1, 1, 0, 0, false)
+		}
+		p.grid.AddItem(p.actionBtn, 0, 6, 1, 1, 0, 0, false)
 	} else {
 		p.grid.RemoveItem(p.reloadBtn)
 		p.grid.RemoveItem(p.delBtn)
+		p.grid.RemoveItem(p.actionBtn)
 	}
 }
 
@@ -175,7 +188,9 @@ func (p *Preview) SetSaveFunc(f func(oldValue, newValue string)) {
 func (p *Preview) SetKey(text string) {
 	if len(text) > 0 {
 		p.grid.AddItem(p.keyInput, 0, 4, 1, 1, 0, 0, false)
-		p.grid.AddItem(p.renameBtn, 0, 5, 1, 1, 0, 0, false)
+		if !p.readOnly {
+			p.grid.AddItem(p.renameBtn, 0, 5, 1, 1, 0, 0, false)
+		}
 		p.keyInput.SetText(text)
 	} else {
 		p.grid.RemoveItem(p.keyInput)
@@ -193,6 +208,11 @@ func (p *Preview) SetReloadFunc(f func()) {
 	p.reloadBtn.SetSelectedFunc(f)
 }
 
+// SetActionFunc set the function of the Actions button
+func (p *Preview) SetActionFunc(f func()) {
+	p.actionBtn.SetSelectedFunc(f)
+}
+
 // SetRenameFunc set rename function
 func (p *Preview) SetRenameFunc(f func()) {
 	p.renameBtn.SetSelectedFunc(f)
@@ -208,5 +228,22 @@ func (p *Preview) ShowText(text string, showSave bool) {
 	p.showFlex.Clear()
 	p.showFlex.AddItem(p.textPreview, 0, 1, false)
 	p.textPreview.SetText(text)
-	p.textPreview.ShowSaveGrid(showSave)
+	p.textPreview.ShowSaveGrid(showSave && !p.readOnly)
+}
+
+// SetReadOnly hides the Delete, Rename and Save buttons when set.
+func (p *Preview) SetReadOnly(readOnly bool) {
+	p.readOnly = readOnly
+	if readOnly {
+		p.grid.RemoveItem(p.delBtn)
+		p.grid.RemoveItem(p.renameBtn)
+		p.textPreview.ShowSaveGrid(false)
+	}
+}
+
+// ShowDiff shows the values of a key on two databases side by side.
+func (p *Preview) ShowDiff(a, b DiffPane) {
+	p.showFlex.Clear()
+	p.showFlex.AddItem(p.diffPreview, 0, 1, false)
+	p.diffPreview.Update(a, b)
 }
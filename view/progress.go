@@ -0,0 +1,66 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// Progress is a modal showing the progress of a long running operation
+// with a Cancel button.
+type Progress struct {
+	tview.Primitive
+	text   *tview.TextView
+	bar    *tview.TextView
+	form   *tview.Form
+	cancel func()
+	hide   func()
+}
+
+func newProgress() *Progress {
+	p := &Progress{}
+	text := tview.NewTextView().SetTextAlign(tview.AlignCenter)
+	bar := tview.NewTextView().SetTextAlign(tview.AlignCenter).SetDynamicColors(true)
+	form := tview.NewForm().
+		AddButton("Cancel", func() {
+			if p.cancel != nil {
+				p.cancel()
+			}
+		})
+	form.SetButtonsAlign(tview.AlignCenter)
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(text, 0, 1, false).
+		AddItem(bar, 1, 0, false).
+		AddItem(form, 3, 0, true)
+	flex.SetBorder(true).SetBorderColor(ThemeBorder)
+
+	p.Primitive = Center(60, 9, flex)
+	p.text = text
+	p.bar = bar
+	p.form = form
+	return p
+}
+
+// Update set the progress text; total <= 0 shows only the count done.
+func (p *Progress) Update(text string, done, total int) {
+	p.text.SetText(text)
+	if total <= 0 {
+		p.bar.SetText(fmt.Sprintf("%d", done))
+		return
+	}
+	const width = 40
+	if done > total {
+		done = total
+	}
+	n := done * width / total
+	p.bar.SetText(fmt.Sprintf("[green]%s[gray]%s[white] %d/%d",
+		strings.Repeat("█", n), strings.Repeat("░", width-n), done, total))
+}
+
+// Hide close the modal.
+func (p *Progress) Hide() {
+	p.hide()
+}
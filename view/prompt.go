@@ -0,0 +1,67 @@
+package view
+
+import (
+	"github.com/rivo/tview"
+)
+
+// PromptField is an input of a prompt: a text field, or a drop down when
+// Options is set, Text being the selected option. Mask hides the text, as
+// for passwords.
+type PromptField struct {
+	Label   string
+	Text    string
+	Options []string
+	Mask    bool
+}
+
+// ShowPrompt shows a form with fields. ok receives the values of the
+// fields when OK is pressed, Esc and Cancel close it.
+func (m *MainView) ShowPrompt(title string, fields []PromptField, ok func(values []string)) {
+	form := tview.NewForm()
+	width := 0
+	for _, f := range fields {
+		width = max(width, len(f.Label))
+	}
+	for _, f := range fields {
+		if f.Mask {
+			form.AddPasswordField(f.Label, f.Text, 40, '*', nil)
+			continue
+		}
+		if len(f.Options) == 0 {
+			form.AddInputField(f.Label, f.Text, 40, nil, nil)
+			continue
+		}
+		initial := 0
+		for i, option := range f.Options {
+			if option == f.Text {
+				initial = i
+			}
+		}
+		form.AddDropDown(f.Label, f.Options, initial, nil)
+	}
+	hide := func() {
+		m.pages.RemovePage("prompt")
+	}
+	form.AddButton("  OK  ", func() {
+		values := make([]string, len(fields))
+		for i := range fields {
+			switch item := form.GetFormItem(i).(type) {
+			case *tview.InputField:
+				values[i] = item.GetText()
+			case *tview.DropDown:
+				_, values[i] = item.GetCurrentOption()
+			}
+		}
+		hide()
+		ok(values)
+	})
+	form.AddButton("Cancel", hide)
+	form.SetCancelFunc(hide)
+	form.SetButtonsAlign(tview.AlignCenter)
+	form.SetFieldTextColor(ThemeControlFG)
+	form.SetFieldBackgroundColor(ThemeControlBG)
+	form.SetBorder(true).SetTitle(" " + tview.Escape(title) + " ")
+
+	m.pages.RemovePage("prompt")
+	m.pages.AddPage("prompt", Center(width+48, len(fields)*2+5, form), true, true)
+}
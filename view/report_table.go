@@ -0,0 +1,274 @@
+package view
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ReportColumn is a column of a ReportTable. Numeric columns sort by value
+// and accept size suffixes such as 1.5K or 20M.
+type ReportColumn struct {
+	Name      string
+	Expansion int
+	Numeric   bool
+}
+
+// ReportTable is a sortable, filterable table with action buttons, used by
+// the report pages of the bottom panel. Keys 1-9 sort by a column, pressing
+// the same key again reverses the order; / focuses the filter.
+type ReportTable struct {
+	*tview.Flex
+	table   *tview.Table
+	filter  *tview.InputField
+	buttons *tview.Flex
+	status  *tview.TextView
+
+	title      string
+	columns    []ReportColumn
+	rows       []Row
+	shown      []Row
+	sortColumn int
+	sortDesc   bool
+	setFocus   func(p tview.Primitive)
+	onSelected func(row Row)
+}
+
+// NewReportTable new, setFocus moves the focus between the table and the
+// filter input.
+func NewReportTable(title string, setFocus func(p tview.Primitive)) *ReportTable {
+	t := &ReportTable{
+		title:      title,
+		sortColumn: -1,
+		setFocus:   setFocus,
+	}
+	t.init()
+	return t
+}
+
+func (t *ReportTable) init() {
+	style := tcell.Style{}
+	table := tview.NewTable()
+	table.SetBorders(false).
+		SetSelectable(true, false).
+		SetSeparator(' ').
+		SetFixed(1, 0).
+		SetSelectedStyle(style.Foreground(tcell.ColorWhite).
+			Background(ThemeControlBG).
+			Attributes(tcell.AttrBold))
+	table.SetInputCapture(t.onKey)
+	table.SetSelectedFunc(func(row, column int) {
+		if t.onSelected != nil && row > 0 && row <= len(t.shown) {
+			t.onSelected(t.shown[row-1])
+		}
+	})
+
+	filter := tview.NewInputField().SetLabel("Filter: ")
+	filter.SetFieldStyle(tcell.StyleDefault.Foreground(ThemeControlFG).Background(ThemeControlBG))
+	filter.SetChangedFunc(func(string) {
+		t.refresh()
+	})
+	filter.SetDoneFunc(func(tcell.Key) {
+		t.setFocus(t.table)
+	})
+
+	status := tview.NewTextView()
+	status.SetTextColor(tcell.ColorGray)
+
+	buttons := tview.NewFlex()
+	bar := tview.NewFlex().
+		AddItem(filter, 30, 0, false).
+		AddItem(nil, 1, 0, false).
+		AddItem(buttons, 0, 1, false).
+		AddItem(status, 0, 1, false)
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(bar, 1, 0, false).
+		AddItem(table, 0, 1, true)
+	flex.SetBorder(true)
+
+	t.Flex = flex
+	t.table = table
+	t.filter = filter
+	t.buttons = buttons
+	t.status = status
+}
+
+// Title return the page title.
+func (t *ReportTable) Title() string {
+	return t.title
+}
+
+// SetColumns set the columns.
+func (t *ReportTable) SetColumns(columns []ReportColumn) {
+	t.columns = columns
+}
+
+// AddButton add an action button next to the filter.
+func (t *ReportTable) AddButton(label string, f func()) {
+	btn := tview.NewButton(label)
+	btn.SetBackgroundColor(ThemeBtnRenameBG)
+	btn.SetLabelColor(ThemeBtnRenameFG)
+	btn.SetSelectedFunc(f)
+	t.buttons.AddItem(btn, len(label)+2, 0, false)
+	t.buttons.AddItem(nil, 1, 0, false)
+}
+
+// SetSelectedFunc set the function called when Enter is pressed on a row.
+func (t *ReportTable) SetSelectedFunc(f func(row Row)) {
+	t.onSelected = f
+}
+
+// SetStatus shows a short text after the buttons.
+func (t *ReportTable) SetStatus(text string) {
+	t.status.SetText(text)
+}
+
+// SetRows replace the rows, keeping the sort order and the filter.
+func (t *ReportTable) SetRows(rows []Row) {
+	t.rows = rows
+	t.refresh()
+}
+
+// SortBy sort rows by column.
+func (t *ReportTable) SortBy(column int, desc bool) {
+	t.sortColumn = column
+	t.sortDesc = desc
+	t.refresh()
+}
+
+// GetSelectedRow return the selected row, or nil.
+func (t *ReportTable) GetSelectedRow() Row {
+	row, _ := t.table.GetSelection()
+	if row <= 0 || row > len(t.shown) {
+		return nil
+	}
+	return t.shown[row-1]
+}
+
+func (t *ReportTable) onKey(event *tcell.EventKey) *tcell.EventKey {
+	r := event.Rune()
+	switch {
+	case r >= '1' && r <= '9':
+		column := int(r - '1')
+		if column < len(t.columns) {
+			t.SortBy(column, column == t.sortColumn && !t.sortDesc)
+		}
+		return nil
+	case r == '/':
+		t.setFocus(t.filter)
+		return nil
+	}
+	return event
+}
+
+func (t *ReportTable) refresh() {
+	filter := strings.ToLower(t.filter.GetText())
+	t.shown = t.shown[:0]
+	for _, row := range t.rows {
+		if filter == "" || rowContains(row, filter) {
+			t.shown = append(t.shown, row)
+		}
+	}
+	if t.sortColumn >= 0 && t.sortColumn < len(t.columns) {
+		c, numeric, desc := t.sortColumn, t.columns[t.sortColumn].Numeric, t.sortDesc
+		sort.SliceStable(t.shown, func(i, j int) bool {
+			n := compareCells(cell(t.shown[i], c), cell(t.shown[j], c), numeric)
+			if desc {
+				return n > 0
+			}
+			return n < 0
+		})
+	}
+
+	row, _ := t.table.GetSelection()
+	t.table.Clear()
+	for i, c := range t.columns {
+		name := fmt.Sprintf("%d:%s", i+1, c.Name)
+		if i == t.sortColumn {
+			if t.sortDesc {
+				name += " ▼"
+			} else {
+				name += " ▲"
+			}
+		}
+		t.table.SetCell(0, i, tview.NewTableCell(name).
+			SetExpansion(c.Expansion).
+			SetSelectable(false).
+			SetTextColor(tcell.ColorYellow))
+	}
+	for i, r := range t.shown {
+		for j := range t.columns {
+			text := cell(r, j)
+			if len(text) > 1024 {
+				text = text[:1024]
+			}
+			t.table.SetCell(i+1, j, tview.NewTableCell(tview.Escape(text)).SetExpansion(t.columns[j].Expansion))
+		}
+	}
+	if row <= 0 {
+		row = 1
+	}
+	if row > len(t.shown) {
+		row = len(t.shown)
+	}
+	t.table.Select(row, 0)
+	t.Flex.SetTitle(fmt.Sprintf(" %s (%d/%d) ", t.title, len(t.shown), len(t.rows)))
+}
+
+func cell(row Row, column int) string {
+	if column < len(row) {
+		return row[column]
+	}
+	return ""
+}
+
+func compareCells(a, b string, numeric bool) int {
+	if !numeric {
+		return strings.Compare(a, b)
+	}
+	x, y := parseNumber(a), parseNumber(b)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func rowContains(row Row, filter string) bool {
+	for _, c := range row {
+		if strings.Contains(strings.ToLower(c), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNumber parses a number with an optional size suffix (K, M, G, T, P,
+// 1024 based) or unit, such as "1.5K", "20.0M" or "12ms".
+func parseNumber(s string) float64 {
+	s = strings.TrimSpace(s)
+	end := 0
+	for end < len(s) && (s[end] == '-' || s[end] == '.' || (s[end] >= '0' && s[end] <= '9')) {
+		end++
+	}
+	v, err := strconv.ParseFloat(s[:end], 64)
+	if err != nil {
+		return 0
+	}
+	if end < len(s) {
+		if i := strings.IndexByte("KMGTP", s[end]); i >= 0 {
+			for ; i >= 0; i-- {
+				v *= 1024
+			}
+		}
+	}
+	return v
+}
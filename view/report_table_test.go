@@ -0,0 +1,31 @@
+package view
+
+import "testing"
+
+func TestParseNumber(t *testing.T) {
+	tests := map[string]float64{
+		"12":    12,
+		"1.5K":  1536,
+		"2.0M":  2 * 1024 * 1024,
+		"300us": 300,
+		"-1":    -1,
+		"abc":   0,
+	}
+	for s, want := range tests {
+		if got := parseNumber(s); got != want {
+			t.Errorf("parseNumber(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := Sparkline([]float64{0, 7, 14}, 10); got != "▁▄█" {
+		t.Errorf("Sparkline = %q", got)
+	}
+	if got := Sparkline([]float64{5, 5, 1, 2, 3}, 2); got != "▁█" {
+		t.Errorf("Sparkline width = %q", got)
+	}
+	if got := Sparkline([]float64{3, 3}, 10); got != "▁▁" {
+		t.Errorf("Sparkline flat = %q", got)
+	}
+}
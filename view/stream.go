@@ -0,0 +1,201 @@
+package view
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const streamBufferSize = 5000
+
+// StreamMessage is a line shown by a StreamView.
+type StreamMessage struct {
+	Time    time.Time
+	Channel string
+	Text    string
+}
+
+// StreamView shows the messages of a SUBSCRIBE or MONITOR command.
+// Keys: p pause/resume, c clear, / filter channels, q or Esc stop.
+type StreamView struct {
+	*tview.Flex
+	view   *tview.TextView
+	filter *tview.InputField
+	status *tview.TextView
+
+	title    string
+	messages []StreamMessage
+	paused   bool
+	pending  int
+	running  bool
+	onStop   func()
+	setFocus func(p tview.Primitive)
+}
+
+// NewStreamView new, setFocus moves the focus between the messages and the
+// filter input.
+func NewStreamView(setFocus func(p tview.Primitive)) *StreamView {
+	s := &StreamView{
+		title:    "STREAM",
+		setFocus: setFocus,
+	}
+	s.init()
+	return s
+}
+
+func (s *StreamView) init() {
+	view := tview.NewTextView()
+	view.SetDynamicColors(true).SetScrollable(true).SetMaxLines(streamBufferSize)
+	view.SetInputCapture(s.onKey)
+
+	filter := tview.NewInputField().SetLabel("Filter channel: ")
+	filter.SetFieldStyle(tcell.StyleDefault.Foreground(ThemeControlFG).Background(ThemeControlBG))
+	filter.SetChangedFunc(func(string) {
+		s.render()
+	})
+	filter.SetDoneFunc(func(tcell.Key) {
+		s.setFocus(s.view)
+	})
+
+	status := tview.NewTextView()
+	status.SetTextColor(tcell.ColorGray)
+
+	bar := tview.NewFlex().
+		AddItem(status, 0, 1, false).
+		AddItem(filter, 40, 0, false)
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(view, 0, 1, true).
+		AddItem(bar, 1, 0, false)
+	flex.SetBorder(true)
+
+	s.Flex = flex
+	s.view = view
+	s.filter = filter
+	s.status = status
+	s.updateStatus()
+}
+
+// Title return the page title.
+func (s *StreamView) Title() string {
+	return s.title
+}
+
+// Start clears the view for a new stream. stop is called when the user
+// stops the stream.
+func (s *StreamView) Start(command string, stop func()) {
+	s.messages = s.messages[:0]
+	s.pending = 0
+	s.paused = false
+	s.running = true
+	s.onStop = stop
+	s.Flex.SetTitle(" " + tview.Escape(command) + " ")
+	s.render()
+}
+
+// AddMessage append a message, shown unless paused or filtered.
+func (s *StreamView) AddMessage(m StreamMessage) {
+	s.messages = append(s.messages, m)
+	if len(s.messages) > streamBufferSize {
+		s.messages = s.messages[len(s.messages)-streamBufferSize:]
+	}
+	if s.paused {
+		s.pending++
+		s.updateStatus()
+		return
+	}
+	if s.match(m) {
+		s.writeMessage(m)
+	}
+}
+
+// Stopped marks the stream as ended, with the error that ended it if any.
+func (s *StreamView) Stopped(err error) {
+	s.running = false
+	if err != nil {
+		fmt.Fprintf(s.view, "[red]%s[white]\n", tview.Escape(err.Error()))
+	}
+	s.updateStatus()
+}
+
+func (s *StreamView) onKey(event *tcell.EventKey) *tcell.EventKey {
+	switch {
+	case event.Key() == tcell.KeyEscape || event.Rune() == 'q':
+		if s.running && s.onStop != nil {
+			s.onStop()
+		}
+		return nil
+	case event.Rune() == 'p':
+		s.paused = !s.paused
+		if !s.paused {
+			s.pending = 0
+			s.render()
+		}
+		s.updateStatus()
+		return nil
+	case event.Rune() == 'c':
+		s.messages = s.messages[:0]
+		s.pending = 0
+		s.render()
+		return nil
+	case event.Rune() == '/':
+		s.setFocus(s.filter)
+		return nil
+	}
+	return event
+}
+
+// match reports whether a message passes the channel filter, a glob
+// pattern or a substring. Messages without channel, such as MONITOR
+// lines, are matched on their text.
+func (s *StreamView) match(m StreamMessage) bool {
+	filter := s.filter.GetText()
+	if filter == "" {
+		return true
+	}
+	target := m.Channel
+	if target == "" {
+		target = m.Text
+	}
+	if ok, err := path.Match(filter, target); err == nil && ok {
+		return true
+	}
+	return strings.Contains(target, filter)
+}
+
+func (s *StreamView) writeMessage(m StreamMessage) {
+	fmt.Fprintf(s.view, "[gray]%s[white] ", m.Time.Format("15:04:05.000"))
+	if m.Channel != "" {
+		fmt.Fprintf(s.view, "[yellow]%s[white] ", tview.Escape(m.Channel))
+	}
+	fmt.Fprintln(s.view, tview.Escape(m.Text))
+	s.view.ScrollToEnd()
+}
+
+func (s *StreamView) render() {
+	s.view.Clear()
+	for _, m := range s.messages {
+		if s.match(m) {
+			s.writeMessage(m)
+		}
+	}
+	s.updateStatus()
+}
+
+func (s *StreamView) updateStatus() {
+	var state string
+	switch {
+	case !s.running:
+		state = "stopped"
+	case s.paused:
+		state = fmt.Sprintf("paused (%d new)", s.pending)
+	default:
+		state = "running"
+	}
+	s.status.SetText(fmt.Sprintf("%s  p:pause c:clear /:filter q:stop", state))
+}
@@ -1,6 +1,12 @@
 package view
 
-import "github.com/gdamore/tcell/v2"
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gdamore/tcell/v2"
+)
 
 // 统一浅色主题，尽量在不同终端保持一致
 var (
@@ -13,6 +19,10 @@ var (
 	ThemeSizeBG  = tcell.GetColor("lightpink")
 	ThemeLabelFG = tcell.ColorBlack
 
+	ThemeMarkedFG = tcell.ColorFuchsia
+	// ThemeReadOnlyFG marks read-only connections.
+	ThemeReadOnlyFG = tcell.ColorRed
+
 	// Semantic button colors using 16-color palette for terminal consistency
 	ThemeBtnReloadBG = tcell.ColorBlue
 	ThemeBtnReloadFG = tcell.ColorWhite
@@ -21,3 +31,45 @@ var (
 	ThemeBtnRenameBG = tcell.ColorGreen
 	ThemeBtnRenameFG = tcell.ColorBlack
 )
+
+// themeColors are the colours of the theme by their name in the config.
+var themeColors = map[string]*tcell.Color{
+	"control_bg":    &ThemeControlBG,
+	"control_fg":    &ThemeControlFG,
+	"border":        &ThemeBorder,
+	"type_bg":       &ThemeTypeBG,
+	"size_bg":       &ThemeSizeBG,
+	"label_fg":      &ThemeLabelFG,
+	"marked_fg":     &ThemeMarkedFG,
+	"read_only_fg":  &ThemeReadOnlyFG,
+	"btn_reload_bg": &ThemeBtnReloadBG,
+	"btn_reload_fg": &ThemeBtnReloadFG,
+	"btn_delete_bg": &ThemeBtnDeleteBG,
+	"btn_delete_fg": &ThemeBtnDeleteFG,
+	"btn_rename_bg": &ThemeBtnRenameBG,
+	"btn_rename_fg": &ThemeBtnRenameFG,
+}
+
+// ApplyTheme overrides the colours of the theme by name with colour names
+// or #rrggbb values. It must be called before the views are created, the
+// unknown names and colours are returned as errors.
+func ApplyTheme(theme map[string]string) error {
+	var errs []error
+	for name, value := range theme {
+		c, ok := themeColors[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("theme: unknown colour %q", name))
+			continue
+		}
+		color := tcell.GetColor(value)
+		if color == tcell.ColorDefault {
+			errs = append(errs, fmt.Errorf("theme: %v: unknown colour value %q", name, value))
+			continue
+		}
+		*c = color
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Error() < errs[j].Error()
+	})
+	return errors.Join(errs...)
+}
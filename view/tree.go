@@ -1,6 +1,8 @@
 package view
 
 import (
+	"fmt"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -9,6 +11,7 @@ import (
 type Tree struct {
 	*tview.TreeView
 	lastNode *tview.TreeNode
+	onMark   func(node *tview.TreeNode)
 }
 
 // NewTree new
@@ -21,22 +24,39 @@ func NewTree(rootName string) *Tree {
 	t := &Tree{
 		TreeView: tree,
 	}
+	tree.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyRune && event.Rune() == ' ' && t.onMark != nil {
+			t.onMark(t.GetCurrentNode())
+			return nil
+		}
+		return event
+	})
 	return t
 }
 
-// AddNode add node
-func (t *Tree) AddNode(name string, reference interface{}) {
-	node := tview.NewTreeNode(name).SetSelectable(true)
+// AddNode add node. The name is shown verbatim, without style tags.
+func (t *Tree) AddNode(name string, reference interface{}) *tview.TreeNode {
+	node := tview.NewTreeNode(tview.Escape(name)).SetSelectable(true)
 	if reference != nil {
 		node.SetReference(reference)
 	}
 	node.SetColor(tcell.ColorGreen)
 	t.TreeView.GetCurrentNode().AddChild(node)
+	return node
+}
+
+// SetNodeText set node text, shown verbatim like AddNode.
+func (t *Tree) SetNodeText(node *tview.TreeNode, text string) {
+	node.SetText(tview.Escape(text))
 }
 
 // SetNodeRemoved set node removed
 func (t *Tree) SetNodeRemoved() {
-	node := t.GetCurrentNode()
+	t.SetRemoved(t.GetCurrentNode())
+}
+
+// SetRemoved shows node as removed.
+func (t *Tree) SetRemoved(node *tview.TreeNode) {
 	text := node.GetText() + " (Removed)"
 	node.SetText(text)
 	node.SetColor(tcell.ColorGray)
@@ -57,3 +77,26 @@ func (t *Tree) SetSelectedFunc(handler func(node *tview.TreeNode)) {
 		t.lastNode = node
 	})
 }
+
+// SetMarkFunc set the function called when space is pressed on a node.
+func (t *Tree) SetMarkFunc(handler func(node *tview.TreeNode)) {
+	t.onMark = handler
+}
+
+// SetNodeMarked shows node as marked for a bulk operation.
+func (t *Tree) SetNodeMarked(node *tview.TreeNode, marked bool) {
+	if marked {
+		node.SetColor(ThemeMarkedFG)
+	} else {
+		node.SetColor(tcell.ColorGreen)
+	}
+}
+
+// SetMarkCount shows the number of marked nodes in the title.
+func (t *Tree) SetMarkCount(n int) {
+	if n == 0 {
+		t.SetTitle("KEYS")
+	} else {
+		t.SetTitle(fmt.Sprintf("KEYS (%d marked)", n))
+	}
+}